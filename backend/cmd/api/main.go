@@ -1,8 +1,12 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jacob-fain/MRS/internal/database"
@@ -32,20 +36,47 @@ func main() {
 		log.Fatal("Failed to initialize auth service:", err)
 	}
 
-	// Initialize audit service
+	// Initialize audit service. The hash chain key has to live outside the database.
+	if os.Getenv("AUDIT_HASH_KEY") == "" {
+		log.Fatal("AUDIT_HASH_KEY environment variable not set")
+	}
 	auditService := services.NewAuditService(db)
 
+	// Initialize notifier
+	notifier := services.NewLogNotifier()
+
 	// Initialize TMDB service
 	tmdbService, err := services.NewTMDBService()
 	if err != nil {
 		log.Fatal("Failed to initialize TMDB service:", err)
 	}
 
-	// Initialize Plex service
-	plexService, err := services.NewPlexService()
+	// Cache TMDB responses
+	tmdbCache, err := services.NewCachedTMDBService(tmdbService, db)
 	if err != nil {
+		log.Fatal("Failed to initialize TMDB cache:", err)
+	}
+
+	// Initialize Plex service. It stays a nil interface when Plex isn't
+	// configured, a nil *PlexService would pass the services' nil checks.
+	var plexService services.PlexServiceInterface
+	if plex, err := services.NewPlexService(); err != nil {
 		log.Printf("Warning: Plex service initialization failed: %v", err)
 		log.Printf("Plex features will be disabled")
+	} else {
+		plexService = plex
+	}
+
+	// Initialize For You feed
+	forYouService, err := services.NewForYouService(db, tmdbCache, plexService)
+	if err != nil {
+		log.Fatal("Failed to initialize For You service:", err)
+	}
+
+	// Initialize watchlist import service
+	importService, err := services.NewImportService(tmdbCache, plexService)
+	if err != nil {
+		log.Fatal("Failed to initialize import service:", err)
 	}
 
 	// Initialize OMDB service
@@ -55,26 +86,102 @@ func main() {
 		log.Printf("Rating features will be disabled")
 	}
 
+	// Initialize retention service
+	retentionService, err := services.NewRetentionService(db, auditService)
+	if err != nil {
+		log.Fatal("Failed to initialize retention service:", err)
+	}
+
+	// Initialize stale request service
+	staleRequestService, err := services.NewStaleRequestService(db, auditService, notifier)
+	if err != nil {
+		log.Fatal("Failed to initialize stale request service:", err)
+	}
+
+	// Initialize stats service
+	statsService := services.NewStatsService(db, tmdbCache)
+
+	// Fill in the genres of requests created before they were recorded, so the
+	// stats' top genres include them
+	go func() {
+		filled, err := statsService.BackfillGenres(context.Background())
+		if err != nil {
+			log.Printf("Genre backfill failed: %v", err)
+		}
+		if filled > 0 {
+			log.Printf("Genre backfill: filled=%d", filled)
+		}
+	}()
+
+	// Initialize watchlist service
+	watchlistService := services.NewWatchlistService(db, tmdbCache, auditService, notifier)
+
+	// Start background jobs (set an interval to 0 to disable a job)
+	startScheduler([]scheduledJob{
+		{
+			name:     "retention",
+			interval: intervalFromEnv("RETENTION_INTERVAL_HOURS", 24),
+			run: func() error {
+				_, err := retentionService.Run(time.Now())
+				return err
+			},
+		},
+		{
+			name:     "stale-requests",
+			interval: intervalFromEnv("REQUEST_SLA_INTERVAL_HOURS", 24),
+			run: func() error {
+				result, err := staleRequestService.Run(time.Now())
+				log.Printf("Stale requests: reminded=%d expired=%d", result.Reminded, result.Expired)
+				return err
+			},
+		},
+		{
+			name:     "watchlist",
+			interval: intervalFromEnv("WATCHLIST_INTERVAL_HOURS", 24),
+			run: func() error {
+				result, err := watchlistService.Run(context.Background(), time.Now())
+				log.Printf("Watchlist: checked=%d converted=%d", result.Checked, result.Converted)
+				return err
+			},
+		},
+		{
+			name:     "tmdb-cache",
+			interval: intervalFromEnv("TMDB_CACHE_CLEANUP_INTERVAL_HOURS", 1),
+			run: func() error {
+				_, err := tmdbCache.DeleteExpired(time.Now())
+				return err
+			},
+		},
+	})
+
 	router := gin.Default()
+
+	// Audit logs record the client IP, only trust X-Forwarded-For from known proxies
+	if err := router.SetTrustedProxies(trustedProxiesFromEnv()); err != nil {
+		log.Fatal("Invalid TRUSTED_PROXIES:", err)
+	}
 	
 	router.Use(middleware.CORS())
 	
 	api := router.Group("/api/v1")
+	api.Use(middleware.RequestTimeout(requestTimeoutFromEnv()))
 	{
 		api.GET("/health", handlers.HealthCheck)
 		
 		// Auth endpoints
-		authHandler := handlers.NewAuthHandler(db, authService)
+		authHandler := handlers.NewAuthHandler(db, authService, auditService)
 		auth := api.Group("/auth")
 		{
 			auth.POST("/register", authHandler.Register)
 			auth.POST("/login", authHandler.Login)
 			auth.GET("/me", middleware.AuthRequired(authService), authHandler.GetCurrentUser)
+			auth.GET("/me/preferences", middleware.AuthRequired(authService), authHandler.GetPreferences)
+			auth.PUT("/me/preferences", middleware.AuthRequired(authService), authHandler.UpdatePreferences)
 		}
 		
 		// Protected endpoints (require authentication)
 		protected := api.Group("/")
-		protected.Use(middleware.AuthRequired(authService))
+		protected.Use(middleware.AuthRequired(authService), middleware.TMDBLocale(db))
 		{
 			// Request endpoints
 			requestHandler := handlers.NewRequestHandler(db, plexService, auditService)
@@ -83,21 +190,46 @@ func main() {
 			protected.PUT("/requests/:id", requestHandler.UpdateRequest)
 			protected.DELETE("/requests/:id", requestHandler.DeleteRequest)
 			protected.GET("/requests/stats", middleware.AdminRequired(authService), requestHandler.GetRequestStats)
+			protected.GET("/requests/export", middleware.AdminRequired(authService), requestHandler.ExportRequests)
 			protected.GET("/requests/:id/audit-logs", middleware.AdminRequired(authService), requestHandler.GetRequestAuditLogs)
+
+			// Request import endpoints
+			importHandler := handlers.NewImportHandler(db, importService, auditService)
+			protected.POST("/requests/import", importHandler.PreviewImport)
+			protected.POST("/requests/import/confirm", importHandler.ConfirmImport)
+
+			// Request comment endpoints
+			commentHandler := handlers.NewCommentHandler(db, auditService, notifier)
+			protected.GET("/requests/:id/comments", commentHandler.GetComments)
+			protected.POST("/requests/:id/comments", commentHandler.CreateComment)
 			
+			// Watchlist endpoints
+			watchlistHandler := handlers.NewWatchlistHandler(db)
+			protected.GET("/watchlist", watchlistHandler.GetWatchlist)
+			protected.POST("/watchlist", watchlistHandler.AddToWatchlist)
+			protected.DELETE("/watchlist/:id", watchlistHandler.RemoveFromWatchlist)
+
 			// Search endpoints
-			searchHandler := handlers.NewSearchHandler(tmdbService, plexService, omdbService, db)
+			searchHandler := handlers.NewSearchHandler(tmdbCache, plexService, omdbService, db)
 			protected.GET("/search", searchHandler.SearchMedia)
 			protected.GET("/search/:type/:id", searchHandler.GetMediaDetails)
+			protected.GET("/search/:type/:id/recommendations", searchHandler.GetRecommendations)
+			protected.GET("/search/tv/:id/season/:n", searchHandler.GetSeasonDetails)
 
 			// Person endpoints
-			personHandler := handlers.NewPersonHandler(tmdbService)
+			personHandler := handlers.NewPersonHandler(db, tmdbCache, plexService, auditService)
 			protected.GET("/person/search", personHandler.SearchPerson)
 			protected.GET("/person/:id", personHandler.GetPersonDetails)
 			protected.GET("/person/:id/credits", personHandler.GetPersonCredits)
+			protected.POST("/person/:id/request-missing", personHandler.RequestMissing)
+
+			// Collection endpoints
+			collectionHandler := handlers.NewCollectionHandler(db, tmdbCache, plexService, auditService)
+			protected.GET("/collection/:id", collectionHandler.GetCollection)
+			protected.POST("/collection/:id/request-missing", collectionHandler.RequestMissing)
 
 			// Discover endpoints
-			discoverHandler := handlers.NewDiscoverHandler(tmdbService, plexService)
+			discoverHandler := handlers.NewDiscoverHandler(tmdbCache, plexService, forYouService)
 			protected.GET("/discover/trending", discoverHandler.GetTrending)
 			protected.GET("/discover/popular/movies", discoverHandler.GetPopularMovies)
 			protected.GET("/discover/popular/tv", discoverHandler.GetPopularTV)
@@ -105,6 +237,9 @@ func main() {
 			protected.GET("/discover/top-rated/tv", discoverHandler.GetTopRatedTV)
 			protected.GET("/discover/upcoming/movies", discoverHandler.GetUpcomingMovies)
 			protected.GET("/discover/upcoming/tv", discoverHandler.GetUpcomingTV)
+			protected.GET("/discover/for-you", discoverHandler.GetForYou)
+			protected.GET("/discover", discoverHandler.Discover)
+			protected.GET("/genres", discoverHandler.GetGenres)
 
 			// Plex endpoints (only if service is available)
 			if plexService != nil {
@@ -118,7 +253,7 @@ func main() {
 			}
 
 			// User management endpoints (admin only)
-			userHandler := handlers.NewUserHandler(db)
+			userHandler := handlers.NewUserHandler(db, auditService)
 			users := protected.Group("/users")
 			users.Use(middleware.AdminRequired(authService))
 			{
@@ -127,6 +262,46 @@ func main() {
 				users.PUT("/:id", userHandler.UpdateUser)
 				users.DELETE("/:id", userHandler.DeleteUser)
 			}
+
+			// Trash endpoints for deleted requests and users (admin only)
+			trashHandler := handlers.NewTrashHandler(db, auditService)
+			trash := protected.Group("/trash")
+			trash.Use(middleware.AdminRequired(authService))
+			{
+				trash.GET("/requests", trashHandler.GetTrashedRequests)
+				trash.GET("/users", trashHandler.GetTrashedUsers)
+				trash.POST("/requests/:id/restore", trashHandler.RestoreRequest)
+				trash.POST("/users/:id/restore", trashHandler.RestoreUser)
+				trash.DELETE("/requests/:id", trashHandler.PurgeRequest)
+				trash.DELETE("/users/:id", trashHandler.PurgeUser)
+			}
+
+			// Audit log endpoints (admin only)
+			auditHandler := handlers.NewAuditHandler(db, auditService)
+			auditLogs := protected.Group("/audit-logs")
+			auditLogs.Use(middleware.AdminRequired(authService))
+			{
+				auditLogs.GET("", auditHandler.GetAuditLogs)
+				auditLogs.GET("/export", auditHandler.ExportAuditLogs)
+				auditLogs.GET("/verify", auditHandler.VerifyAuditLogs)
+			}
+
+			// Stats endpoints (admin only)
+			statsHandler := handlers.NewStatsHandler(statsService)
+			stats := protected.Group("/stats")
+			stats.Use(middleware.AdminRequired(authService))
+			{
+				stats.GET("/overview", statsHandler.GetOverview)
+			}
+
+			// Cache endpoints (admin only)
+			cacheHandler := handlers.NewCacheHandler(tmdbCache)
+			cache := protected.Group("/cache")
+			cache.Use(middleware.AdminRequired(authService))
+			{
+				cache.GET("/tmdb", cacheHandler.GetTMDBCacheStats)
+				cache.DELETE("/tmdb", cacheHandler.FlushTMDBCache)
+			}
 		}
 	}
 
@@ -134,4 +309,31 @@ func main() {
 	if err := router.Run(":" + port); err != nil {
 		log.Fatal("Failed to start server:", err)
 	}
-}
\ No newline at end of file
+}
+
+// requestTimeoutFromEnv reads the per-request deadline from REQUEST_TIMEOUT_SECONDS (0 disables it)
+func requestTimeoutFromEnv() time.Duration {
+	value := os.Getenv("REQUEST_TIMEOUT_SECONDS")
+	if value == "" {
+		return 30 * time.Second
+	}
+
+	seconds, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Warning: REQUEST_TIMEOUT_SECONDS must be an integer, using 30")
+		seconds = 30
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// trustedProxiesFromEnv reads the comma-separated proxy IPs and CIDRs allowed to set
+// X-Forwarded-For from TRUSTED_PROXIES. None are trusted by default.
+func trustedProxiesFromEnv() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+// scheduledJob is a background task run on a fixed interval
+type scheduledJob struct {
+	name     string
+	interval time.Duration
+	run      func() error
+}
+
+// startScheduler runs each job once at startup and then on its interval.
+// Jobs run in their own goroutines so a slow job never delays another.
+func startScheduler(jobs []scheduledJob) {
+	for _, job := range jobs {
+		if job.interval <= 0 {
+			log.Printf("Scheduler: job %s disabled (interval %v)", job.name, job.interval)
+			continue
+		}
+
+		go func(job scheduledJob) {
+			ticker := time.NewTicker(job.interval)
+			defer ticker.Stop()
+
+			for {
+				runJob(job)
+				<-ticker.C
+			}
+		}(job)
+
+		log.Printf("Scheduler: job %s scheduled every %v", job.name, job.interval)
+	}
+}
+
+// runJob runs a job, logging failures and recovering from panics
+func runJob(job scheduledJob) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Scheduler: job %s panicked: %v", job.name, r)
+		}
+	}()
+
+	start := time.Now()
+	if err := job.run(); err != nil {
+		log.Printf("Scheduler: job %s failed after %v: %v", job.name, time.Since(start), err)
+		return
+	}
+	log.Printf("Scheduler: job %s finished in %v", job.name, time.Since(start))
+}
+
+// intervalFromEnv reads a job interval in hours, falling back to a default
+func intervalFromEnv(key string, defaultHours int) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return time.Duration(defaultHours) * time.Hour
+	}
+
+	hours, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Warning: %s must be an integer number of hours, using %d", key, defaultHours)
+		hours = defaultHours
+	}
+	return time.Duration(hours) * time.Hour
+}
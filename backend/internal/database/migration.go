@@ -6,10 +6,22 @@ import (
 )
 
 func Migrate(db *gorm.DB) error {
-	return db.AutoMigrate(
+	if err := db.AutoMigrate(
 		&models.User{},
 		&models.Request{},
 		&models.Rating{},
 		&models.AuditLog{},
-	)
+		&models.RequestComment{},
+		&models.RetentionReport{},
+		&models.TMDBCacheEntry{},
+		&models.WatchlistItem{},
+		&models.RequestGenre{},
+	); err != nil {
+		return err
+	}
+
+	// Audit logs from before entity types were added are all about requests
+	return db.Unscoped().Model(&models.AuditLog{}).
+		Where("entity_id = 0 AND request_id IS NOT NULL").
+		Update("entity_id", gorm.Expr("request_id")).Error
 }
\ No newline at end of file
@@ -0,0 +1,279 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jacob-fain/MRS/internal/models"
+	"github.com/jacob-fain/MRS/internal/services"
+	"gorm.io/gorm"
+)
+
+// auditFor returns the audit service recording the client of the current
+// request, or nil when auditing is disabled. The client IP only comes from
+// X-Forwarded-For when the request came through one of TRUSTED_PROXIES.
+func auditFor(c *gin.Context, auditService *services.AuditService) *services.AuditService {
+	return auditService.WithClient(c.ClientIP(), c.Request.UserAgent())
+}
+
+// AuditLogResponse is an audit log entry returned by the audit log endpoints
+type AuditLogResponse struct {
+	ID         uint                   `json:"id"`
+	EntityType string                 `json:"entity_type"`
+	EntityID   uint                   `json:"entity_id"`
+	RequestID  *uint                  `json:"request_id"`
+	Request    interface{}            `json:"request,omitempty"`
+	User       interface{}            `json:"user"`
+	Action     string                 `json:"action"`
+	OldValue   string                 `json:"old_value,omitempty"`
+	NewValue   string                 `json:"new_value,omitempty"`
+	Notes      string                 `json:"notes"`
+	Changes    []services.FieldChange `json:"changes,omitempty"`
+	IPAddress  string                 `json:"ip_address,omitempty"`
+	UserAgent  string                 `json:"user_agent,omitempty"`
+	CreatedAt  string                 `json:"created_at"`
+}
+
+// toAuditLogResponse converts an audit log entry, the request is only
+// included when it was preloaded
+func toAuditLogResponse(log models.AuditLog) AuditLogResponse {
+	var user interface{}
+	if log.User != nil {
+		user = map[string]interface{}{
+			"id":       log.User.ID,
+			"username": log.User.Username,
+		}
+	} else {
+		user = map[string]interface{}{
+			"id":       nil,
+			"username": "System",
+		}
+	}
+
+	response := AuditLogResponse{
+		ID:         log.ID,
+		EntityType: string(log.EntityType),
+		EntityID:   log.EntityID,
+		RequestID:  log.RequestID,
+		User:       user,
+		Action:     string(log.Action),
+		OldValue:   log.OldValue,
+		NewValue:   log.NewValue,
+		Notes:      log.Notes,
+		Changes:    services.ParseAuditChanges(log.OldValue, log.NewValue),
+		IPAddress:  log.IPAddress,
+		UserAgent:  log.UserAgent,
+		CreatedAt:  log.CreatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+	if log.Request != nil {
+		response.Request = map[string]interface{}{
+			"id":         log.Request.ID,
+			"title":      log.Request.Title,
+			"media_type": log.Request.MediaType,
+			"deleted":    log.Request.DeletedAt.Valid,
+		}
+	}
+	return response
+}
+
+type auditHandler struct {
+	db           *gorm.DB
+	auditService *services.AuditService
+}
+
+// NewAuditHandler creates a new audit log handler
+func NewAuditHandler(db *gorm.DB, auditService *services.AuditService) *auditHandler {
+	return &auditHandler{
+		db:           db,
+		auditService: auditService,
+	}
+}
+
+// applyAuditLogFilters applies the filters shared by the audit log endpoints
+func applyAuditLogFilters(query *gorm.DB, c *gin.Context) (*gorm.DB, error) {
+	// Filter by acting user, "system" selects actions without a user
+	if userIDParam := c.Query("user_id"); userIDParam != "" {
+		if userIDParam == "system" {
+			query = query.Where("user_id IS NULL")
+		} else {
+			uid, err := strconv.Atoi(userIDParam)
+			if err != nil {
+				return nil, errors.New("Invalid user_id, must be a user ID or 'system'")
+			}
+			query = query.Where("user_id = ?", uid)
+		}
+	}
+
+	if action := c.Query("action"); action != "" {
+		query = query.Where("action = ?", action)
+	}
+
+	if entityType := c.Query("entity_type"); entityType != "" {
+		query = query.Where("entity_type = ?", entityType)
+	}
+
+	if entityIDParam := c.Query("entity_id"); entityIDParam != "" {
+		entityID, err := strconv.Atoi(entityIDParam)
+		if err != nil {
+			return nil, errors.New("Invalid entity_id")
+		}
+		query = query.Where("entity_id = ?", entityID)
+	}
+
+	if requestIDParam := c.Query("request_id"); requestIDParam != "" {
+		requestID, err := strconv.Atoi(requestIDParam)
+		if err != nil {
+			return nil, errors.New("Invalid request_id")
+		}
+		query = query.Where("request_id = ?", requestID)
+	}
+
+	// Filter by date range, same format as the request list
+	if from := c.Query("from"); from != "" {
+		fromTime, err := parseDateParam(from)
+		if err != nil {
+			return nil, errors.New("Invalid from date, use YYYY-MM-DD or RFC3339")
+		}
+		query = query.Where("created_at >= ?", fromTime)
+	}
+	if to := c.Query("to"); to != "" {
+		toTime, err := parseDateParam(to)
+		if err != nil {
+			return nil, errors.New("Invalid to date, use YYYY-MM-DD or RFC3339")
+		}
+		// A bare date includes the whole day
+		if len(to) == len("2006-01-02") {
+			toTime = toTime.AddDate(0, 0, 1)
+		}
+		query = query.Where("created_at < ?", toTime)
+	}
+
+	return query, nil
+}
+
+// GetAuditLogs returns audit log entries across all entities (admin only)
+// @Summary List audit logs
+// @Description Browse the audit log of requests, users and logins, newest first, including the history of deleted requests (admin only)
+// @Tags audit
+// @Produce json
+// @Security BearerAuth
+// @Param user_id query string false "Filter by acting user ID, or 'system' for system actions"
+// @Param action query string false "Filter by action (e.g. approved, rejected, login_failed)"
+// @Param entity_type query string false "Filter by entity type (request, user)"
+// @Param entity_id query int false "Filter by entity ID, e.g. the user an entry is about"
+// @Param request_id query int false "Filter by request ID"
+// @Param from query string false "Only entries on or after this date (YYYY-MM-DD or RFC3339)"
+// @Param to query string false "Only entries on or before this date (YYYY-MM-DD or RFC3339)"
+// @Param limit query int false "Page size (default 50, max 100)"
+// @Param offset query int false "Number of entries to skip"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /audit-logs [get]
+func (h *auditHandler) GetAuditLogs(c *gin.Context) {
+	query, err := applyAuditLogFilters(h.db.Model(&models.AuditLog{}), c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to count audit logs",
+		})
+		return
+	}
+
+	limit := defaultRequestLimit
+	if l, err := strconv.Atoi(c.Query("limit")); err == nil && l > 0 {
+		limit = l
+	}
+	if limit > maxRequestLimit {
+		limit = maxRequestLimit
+	}
+	offset := 0
+	if o, err := strconv.Atoi(c.Query("offset")); err == nil && o > 0 {
+		offset = o
+	}
+
+	// Keep the history of deleted requests and users readable
+	unscoped := func(db *gorm.DB) *gorm.DB {
+		return db.Unscoped()
+	}
+
+	var logs []models.AuditLog
+	if err := query.Preload("Request", unscoped).Preload("User", unscoped).
+		Order("created_at DESC, id DESC").
+		Limit(limit).Offset(offset).
+		Find(&logs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch audit logs",
+		})
+		return
+	}
+
+	responses := make([]AuditLogResponse, len(logs))
+	for i, log := range logs {
+		responses[i] = toAuditLogResponse(log)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"logs":     responses,
+		"count":    len(responses),
+		"total":    total,
+		"limit":    limit,
+		"offset":   offset,
+		"has_more": int64(offset+len(responses)) < total,
+	})
+}
+
+// VerifyAuditLogs checks the audit log hash chain (admin only)
+// @Summary Verify audit logs
+// @Description Walk the hash-chained audit log, including soft-deleted entries, and report entries that were altered, removed or inserted (admin only)
+// @Description Keep head_id and head_hash from a report and pass them back as a checkpoint to detect entries removed from the end of the log.
+// @Tags audit
+// @Produce json
+// @Security BearerAuth
+// @Param checkpoint_id query int false "head_id from an earlier report"
+// @Param checkpoint_hash query string false "head_hash from an earlier report"
+// @Success 200 {object} services.AuditChainReport
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /audit-logs/verify [get]
+func (h *auditHandler) VerifyAuditLogs(c *gin.Context) {
+	var checkpoint *services.AuditCheckpoint
+	if idStr, hash := c.Query("checkpoint_id"), c.Query("checkpoint_hash"); idStr != "" || hash != "" {
+		id, err := strconv.ParseUint(idStr, 10, 0)
+		if err != nil || hash == "" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid checkpoint, checkpoint_id and checkpoint_hash are both required",
+			})
+			return
+		}
+		checkpoint = &services.AuditCheckpoint{ID: uint(id), Hash: hash}
+	}
+
+	report, err := h.auditService.VerifyChain(checkpoint)
+	if err != nil {
+		log.Printf("Failed to verify audit logs: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to verify audit logs",
+		})
+		return
+	}
+
+	if !report.Valid {
+		adminID, _ := c.Get("userID")
+		log.Printf("Audit log verification by admin %v found %d breaks", adminID, report.BreakCount)
+	}
+
+	c.JSON(http.StatusOK, report)
+}
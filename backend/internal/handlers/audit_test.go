@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jacob-fain/MRS/internal/models"
+	"github.com/jacob-fain/MRS/internal/services"
+	"github.com/jacob-fain/MRS/internal/testutil"
+)
+
+func TestAuditHandler_GetAuditLogs(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := testutil.SetupTestDB(t)
+	auditService := services.NewAuditService(db)
+	admin := testutil.CreateTestUser(t, db, "admin@example.com", "admin", "hashedpass", true)
+	user := testutil.CreateTestUser(t, db, "user@example.com", "user", "hashedpass", false)
+
+	dune := testutil.CreateTestRequest(t, db, user.ID, "Dune", models.MediaTypeMovie)
+	arrival := testutil.CreateTestRequest(t, db, user.ID, "Arrival", models.MediaTypeMovie)
+	testutil.AssertNoError(t, auditService.LogRequestCreated(dune.ID, user.ID))
+	testutil.AssertNoError(t, auditService.LogRequestCreated(arrival.ID, user.ID))
+	testutil.AssertNoError(t, auditService.LogRequestStatusChange(dune.ID, &admin.ID, models.StatusPending, models.StatusRejected))
+	testutil.AssertNoError(t, auditService.LogRequestStatusChange(arrival.ID, nil, models.StatusPending, models.StatusApproved))
+
+	// An old rejection outside of last week
+	old := testutil.CreateTestRequest(t, db, user.ID, "Sicario", models.MediaTypeMovie)
+	testutil.AssertNoError(t, auditService.LogRequestStatusChange(old.ID, &admin.ID, models.StatusPending, models.StatusRejected))
+	db.Model(&models.AuditLog{}).Where("request_id = ?", old.ID).Update("created_at", time.Now().AddDate(0, -1, 0))
+
+	// Deleting a request or its owner doesn't hide the history
+	db.Delete(dune)
+	db.Delete(user)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", admin.ID)
+		c.Set("isAdmin", true)
+	})
+	router.GET("/audit-logs", NewAuditHandler(db, nil).GetAuditLogs)
+
+	lastWeek := time.Now().AddDate(0, 0, -7).Format("2006-01-02")
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedCount  int
+		expectedTotal  int
+		expectMore     bool
+		check          func(t *testing.T, logs []AuditLogResponse)
+	}{
+		{
+			name:           "everything, newest first",
+			expectedStatus: http.StatusOK,
+			expectedCount:  5,
+			expectedTotal:  5,
+			check: func(t *testing.T, logs []AuditLogResponse) {
+				testutil.AssertEqual(t, string(models.ActionApproved), logs[0].Action)
+			},
+		},
+		{
+			name:           "who rejected what last week",
+			query:          "?action=rejected&user_id=" + fmt.Sprint(admin.ID) + "&from=" + lastWeek,
+			expectedStatus: http.StatusOK,
+			expectedCount:  1,
+			expectedTotal:  1,
+			check: func(t *testing.T, logs []AuditLogResponse) {
+				request := logs[0].Request.(map[string]interface{})
+				testutil.AssertEqual(t, "Dune", request["title"])
+				testutil.AssertEqual(t, true, request["deleted"])
+				actor := logs[0].User.(map[string]interface{})
+				testutil.AssertEqual(t, "admin", actor["username"])
+			},
+		},
+		{
+			name:           "deleted users are still shown",
+			query:          "?action=created&request_id=" + fmt.Sprint(dune.ID),
+			expectedStatus: http.StatusOK,
+			expectedCount:  1,
+			expectedTotal:  1,
+			check: func(t *testing.T, logs []AuditLogResponse) {
+				actor := logs[0].User.(map[string]interface{})
+				testutil.AssertEqual(t, "user", actor["username"])
+			},
+		},
+		{
+			name:           "system actions",
+			query:          "?user_id=system",
+			expectedStatus: http.StatusOK,
+			expectedCount:  1,
+			expectedTotal:  1,
+			check: func(t *testing.T, logs []AuditLogResponse) {
+				actor := logs[0].User.(map[string]interface{})
+				testutil.AssertEqual(t, "System", actor["username"])
+			},
+		},
+		{
+			name:           "paginated",
+			query:          "?limit=2&offset=2",
+			expectedStatus: http.StatusOK,
+			expectedCount:  2,
+			expectedTotal:  5,
+			expectMore:     true,
+		},
+		{
+			name:           "invalid filter",
+			query:          "?request_id=abc",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/audit-logs"+tt.query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			testutil.AssertEqual(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var response struct {
+				Logs    []AuditLogResponse `json:"logs"`
+				Count   int                `json:"count"`
+				Total   int                `json:"total"`
+				HasMore bool               `json:"has_more"`
+			}
+			testutil.AssertNoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			testutil.AssertEqual(t, tt.expectedCount, response.Count)
+			testutil.AssertEqual(t, tt.expectedCount, len(response.Logs))
+			testutil.AssertEqual(t, tt.expectedTotal, response.Total)
+			testutil.AssertEqual(t, tt.expectMore, response.HasMore)
+			if tt.check != nil {
+				tt.check(t, response.Logs)
+			}
+		})
+	}
+}
+
+func TestAuditHandler_VerifyAuditLogs(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := testutil.SetupTestDB(t)
+	auditService := services.NewAuditService(db)
+	admin := testutil.CreateTestUser(t, db, "admin@example.com", "admin", "hashedpass", true)
+	request := testutil.CreateTestRequest(t, db, admin.ID, "Dune", models.MediaTypeMovie)
+	testutil.AssertNoError(t, auditService.LogRequestCreated(request.ID, admin.ID))
+	testutil.AssertNoError(t, auditService.LogRequestStatusChange(request.ID, &admin.ID, models.StatusPending, models.StatusRejected))
+
+	router := gin.New()
+	router.GET("/audit-logs/verify", NewAuditHandler(db, auditService).VerifyAuditLogs)
+
+	verify := func(query string) services.AuditChainReport {
+		req, _ := http.NewRequest("GET", "/audit-logs/verify"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		testutil.AssertEqual(t, http.StatusOK, w.Code)
+
+		var report services.AuditChainReport
+		testutil.AssertNoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+		return report
+	}
+
+	report := verify("")
+	testutil.AssertTrue(t, report.Valid, "chain should be valid")
+	testutil.AssertEqual(t, int64(2), report.Verified)
+
+	checkpoint := fmt.Sprintf("?checkpoint_id=%d&checkpoint_hash=%s", *report.HeadID, report.HeadHash)
+	report = verify(checkpoint)
+	testutil.AssertTrue(t, report.Valid, "chain should match the checkpoint")
+
+	// A checkpoint needs both its ID and hash
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/audit-logs/verify?checkpoint_id=%d", *report.HeadID), nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	testutil.AssertEqual(t, http.StatusBadRequest, w.Code)
+
+	// Turning the rejection into an approval is detected
+	db.Model(&models.AuditLog{}).Where("action = ?", models.ActionRejected).Update("action", models.ActionApproved)
+
+	report = verify("")
+	testutil.AssertTrue(t, !report.Valid, "chain should be broken")
+	testutil.AssertEqual(t, 1, len(report.Breaks))
+
+	// So is removing the last entry, given a checkpoint
+	db.Exec("DELETE FROM audit_logs WHERE action = ?", models.ActionApproved)
+	report = verify(checkpoint)
+	testutil.AssertTrue(t, !report.Valid, "chain should be broken")
+	testutil.AssertEqual(t, "checkpoint entry is missing, entries were removed from the end", report.Breaks[0].Reason)
+}
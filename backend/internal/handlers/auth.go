@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"errors"
+	"log"
 	"net/http"
 	"strings"
 
@@ -12,15 +13,17 @@ import (
 )
 
 type authHandler struct {
-	db          *gorm.DB
-	authService *services.AuthService
+	db           *gorm.DB
+	authService  *services.AuthService
+	auditService *services.AuditService
 }
 
 // NewAuthHandler creates a new auth handler
-func NewAuthHandler(db *gorm.DB, authService *services.AuthService) *authHandler {
+func NewAuthHandler(db *gorm.DB, authService *services.AuthService, auditService *services.AuditService) *authHandler {
 	return &authHandler{
-		db:          db,
-		authService: authService,
+		db:           db,
+		authService:  authService,
+		auditService: auditService,
 	}
 }
 
@@ -116,6 +119,12 @@ func (h *authHandler) Register(c *gin.Context) {
 		return
 	}
 
+	if audit := auditFor(c, h.auditService); audit != nil {
+		if err := audit.LogUserRegistered(user.ID, user.Username); err != nil {
+			log.Printf("Failed to log audit entry for registration of user %d: %v", user.ID, err)
+		}
+	}
+
 	// Generate token
 	token, err := h.authService.GenerateToken(user.ID, user.Email, user.IsAdmin)
 	if err != nil {
@@ -165,6 +174,7 @@ func (h *authHandler) Login(c *gin.Context) {
 	var user models.User
 	if err := h.db.Where("email = ?", req.Email).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
+			h.logLoginFailed(c, 0, req.Email)
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"error": "Invalid credentials",
 			})
@@ -179,6 +189,7 @@ func (h *authHandler) Login(c *gin.Context) {
 	// Verify password
 	if err := h.authService.VerifyPassword(req.Password, user.Password); err != nil {
 		if errors.Is(err, services.ErrInvalidCredentials) {
+			h.logLoginFailed(c, user.ID, req.Email)
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"error": "Invalid credentials",
 			})
@@ -199,6 +210,12 @@ func (h *authHandler) Login(c *gin.Context) {
 		return
 	}
 
+	if audit := auditFor(c, h.auditService); audit != nil {
+		if err := audit.LogUserLogin(user.ID); err != nil {
+			log.Printf("Failed to log audit entry for login of user %d: %v", user.ID, err)
+		}
+	}
+
 	c.JSON(http.StatusOK, AuthResponse{
 		Token: token,
 		User: UserResponse{
@@ -211,6 +228,17 @@ func (h *authHandler) Login(c *gin.Context) {
 	})
 }
 
+// logLoginFailed records a failed login, userID is 0 for unknown emails
+func (h *authHandler) logLoginFailed(c *gin.Context, userID uint, email string) {
+	audit := auditFor(c, h.auditService)
+	if audit == nil {
+		return
+	}
+	if err := audit.LogUserLoginFailed(userID, email); err != nil {
+		log.Printf("Failed to log audit entry for failed login: %v", err)
+	}
+}
+
 // GetCurrentUser returns the current authenticated user
 // @Summary Get current user
 // @Description Get the currently authenticated user's information
@@ -246,4 +274,128 @@ func (h *authHandler) GetCurrentUser(c *gin.Context) {
 		Username: user.Username,
 		IsAdmin:  user.IsAdmin,
 	})
-}
\ No newline at end of file
+}
+
+// PreferencesResponse represents the user's language, region and content preferences
+type PreferencesResponse struct {
+	Language         string `json:"language"`
+	Region           string `json:"region"`
+	IncludeAdult     bool   `json:"include_adult"`
+	MaxCertification string `json:"max_certification"`
+}
+
+// UpdatePreferencesInput represents the preferences update payload. Omitted
+// fields are left unchanged, empty strings reset to the server defaults.
+type UpdatePreferencesInput struct {
+	Language         *string `json:"language"`
+	Region           *string `json:"region"`
+	MaxCertification *string `json:"max_certification"` // Highest certification in discover lists
+	// Adult content is a content restriction set by admins through PUT /users/{id}, sending it here is rejected
+	IncludeAdult *bool `json:"include_adult"`
+}
+
+// GetPreferences returns the current user's preferences
+// @Summary Get preferences
+// @Description Get the current user's language, region and content preferences used for TMDB results
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} PreferencesResponse
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/me/preferences [get]
+func (h *authHandler) GetPreferences(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var user models.User
+	if err := h.db.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to find user",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, toPreferencesResponse(user))
+}
+
+// UpdatePreferences updates the current user's preferences
+// @Summary Update preferences
+// @Description Update the current user's language (e.g. "fr-FR"), region (e.g. "FR") and the highest
+// @Description certification in discover lists (e.g. "PG-13"). Adult content is a content restriction set by admins.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param preferences body UpdatePreferencesInput true "Preferences to update"
+// @Success 200 {object} PreferencesResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/me/preferences [put]
+func (h *authHandler) UpdatePreferences(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var input UpdatePreferencesInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request data",
+		})
+		return
+	}
+	if input.IncludeAdult != nil {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Adult content can only be changed by an admin",
+		})
+		return
+	}
+
+	var user models.User
+	if err := h.db.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to find user",
+		})
+		return
+	}
+
+	if input.Language != nil {
+		user.Language = strings.TrimSpace(*input.Language)
+	}
+	if input.Region != nil {
+		user.Region = strings.ToUpper(strings.TrimSpace(*input.Region))
+	}
+	if input.MaxCertification != nil {
+		user.MaxCertification = strings.TrimSpace(*input.MaxCertification)
+	}
+
+	locale := services.TMDBLocale{
+		Language:         user.Language,
+		Region:           user.Region,
+		IncludeAdult:     user.IncludeAdult,
+		MaxCertification: user.MaxCertification,
+	}
+	if err := locale.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if err := h.db.Model(&user).Select("language", "region", "max_certification").Updates(&user).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update preferences",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, toPreferencesResponse(user))
+}
+
+func toPreferencesResponse(user models.User) PreferencesResponse {
+	return PreferencesResponse{
+		Language:         user.Language,
+		Region:           user.Region,
+		IncludeAdult:     user.IncludeAdult,
+		MaxCertification: user.MaxCertification,
+	}
+}
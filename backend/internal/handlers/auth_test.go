@@ -26,7 +26,7 @@ func TestAuthHandler_Register(t *testing.T) {
 	authService, err := services.NewAuthService()
 	testutil.AssertNoError(t, err)
 	
-	handler := NewAuthHandler(db, authService)
+	handler := NewAuthHandler(db, authService, nil)
 	router := gin.New()
 	router.POST("/auth/register", handler.Register)
 
@@ -187,7 +187,7 @@ func TestAuthHandler_Login(t *testing.T) {
 	hashedPassword, err := authService.HashPassword("correctpassword")
 	testutil.AssertNoError(t, err)
 	
-	handler := NewAuthHandler(db, authService)
+	handler := NewAuthHandler(db, authService, nil)
 	router := gin.New()
 	router.POST("/auth/login", handler.Login)
 
@@ -348,7 +348,7 @@ func TestAuthHandler_GetCurrentUser(t *testing.T) {
 	authService, err := services.NewAuthService()
 	testutil.AssertNoError(t, err)
 	
-	handler := NewAuthHandler(db, authService)
+	handler := NewAuthHandler(db, authService, nil)
 	
 	// Create test user
 	user := testutil.CreateTestUser(t, db, "user@example.com", "testuser", "hashedpass", false)
@@ -423,4 +423,179 @@ func TestAuthHandler_GetCurrentUser(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+func TestAuthHandler_UpdatePreferences(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	db := testutil.SetupTestDB(t)
+
+	os.Setenv("JWT_SECRET", "test-secret-key")
+	defer os.Unsetenv("JWT_SECRET")
+
+	authService, err := services.NewAuthService()
+	testutil.AssertNoError(t, err)
+
+	handler := NewAuthHandler(db, authService, nil)
+	user := testutil.CreateTestUser(t, db, "user@example.com", "testuser", "hashedpass", false)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", user.ID)
+	})
+	router.GET("/auth/me/preferences", handler.GetPreferences)
+	router.PUT("/auth/me/preferences", handler.UpdatePreferences)
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		checkResponse  func(t *testing.T, response map[string]interface{})
+	}{
+		{
+			name:           "set French locale",
+			body:           `{"language": "fr-FR", "region": "fr"}`,
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, response map[string]interface{}) {
+				testutil.AssertEqual(t, "fr-FR", response["language"])
+				testutil.AssertEqual(t, "FR", response["region"])
+				testutil.AssertEqual(t, "", response["max_certification"])
+				testutil.AssertEqual(t, false, response["include_adult"])
+			},
+		},
+		{
+			name:           "omitted fields are unchanged",
+			body:           `{"region": "BE"}`,
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, response map[string]interface{}) {
+				testutil.AssertEqual(t, "fr-FR", response["language"])
+				testutil.AssertEqual(t, "BE", response["region"])
+			},
+		},
+		{
+			name:           "adult content is set by admins",
+			body:           `{"include_adult": true}`,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "set the discover certification",
+			body:           `{"max_certification": " PG-13 "}`,
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, response map[string]interface{}) {
+				testutil.AssertEqual(t, "PG-13", response["max_certification"])
+				testutil.AssertEqual(t, "BE", response["region"])
+			},
+		},
+		{
+			name:           "invalid certification",
+			body:           `{"max_certification": "NOT-A-RATING"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid language",
+			body:           `{"language": "french"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid region",
+			body:           `{"region": "FRA"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "empty values reset to defaults",
+			body:           `{"language": "", "region": ""}`,
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, response map[string]interface{}) {
+				testutil.AssertEqual(t, "", response["language"])
+				testutil.AssertEqual(t, "", response["region"])
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("PUT", "/auth/me/preferences", bytes.NewBufferString(tt.body))
+			testutil.AssertNoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			testutil.AssertEqual(t, tt.expectedStatus, w.Code)
+
+			if tt.checkResponse != nil {
+				var response map[string]interface{}
+				testutil.AssertNoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				tt.checkResponse(t, response)
+			}
+		})
+	}
+
+	// Preferences are stored on the user, restrictions are untouched
+	var stored models.User
+	testutil.AssertNoError(t, db.First(&stored, user.ID).Error)
+	testutil.AssertEqual(t, "", stored.Language)
+	testutil.AssertEqual(t, false, stored.IncludeAdult)
+
+	req, _ := http.NewRequest("GET", "/auth/me/preferences", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	testutil.AssertEqual(t, http.StatusOK, w.Code)
+}
+
+func TestAuthHandler_AuditEvents(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := testutil.SetupTestDB(t)
+
+	os.Setenv("JWT_SECRET", "test-secret-key")
+	defer os.Unsetenv("JWT_SECRET")
+
+	authService, err := services.NewAuthService()
+	testutil.AssertNoError(t, err)
+
+	handler := NewAuthHandler(db, authService, services.NewAuditService(db))
+	router := gin.New()
+	router.POST("/auth/register", handler.Register)
+	router.POST("/auth/login", handler.Login)
+
+	post := func(path, body string) int {
+		req, _ := http.NewRequest("POST", path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", "test-agent/1.0")
+		req.RemoteAddr = "203.0.113.7:4321"
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	testutil.AssertEqual(t, http.StatusCreated, post("/auth/register", `{"email": "new@example.com", "username": "newuser", "password": "password123"}`))
+	testutil.AssertEqual(t, http.StatusUnauthorized, post("/auth/login", `{"email": "new@example.com", "password": "wrong"}`))
+	testutil.AssertEqual(t, http.StatusUnauthorized, post("/auth/login", `{"email": "nobody@example.com", "password": "wrong"}`))
+	testutil.AssertEqual(t, http.StatusOK, post("/auth/login", `{"email": "new@example.com", "password": "password123"}`))
+
+	var user models.User
+	testutil.AssertNoError(t, db.Where("email = ?", "new@example.com").First(&user).Error)
+
+	var logs []models.AuditLog
+	testutil.AssertNoError(t, db.Order("id").Find(&logs).Error)
+	testutil.AssertEqual(t, 4, len(logs))
+
+	expected := []struct {
+		action   models.AuditAction
+		entityID uint
+		actor    bool
+	}{
+		{models.ActionRegistered, user.ID, true},
+		{models.ActionLoginFailed, user.ID, false},
+		{models.ActionLoginFailed, 0, false},
+		{models.ActionLogin, user.ID, true},
+	}
+	for i, e := range expected {
+		testutil.AssertEqual(t, e.action, logs[i].Action)
+		testutil.AssertEqual(t, models.AuditEntityUser, logs[i].EntityType)
+		testutil.AssertEqual(t, e.entityID, logs[i].EntityID)
+		testutil.AssertEqual(t, e.actor, logs[i].UserID != nil)
+		testutil.AssertTrue(t, logs[i].RequestID == nil, "user events have no request")
+		testutil.AssertEqual(t, "203.0.113.7", logs[i].IPAddress)
+		testutil.AssertEqual(t, "test-agent/1.0", logs[i].UserAgent)
+	}
+	testutil.AssertEqual(t, `{"email":"nobody@example.com"}`, logs[2].NewValue)
+}
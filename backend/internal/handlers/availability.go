@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/jacob-fain/MRS/internal/models"
+	"github.com/jacob-fain/MRS/internal/services"
+	"gorm.io/gorm"
+)
+
+// markInPlex sets InPlex on movie and TV results with a single batch Plex check.
+// Other media types (e.g. people) are left untouched.
+func markInPlex(ctx context.Context, plexService services.PlexServiceInterface, results []services.TMDBResult) {
+	if plexService == nil || len(results) == 0 {
+		return
+	}
+
+	refs := make([]services.MediaRef, len(results))
+	for i, result := range results {
+		refs[i] = result.MediaRef()
+	}
+
+	found := plexService.CheckManyExistContext(ctx, refs)
+	for i := range results {
+		if refs[i].Title != "" {
+			results[i].InPlex = found[refs[i]]
+		}
+	}
+}
+
+// yearFromDate returns the year of a TMDB "YYYY-MM-DD" date, or 0 if unknown
+func yearFromDate(date string) int {
+	if len(date) < 4 {
+		return 0
+	}
+	year, _ := strconv.Atoi(date[:4])
+	return year
+}
+
+// isReleased reports whether a movie has been released or a TV show has started airing
+func isReleased(result services.TMDBResult) bool {
+	date := result.ReleaseDate
+	if result.MediaType == "tv" {
+		date = result.FirstAirDate
+	}
+	return date != "" && date <= time.Now().Format("2006-01-02")
+}
+
+// requestedTitles returns which of the results the user has already requested,
+// keyed by requestKey
+func requestedTitles(db *gorm.DB, userID interface{}, results []services.TMDBResult) (map[string]bool, error) {
+	requested := make(map[string]bool)
+	if len(results) == 0 {
+		return requested, nil
+	}
+
+	tmdbIDs := make([]int, len(results))
+	for i, result := range results {
+		tmdbIDs[i] = result.ID
+	}
+
+	var requests []models.Request
+	err := db.Select("media_type", "tmdb_id").
+		Where("user_id = ? AND tmdb_id IN ?", userID, tmdbIDs).
+		Find(&requests).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, request := range requests {
+		requested[requestKey(string(request.MediaType), request.TMDBId)] = true
+	}
+	return requested, nil
+}
+
+// filterRequested drops the results the user has already requested
+func filterRequested(db *gorm.DB, userID interface{}, results []services.TMDBResult) ([]services.TMDBResult, error) {
+	requested, err := requestedTitles(db, userID, results)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := make([]services.TMDBResult, 0, len(results))
+	for _, result := range results {
+		if !requested[requestKey(result.MediaType, result.ID)] {
+			filtered = append(filtered, result)
+		}
+	}
+	return filtered, nil
+}
+
+// requestKey identifies a title across media types, TMDB IDs are only unique per type
+func requestKey(mediaType string, tmdbID int) string {
+	return fmt.Sprintf("%s:%d", mediaType, tmdbID)
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jacob-fain/MRS/internal/services"
+	"github.com/jacob-fain/MRS/internal/testutil"
+)
+
+func TestMarkInPlex_NotConfigured(t *testing.T) {
+	var notConfigured *services.PlexService
+
+	tests := []struct {
+		name        string
+		plexService services.PlexServiceInterface
+	}{
+		{"nil interface", nil},
+		{"nil Plex service", notConfigured},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := []services.TMDBResult{{ID: 603, Title: "The Matrix", ReleaseDate: "1999-03-31", MediaType: "movie"}}
+			markInPlex(context.Background(), tt.plexService, results)
+			testutil.AssertTrue(t, !results[0].InPlex, "nothing is in Plex without Plex")
+		})
+	}
+}
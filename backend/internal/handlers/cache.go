@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jacob-fain/MRS/internal/services"
+)
+
+type cacheHandler struct {
+	tmdbCache *services.CachedTMDBService
+}
+
+// NewCacheHandler creates a new cache administration handler
+func NewCacheHandler(tmdbCache *services.CachedTMDBService) *cacheHandler {
+	return &cacheHandler{
+		tmdbCache: tmdbCache,
+	}
+}
+
+// GetTMDBCacheStats returns TMDB cache statistics (admin only)
+// @Summary Get TMDB cache statistics
+// @Description Get the number of cached TMDB responses and hit/miss counts per endpoint (admin only)
+// @Tags cache
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} services.CacheStats
+// @Failure 403 {object} map[string]string
+// @Router /cache/tmdb [get]
+func (h *cacheHandler) GetTMDBCacheStats(c *gin.Context) {
+	c.JSON(http.StatusOK, h.tmdbCache.Stats())
+}
+
+// FlushTMDBCache removes all cached TMDB responses (admin only)
+// @Summary Flush TMDB cache
+// @Description Remove all cached TMDB responses and reset the statistics (admin only)
+// @Tags cache
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /cache/tmdb [delete]
+func (h *cacheHandler) FlushTMDBCache(c *gin.Context) {
+	if err := h.tmdbCache.Flush(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to flush TMDB cache",
+		})
+		return
+	}
+
+	adminID, _ := c.Get("userID")
+	log.Printf("TMDB cache flushed by admin %v", adminID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "TMDB cache flushed",
+	})
+}
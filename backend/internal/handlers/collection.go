@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jacob-fain/MRS/internal/services"
+	"gorm.io/gorm"
+)
+
+type collectionHandler struct {
+	db           *gorm.DB
+	tmdbService  services.TMDBServiceInterface
+	plexService  services.PlexServiceInterface
+	auditService *services.AuditService
+}
+
+// NewCollectionHandler creates a new collection handler
+func NewCollectionHandler(db *gorm.DB, tmdbService services.TMDBServiceInterface, plexService services.PlexServiceInterface, auditService *services.AuditService) *collectionHandler {
+	return &collectionHandler{
+		db:           db,
+		tmdbService:  tmdbService,
+		plexService:  plexService,
+		auditService: auditService,
+	}
+}
+
+// CollectionPart is a movie in a collection with its availability for the current user
+type CollectionPart struct {
+	services.TMDBResult
+	Requested bool `json:"requested"`
+	Released  bool `json:"released"`
+}
+
+// CollectionResponse is a collection with per-part availability
+type CollectionResponse struct {
+	*services.TMDBCollection
+	Parts        []CollectionPart `json:"parts"`
+	MissingCount int              `json:"missing_count"` // Released parts neither in Plex nor requested
+}
+
+// GetCollection returns a movie collection with Plex availability for each part
+// @Summary Get collection
+// @Description Get a TMDB movie collection (e.g. a trilogy) with Plex availability and request status for each part
+// @Tags collection
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "TMDB collection ID"
+// @Success 200 {object} CollectionResponse
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /collection/{id} [get]
+func (h *collectionHandler) GetCollection(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	response, ok := h.loadCollection(c, userID)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// RequestMissing requests every released part of a collection that is neither in
+// Plex nor already requested by the current user
+// @Summary Request missing collection parts
+// @Description Create one request per missing movie of a collection, in a single transaction
+// @Tags collection
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "TMDB collection ID"
+// @Success 201 {object} map[string]interface{}
+// @Success 200 {object} map[string]interface{} "Nothing to request"
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /collection/{id}/request-missing [post]
+func (h *collectionHandler) RequestMissing(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	isAdmin, _ := c.Get("isAdmin")
+
+	collection, ok := h.loadCollection(c, userID)
+	if !ok {
+		return
+	}
+
+	var missing []services.TMDBResult
+	for _, part := range collection.Parts {
+		if part.Released && !part.InPlex && !part.Requested {
+			missing = append(missing, part.TMDBResult)
+		}
+	}
+
+	notes := fmt.Sprintf("Requested with %s", collection.Name)
+	requests, err := createRequestsForResults(h.db, auditFor(c, h.auditService), userID.(uint), isAdmin.(bool), missing, notes)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to create requests",
+		})
+		return
+	}
+
+	status := http.StatusCreated
+	if len(requests) == 0 {
+		status = http.StatusOK
+	}
+
+	responses := make([]RequestResponse, len(requests))
+	for i, request := range requests {
+		responses[i] = toRequestResponse(request)
+	}
+
+	c.JSON(status, gin.H{
+		"collection_id": collection.ID,
+		"requests":      responses,
+		"count":         len(responses),
+	})
+}
+
+// loadCollection fetches the collection in the :id param and annotates its parts.
+// It writes the error response and returns false on failure.
+func (h *collectionHandler) loadCollection(c *gin.Context, userID interface{}) (*CollectionResponse, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid ID",
+		})
+		return nil, false
+	}
+
+	collection, err := h.tmdbService.GetCollectionContext(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(upstreamErrorStatus(err), gin.H{
+			"error":   "failed to get collection",
+			"details": err.Error(),
+		})
+		return nil, false
+	}
+
+	// Check Plex availability for the parts
+	markInPlex(c.Request.Context(), h.plexService, collection.Parts)
+
+	requested, err := requestedTitles(h.db, userID, collection.Parts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to check requested titles",
+		})
+		return nil, false
+	}
+
+	response := &CollectionResponse{
+		TMDBCollection: collection,
+		Parts:          make([]CollectionPart, len(collection.Parts)),
+	}
+	for i, part := range collection.Parts {
+		response.Parts[i] = CollectionPart{
+			TMDBResult: part,
+			Requested:  requested[requestKey(part.MediaType, part.ID)],
+			Released:   isReleased(part),
+		}
+		if response.Parts[i].Released && !part.InPlex && !response.Parts[i].Requested {
+			response.MissingCount++
+		}
+	}
+
+	return response, true
+}
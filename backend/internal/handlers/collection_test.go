@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jacob-fain/MRS/internal/models"
+	"github.com/jacob-fain/MRS/internal/services"
+	"github.com/jacob-fain/MRS/internal/testutil"
+)
+
+func lordOfTheRingsCollection(collectionID int) (*services.TMDBCollection, error) {
+	return &services.TMDBCollection{
+		ID:   collectionID,
+		Name: "The Lord of the Rings Collection",
+		Parts: []services.TMDBResult{
+			{ID: 120, Title: "The Lord of the Rings: The Fellowship of the Ring", MediaType: "movie", ReleaseDate: "2001-12-18"},
+			{ID: 121, Title: "The Lord of the Rings: The Two Towers", MediaType: "movie", ReleaseDate: "2002-12-18"},
+			{ID: 122, Title: "The Lord of the Rings: The Return of the King", MediaType: "movie", ReleaseDate: "2003-12-01"},
+			{ID: 999, Title: "The Lord of the Rings: The Hunt for Gollum", MediaType: "movie"},
+		},
+	}, nil
+}
+
+func TestCollectionHandler_GetCollection(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, db, "user@example.com", "testuser", "hashedpass", false)
+
+	twoTowers := testutil.CreateTestRequest(t, db, user.ID, "The Lord of the Rings: The Two Towers", models.MediaTypeMovie)
+	db.Model(twoTowers).Update("tmdb_id", 121)
+
+	mockTMDB := &mockTMDBService{getCollectionFunc: lordOfTheRingsCollection}
+	mockPlex := &mockPlexService{
+		checkIfExistsFunc: func(title string, year int, mediaType string) (bool, error) {
+			return title == "The Lord of the Rings: The Fellowship of the Ring", nil
+		},
+	}
+	router := newTestRouter(user.ID, user.IsAdmin)
+	router.GET("/collection/:id", NewCollectionHandler(db, mockTMDB, mockPlex, nil).GetCollection)
+
+	req, err := http.NewRequest("GET", "/collection/119", nil)
+	testutil.AssertNoError(t, err)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	testutil.AssertEqual(t, http.StatusOK, w.Code)
+
+	var response map[string]interface{}
+	testutil.AssertNoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	testutil.AssertEqual(t, "The Lord of the Rings Collection", response["name"])
+	testutil.AssertEqual(t, float64(1), response["missing_count"])
+
+	parts := response["parts"].([]interface{})
+	testutil.AssertEqual(t, 4, len(parts))
+	testutil.AssertEqual(t, true, parts[0].(map[string]interface{})["in_plex"])
+	testutil.AssertEqual(t, true, parts[1].(map[string]interface{})["requested"])
+	testutil.AssertEqual(t, false, parts[2].(map[string]interface{})["requested"])
+	testutil.AssertEqual(t, false, parts[3].(map[string]interface{})["released"])
+
+	// Invalid ID
+	req, err = http.NewRequest("GET", "/collection/abc", nil)
+	testutil.AssertNoError(t, err)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	testutil.AssertEqual(t, http.StatusBadRequest, w.Code)
+}
+
+func TestCollectionHandler_RequestMissing(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := testutil.SetupTestDB(t)
+	auditService := services.NewAuditService(db)
+
+	mockTMDB := &mockTMDBService{getCollectionFunc: lordOfTheRingsCollection}
+	mockPlex := &mockPlexService{
+		checkIfExistsFunc: func(title string, year int, mediaType string) (bool, error) {
+			return title == "The Lord of the Rings: The Fellowship of the Ring", nil
+		},
+	}
+	handler := NewCollectionHandler(db, mockTMDB, mockPlex, auditService)
+
+	t.Run("requests released parts missing from Plex", func(t *testing.T) {
+		user := testutil.CreateTestUser(t, db, "user@example.com", "testuser", "hashedpass", false)
+		router := newTestRouter(user.ID, user.IsAdmin)
+		router.POST("/collection/:id/request-missing", handler.RequestMissing)
+
+		req, err := http.NewRequest("POST", "/collection/119/request-missing", nil)
+		testutil.AssertNoError(t, err)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		testutil.AssertEqual(t, http.StatusCreated, w.Code)
+
+		var response map[string]interface{}
+		testutil.AssertNoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		testutil.AssertEqual(t, float64(2), response["count"])
+
+		var requests []models.Request
+		db.Where("user_id = ?", user.ID).Order("tmdb_id").Find(&requests)
+		testutil.AssertEqual(t, 2, len(requests))
+		testutil.AssertEqual(t, 121, requests[0].TMDBId)
+		testutil.AssertEqual(t, 2002, requests[0].Year)
+		testutil.AssertEqual(t, models.StatusPending, requests[0].Status)
+		testutil.AssertEqual(t, "Requested with The Lord of the Rings Collection", requests[0].Notes)
+		testutil.AssertEqual(t, 122, requests[1].TMDBId)
+
+		var auditCount int64
+		db.Model(&models.AuditLog{}).Where("request_id IN ?", []uint{requests[0].ID, requests[1].ID}).Count(&auditCount)
+		testutil.AssertEqual(t, int64(2), auditCount)
+
+		// Everything is requested now
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		testutil.AssertEqual(t, http.StatusOK, w.Code)
+		testutil.AssertNoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		testutil.AssertEqual(t, float64(0), response["count"])
+	})
+
+	t.Run("auto-approves for admins", func(t *testing.T) {
+		admin := testutil.CreateTestUser(t, db, "admin@example.com", "admin", "hashedpass", true)
+		router := newTestRouter(admin.ID, admin.IsAdmin)
+		router.POST("/collection/:id/request-missing", handler.RequestMissing)
+
+		req, err := http.NewRequest("POST", "/collection/119/request-missing", nil)
+		testutil.AssertNoError(t, err)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		testutil.AssertEqual(t, http.StatusCreated, w.Code)
+
+		var requests []models.Request
+		db.Where("user_id = ?", admin.ID).Find(&requests)
+		testutil.AssertEqual(t, 2, len(requests))
+		for _, request := range requests {
+			testutil.AssertEqual(t, models.StatusApproved, request.Status)
+		}
+	})
+}
@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jacob-fain/MRS/internal/models"
+	"github.com/jacob-fain/MRS/internal/services"
+	"gorm.io/gorm"
+)
+
+type commentHandler struct {
+	db           *gorm.DB
+	auditService *services.AuditService
+	notifier     services.Notifier
+}
+
+// NewCommentHandler creates a new request comment handler
+func NewCommentHandler(db *gorm.DB, auditService *services.AuditService, notifier services.Notifier) *commentHandler {
+	return &commentHandler{
+		db:           db,
+		auditService: auditService,
+		notifier:     notifier,
+	}
+}
+
+// CreateCommentInput represents the comment creation payload
+type CreateCommentInput struct {
+	Body     string `json:"body" binding:"required,max=5000"`
+	Internal bool   `json:"internal"`
+}
+
+// CommentAuthor represents the author of a comment in API responses
+type CommentAuthor struct {
+	ID       uint   `json:"id"`
+	Username string `json:"username"`
+	IsAdmin  bool   `json:"is_admin"`
+}
+
+// CommentResponse represents a comment in API responses
+type CommentResponse struct {
+	ID        uint          `json:"id"`
+	RequestID uint          `json:"request_id"`
+	User      CommentAuthor `json:"user"`
+	Body      string        `json:"body"`
+	Internal  bool          `json:"internal"`
+	CreatedAt string        `json:"created_at"`
+}
+
+// GetComments returns the comment thread for a request
+// @Summary Get request comments
+// @Description Get the comment thread for a request (requester and admins only, internal comments are admin only)
+// @Tags requests
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Request ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /requests/{id}/comments [get]
+func (h *commentHandler) GetComments(c *gin.Context) {
+	request, ok := h.findAccessibleRequest(c)
+	if !ok {
+		return
+	}
+
+	isAdmin, _ := c.Get("isAdmin")
+
+	query := h.db.Preload("User").Where("request_id = ?", request.ID)
+	if !isAdmin.(bool) {
+		// Requesters never see internal comments
+		query = query.Where("internal = ?", false)
+	}
+
+	var comments []models.RequestComment
+	if err := query.Order("created_at ASC").Order("id ASC").Find(&comments).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch comments",
+		})
+		return
+	}
+
+	responses := make([]CommentResponse, len(comments))
+	for i, comment := range comments {
+		responses[i] = toCommentResponse(comment)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"comments": responses,
+		"count":    len(responses),
+	})
+}
+
+// CreateComment adds a comment to a request's thread
+// @Summary Add a request comment
+// @Description Add a comment to a request (requester and admins only, only admins can post internal comments)
+// @Tags requests
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Request ID"
+// @Param comment body CreateCommentInput true "Comment details"
+// @Success 201 {object} CommentResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /requests/{id}/comments [post]
+func (h *commentHandler) CreateComment(c *gin.Context) {
+	request, ok := h.findAccessibleRequest(c)
+	if !ok {
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	isAdmin, _ := c.Get("isAdmin")
+
+	var input CreateCommentInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid comment data",
+		})
+		return
+	}
+
+	if input.Internal && !isAdmin.(bool) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Only admins can post internal comments",
+		})
+		return
+	}
+
+	comment := models.RequestComment{
+		RequestID: request.ID,
+		UserID:    userID.(uint),
+		Body:      input.Body,
+		Internal:  input.Internal,
+	}
+
+	if err := h.db.Create(&comment).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create comment",
+		})
+		return
+	}
+
+	// Log audit entry
+	if audit := auditFor(c, h.auditService); audit != nil {
+		if err := audit.LogRequestComment(request.ID, comment.UserID, comment.ID, comment.Internal); err != nil {
+			log.Printf("Failed to log audit entry for comment %d on request %d: %v", comment.ID, request.ID, err)
+		}
+	}
+
+	// Load author for response
+	h.db.Preload("User").First(&comment, comment.ID)
+
+	h.notifyComment(request, comment)
+
+	c.JSON(http.StatusCreated, toCommentResponse(comment))
+}
+
+// findAccessibleRequest loads the request from the path and checks that the
+// current user is its requester or an admin. It writes the error response itself.
+func (h *commentHandler) findAccessibleRequest(c *gin.Context) (*models.Request, bool) {
+	requestID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request ID",
+		})
+		return nil, false
+	}
+
+	userID, _ := c.Get("userID")
+	isAdmin, _ := c.Get("isAdmin")
+
+	var request models.Request
+	if err := h.db.First(&request, requestID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Request not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to find request",
+			})
+		}
+		return nil, false
+	}
+
+	if !isAdmin.(bool) && request.UserID != userID.(uint) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "You can only comment on your own requests",
+		})
+		return nil, false
+	}
+
+	return &request, true
+}
+
+// notifyComment tells the other side of the conversation about a new comment.
+// Admin replies go to the requester, requester comments go to the admins, and
+// internal comments only ever go to admins.
+func (h *commentHandler) notifyComment(request *models.Request, comment models.RequestComment) {
+	if h.notifier == nil {
+		return
+	}
+
+	notification := services.Notification{
+		Type:      services.NotificationRequestComment,
+		RequestID: request.ID,
+		Subject:   fmt.Sprintf("New comment on %s", request.Title),
+		Message:   comment.Body,
+	}
+
+	switch {
+	case comment.Internal:
+		notification.ToAdmins = true
+	case comment.UserID == request.UserID:
+		notification.ToAdmins = true
+	default:
+		notification.UserIDs = []uint{request.UserID}
+	}
+
+	if err := h.notifier.Notify(notification); err != nil {
+		log.Printf("Failed to send comment notification for request %d: %v", request.ID, err)
+	}
+}
+
+// toCommentResponse converts a comment model to its response format
+func toCommentResponse(comment models.RequestComment) CommentResponse {
+	return CommentResponse{
+		ID:        comment.ID,
+		RequestID: comment.RequestID,
+		User: CommentAuthor{
+			ID:       comment.User.ID,
+			Username: comment.User.Username,
+			IsAdmin:  comment.User.IsAdmin,
+		},
+		Body:      comment.Body,
+		Internal:  comment.Internal,
+		CreatedAt: comment.CreatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+}
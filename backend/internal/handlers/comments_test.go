@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jacob-fain/MRS/internal/models"
+	"github.com/jacob-fain/MRS/internal/services"
+	"github.com/jacob-fain/MRS/internal/testutil"
+)
+
+// Mock notifier that records sent notifications
+type mockNotifier struct {
+	notifications []services.Notification
+}
+
+func (m *mockNotifier) Notify(n services.Notification) error {
+	m.notifications = append(m.notifications, n)
+	return nil
+}
+
+func TestCommentHandler_CreateComment(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	db := testutil.SetupTestDB(t)
+	notifier := &mockNotifier{}
+	handler := NewCommentHandler(db, services.NewAuditService(db), notifier)
+
+	user := testutil.CreateTestUser(t, db, "user@example.com", "user", "pass", false)
+	admin := testutil.CreateTestUser(t, db, "admin@example.com", "admin", "pass", true)
+	otherUser := testutil.CreateTestUser(t, db, "other@example.com", "other", "pass", false)
+
+	request := testutil.CreateTestRequest(t, db, user.ID, "The Matrix", models.MediaTypeMovie)
+
+	tests := []struct {
+		name           string
+		userID         uint
+		isAdmin        bool
+		input          CreateCommentInput
+		expectedStatus int
+		checkNotify    func(t *testing.T, n services.Notification)
+	}{
+		{
+			name:           "requester comments and admins are notified",
+			userID:         user.ID,
+			input:          CreateCommentInput{Body: "Any update?"},
+			expectedStatus: http.StatusCreated,
+			checkNotify: func(t *testing.T, n services.Notification) {
+				testutil.AssertEqual(t, true, n.ToAdmins)
+				testutil.AssertEqual(t, 0, len(n.UserIDs))
+			},
+		},
+		{
+			name:           "admin reply notifies requester",
+			userID:         admin.ID,
+			isAdmin:        true,
+			input:          CreateCommentInput{Body: "Downloading now"},
+			expectedStatus: http.StatusCreated,
+			checkNotify: func(t *testing.T, n services.Notification) {
+				testutil.AssertEqual(t, false, n.ToAdmins)
+				testutil.AssertEqual(t, user.ID, n.UserIDs[0])
+			},
+		},
+		{
+			name:           "admin internal comment only notifies admins",
+			userID:         admin.ID,
+			isAdmin:        true,
+			input:          CreateCommentInput{Body: "Bad release, wait for a better one", Internal: true},
+			expectedStatus: http.StatusCreated,
+			checkNotify: func(t *testing.T, n services.Notification) {
+				testutil.AssertEqual(t, true, n.ToAdmins)
+				testutil.AssertEqual(t, 0, len(n.UserIDs))
+			},
+		},
+		{
+			name:           "requester cannot post internal comments",
+			userID:         user.ID,
+			input:          CreateCommentInput{Body: "Sneaky", Internal: true},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "other users cannot comment",
+			userID:         otherUser.ID,
+			input:          CreateCommentInput{Body: "Me too"},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "empty body",
+			userID:         user.ID,
+			input:          CreateCommentInput{},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notifier.notifications = nil
+
+			router := gin.New()
+			router.POST("/requests/:id/comments", func(c *gin.Context) {
+				c.Set("userID", tt.userID)
+				c.Set("isAdmin", tt.isAdmin)
+				handler.CreateComment(c)
+			})
+
+			body, err := json.Marshal(tt.input)
+			testutil.AssertNoError(t, err)
+
+			req, err := http.NewRequest("POST", fmt.Sprintf("/requests/%d/comments", request.ID), bytes.NewReader(body))
+			testutil.AssertNoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			testutil.AssertEqual(t, tt.expectedStatus, w.Code)
+
+			if tt.checkNotify != nil {
+				testutil.AssertEqual(t, 1, len(notifier.notifications))
+				tt.checkNotify(t, notifier.notifications[0])
+			} else {
+				testutil.AssertEqual(t, 0, len(notifier.notifications))
+			}
+		})
+	}
+
+	// Every created comment should have an audit entry
+	var auditCount int64
+	db.Model(&models.AuditLog{}).Where("request_id = ? AND action = ?", request.ID, models.ActionCommented).Count(&auditCount)
+	testutil.AssertEqual(t, int64(3), auditCount)
+}
+
+func TestCommentHandler_GetComments(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	db := testutil.SetupTestDB(t)
+	handler := NewCommentHandler(db, nil, nil)
+
+	user := testutil.CreateTestUser(t, db, "user@example.com", "user", "pass", false)
+	admin := testutil.CreateTestUser(t, db, "admin@example.com", "admin", "pass", true)
+	otherUser := testutil.CreateTestUser(t, db, "other@example.com", "other", "pass", false)
+
+	request := testutil.CreateTestRequest(t, db, user.ID, "The Matrix", models.MediaTypeMovie)
+	db.Create(&models.RequestComment{RequestID: request.ID, UserID: user.ID, Body: "Please add this"})
+	db.Create(&models.RequestComment{RequestID: request.ID, UserID: admin.ID, Body: "Only a cam release so far", Internal: true})
+	db.Create(&models.RequestComment{RequestID: request.ID, UserID: admin.ID, Body: "Will do"})
+
+	tests := []struct {
+		name           string
+		requestID      string
+		userID         uint
+		isAdmin        bool
+		expectedStatus int
+		expectedCount  int
+	}{
+		{
+			name:           "requester does not see internal comments",
+			requestID:      fmt.Sprintf("%d", request.ID),
+			userID:         user.ID,
+			expectedStatus: http.StatusOK,
+			expectedCount:  2,
+		},
+		{
+			name:           "admin sees all comments",
+			requestID:      fmt.Sprintf("%d", request.ID),
+			userID:         admin.ID,
+			isAdmin:        true,
+			expectedStatus: http.StatusOK,
+			expectedCount:  3,
+		},
+		{
+			name:           "other users are forbidden",
+			requestID:      fmt.Sprintf("%d", request.ID),
+			userID:         otherUser.ID,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "request not found",
+			requestID:      "9999",
+			userID:         admin.ID,
+			isAdmin:        true,
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/requests/:id/comments", func(c *gin.Context) {
+				c.Set("userID", tt.userID)
+				c.Set("isAdmin", tt.isAdmin)
+				handler.GetComments(c)
+			})
+
+			req, err := http.NewRequest("GET", "/requests/"+tt.requestID+"/comments", nil)
+			testutil.AssertNoError(t, err)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			testutil.AssertEqual(t, tt.expectedStatus, w.Code)
+
+			if tt.expectedStatus == http.StatusOK {
+				var response map[string]interface{}
+				err = json.Unmarshal(w.Body.Bytes(), &response)
+				testutil.AssertNoError(t, err)
+
+				comments := response["comments"].([]interface{})
+				testutil.AssertEqual(t, tt.expectedCount, len(comments))
+				first := comments[0].(map[string]interface{})
+				testutil.AssertEqual(t, "Please add this", first["body"])
+			}
+		})
+	}
+}
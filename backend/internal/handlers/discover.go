@@ -1,23 +1,27 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jacob-fain/MRS/internal/services"
 )
 
 type discoverHandler struct {
-	tmdbService services.TMDBServiceInterface
-	plexService services.PlexServiceInterface
+	tmdbService   services.TMDBServiceInterface
+	plexService   services.PlexServiceInterface
+	forYouService *services.ForYouService
 }
 
 // NewDiscoverHandler creates a new discover handler
-func NewDiscoverHandler(tmdbService services.TMDBServiceInterface, plexService services.PlexServiceInterface) *discoverHandler {
+func NewDiscoverHandler(tmdbService services.TMDBServiceInterface, plexService services.PlexServiceInterface, forYouService *services.ForYouService) *discoverHandler {
 	return &discoverHandler{
-		tmdbService: tmdbService,
-		plexService: plexService,
+		tmdbService:   tmdbService,
+		plexService:   plexService,
+		forYouService: forYouService,
 	}
 }
 
@@ -49,9 +53,9 @@ func (h *discoverHandler) GetTrending(c *gin.Context) {
 		return
 	}
 
-	results, err := h.tmdbService.GetTrending(mediaType, timeWindow, page)
+	results, err := h.tmdbService.GetTrendingContext(c.Request.Context(), mediaType, timeWindow, page)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
+		c.JSON(upstreamErrorStatus(err), gin.H{
 			"error":   "failed to get trending content",
 			"details": err.Error(),
 		})
@@ -59,21 +63,7 @@ func (h *discoverHandler) GetTrending(c *gin.Context) {
 	}
 
 	// Check Plex availability for results
-	if h.plexService != nil {
-		for i := range results.Results {
-			year := 0
-			if results.Results[i].ReleaseDate != "" && len(results.Results[i].ReleaseDate) >= 4 {
-				year, _ = strconv.Atoi(results.Results[i].ReleaseDate[:4])
-			} else if results.Results[i].FirstAirDate != "" && len(results.Results[i].FirstAirDate) >= 4 {
-				year, _ = strconv.Atoi(results.Results[i].FirstAirDate[:4])
-			}
-			title := results.Results[i].Title
-			if title == "" {
-				title = results.Results[i].Name
-			}
-			results.Results[i].InPlex, _ = h.plexService.CheckIfExists(title, year, results.Results[i].MediaType)
-		}
-	}
+	markInPlex(c.Request.Context(), h.plexService, results.Results)
 
 	c.JSON(http.StatusOK, results)
 }
@@ -87,9 +77,9 @@ func (h *discoverHandler) GetPopularMovies(c *gin.Context) {
 		}
 	}
 
-	results, err := h.tmdbService.GetPopularMovies(page)
+	results, err := h.tmdbService.GetPopularMoviesContext(c.Request.Context(), page)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
+		c.JSON(upstreamErrorStatus(err), gin.H{
 			"error":   "failed to get popular movies",
 			"details": err.Error(),
 		})
@@ -97,15 +87,7 @@ func (h *discoverHandler) GetPopularMovies(c *gin.Context) {
 	}
 
 	// Check Plex availability for results
-	if h.plexService != nil {
-		for i := range results.Results {
-			year := 0
-			if results.Results[i].ReleaseDate != "" && len(results.Results[i].ReleaseDate) >= 4 {
-				year, _ = strconv.Atoi(results.Results[i].ReleaseDate[:4])
-			}
-			results.Results[i].InPlex, _ = h.plexService.CheckIfExists(results.Results[i].Title, year, "movie")
-		}
-	}
+	markInPlex(c.Request.Context(), h.plexService, results.Results)
 
 	c.JSON(http.StatusOK, results)
 }
@@ -119,9 +101,9 @@ func (h *discoverHandler) GetPopularTV(c *gin.Context) {
 		}
 	}
 
-	results, err := h.tmdbService.GetPopularTV(page)
+	results, err := h.tmdbService.GetPopularTVContext(c.Request.Context(), page)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
+		c.JSON(upstreamErrorStatus(err), gin.H{
 			"error":   "failed to get popular TV shows",
 			"details": err.Error(),
 		})
@@ -129,15 +111,7 @@ func (h *discoverHandler) GetPopularTV(c *gin.Context) {
 	}
 
 	// Check Plex availability for results
-	if h.plexService != nil {
-		for i := range results.Results {
-			year := 0
-			if results.Results[i].FirstAirDate != "" && len(results.Results[i].FirstAirDate) >= 4 {
-				year, _ = strconv.Atoi(results.Results[i].FirstAirDate[:4])
-			}
-			results.Results[i].InPlex, _ = h.plexService.CheckIfExists(results.Results[i].Name, year, "tv")
-		}
-	}
+	markInPlex(c.Request.Context(), h.plexService, results.Results)
 
 	c.JSON(http.StatusOK, results)
 }
@@ -151,9 +125,9 @@ func (h *discoverHandler) GetTopRatedMovies(c *gin.Context) {
 		}
 	}
 
-	results, err := h.tmdbService.GetTopRatedMovies(page)
+	results, err := h.tmdbService.GetTopRatedMoviesContext(c.Request.Context(), page)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
+		c.JSON(upstreamErrorStatus(err), gin.H{
 			"error":   "failed to get top rated movies",
 			"details": err.Error(),
 		})
@@ -161,15 +135,7 @@ func (h *discoverHandler) GetTopRatedMovies(c *gin.Context) {
 	}
 
 	// Check Plex availability for results
-	if h.plexService != nil {
-		for i := range results.Results {
-			year := 0
-			if results.Results[i].ReleaseDate != "" && len(results.Results[i].ReleaseDate) >= 4 {
-				year, _ = strconv.Atoi(results.Results[i].ReleaseDate[:4])
-			}
-			results.Results[i].InPlex, _ = h.plexService.CheckIfExists(results.Results[i].Title, year, "movie")
-		}
-	}
+	markInPlex(c.Request.Context(), h.plexService, results.Results)
 
 	c.JSON(http.StatusOK, results)
 }
@@ -183,9 +149,9 @@ func (h *discoverHandler) GetTopRatedTV(c *gin.Context) {
 		}
 	}
 
-	results, err := h.tmdbService.GetTopRatedTV(page)
+	results, err := h.tmdbService.GetTopRatedTVContext(c.Request.Context(), page)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
+		c.JSON(upstreamErrorStatus(err), gin.H{
 			"error":   "failed to get top rated TV shows",
 			"details": err.Error(),
 		})
@@ -193,15 +159,7 @@ func (h *discoverHandler) GetTopRatedTV(c *gin.Context) {
 	}
 
 	// Check Plex availability for results
-	if h.plexService != nil {
-		for i := range results.Results {
-			year := 0
-			if results.Results[i].FirstAirDate != "" && len(results.Results[i].FirstAirDate) >= 4 {
-				year, _ = strconv.Atoi(results.Results[i].FirstAirDate[:4])
-			}
-			results.Results[i].InPlex, _ = h.plexService.CheckIfExists(results.Results[i].Name, year, "tv")
-		}
-	}
+	markInPlex(c.Request.Context(), h.plexService, results.Results)
 
 	c.JSON(http.StatusOK, results)
 }
@@ -215,9 +173,9 @@ func (h *discoverHandler) GetUpcomingMovies(c *gin.Context) {
 		}
 	}
 
-	results, err := h.tmdbService.GetUpcomingMovies(page)
+	results, err := h.tmdbService.GetUpcomingMoviesContext(c.Request.Context(), page)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
+		c.JSON(upstreamErrorStatus(err), gin.H{
 			"error":   "failed to get upcoming movies",
 			"details": err.Error(),
 		})
@@ -225,15 +183,7 @@ func (h *discoverHandler) GetUpcomingMovies(c *gin.Context) {
 	}
 
 	// Check Plex availability for results
-	if h.plexService != nil {
-		for i := range results.Results {
-			year := 0
-			if results.Results[i].ReleaseDate != "" && len(results.Results[i].ReleaseDate) >= 4 {
-				year, _ = strconv.Atoi(results.Results[i].ReleaseDate[:4])
-			}
-			results.Results[i].InPlex, _ = h.plexService.CheckIfExists(results.Results[i].Title, year, "movie")
-		}
-	}
+	markInPlex(c.Request.Context(), h.plexService, results.Results)
 
 	c.JSON(http.StatusOK, results)
 }
@@ -247,9 +197,9 @@ func (h *discoverHandler) GetUpcomingTV(c *gin.Context) {
 		}
 	}
 
-	results, err := h.tmdbService.GetUpcomingTV(page)
+	results, err := h.tmdbService.GetUpcomingTVContext(c.Request.Context(), page)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
+		c.JSON(upstreamErrorStatus(err), gin.H{
 			"error":   "failed to get upcoming TV shows",
 			"details": err.Error(),
 		})
@@ -257,15 +207,197 @@ func (h *discoverHandler) GetUpcomingTV(c *gin.Context) {
 	}
 
 	// Check Plex availability for results
-	if h.plexService != nil {
-		for i := range results.Results {
-			year := 0
-			if results.Results[i].FirstAirDate != "" && len(results.Results[i].FirstAirDate) >= 4 {
-				year, _ = strconv.Atoi(results.Results[i].FirstAirDate[:4])
+	markInPlex(c.Request.Context(), h.plexService, results.Results)
+
+	c.JSON(http.StatusOK, results)
+}
+
+// Discover returns movies or TV shows matching TMDB discover filters
+// Query params: media_type (movie/tv), genres (comma separated IDs), year_from, year_to,
+// min_rating, min_votes, min_runtime, max_runtime, language, providers (comma separated IDs),
+// region, sort_by, hide_in_plex, page
+// With hide_in_plex=true titles already in Plex are removed, so a page can have fewer results.
+func (h *discoverHandler) Discover(c *gin.Context) {
+	mediaType := c.DefaultQuery("media_type", "movie")
+	if mediaType != "movie" && mediaType != "tv" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid media_type, must be 'movie' or 'tv'",
+		})
+		return
+	}
+
+	page := 1
+	if pageStr := c.Query("page"); pageStr != "" {
+		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
+			page = p
+		}
+	}
+
+	filters, err := discoverFiltersFromQuery(c)
+	if err == nil {
+		err = filters.Validate()
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	results, err := h.tmdbService.DiscoverContext(c.Request.Context(), mediaType, filters, page)
+	if err != nil {
+		c.JSON(upstreamErrorStatus(err), gin.H{
+			"error":   "failed to discover titles",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// Check Plex availability for results
+	markInPlex(c.Request.Context(), h.plexService, results.Results)
+
+	if c.Query("hide_in_plex") == "true" {
+		filtered := make([]services.TMDBResult, 0, len(results.Results))
+		for _, result := range results.Results {
+			if !result.InPlex {
+				filtered = append(filtered, result)
 			}
-			results.Results[i].InPlex, _ = h.plexService.CheckIfExists(results.Results[i].Name, year, "tv")
 		}
+		results.Results = filtered
 	}
 
 	c.JSON(http.StatusOK, results)
 }
+
+// discoverFiltersFromQuery reads the discover filters from the query string
+func discoverFiltersFromQuery(c *gin.Context) (services.TMDBDiscoverFilters, error) {
+	filters := services.TMDBDiscoverFilters{
+		OriginalLanguage: c.Query("language"),
+		WatchRegion:      strings.ToUpper(c.Query("region")),
+		SortBy:           c.Query("sort_by"),
+	}
+
+	var err error
+	if filters.Genres, err = queryIntList(c, "genres"); err != nil {
+		return filters, err
+	}
+	if filters.WatchProviders, err = queryIntList(c, "providers"); err != nil {
+		return filters, err
+	}
+
+	ints := []struct {
+		name   string
+		target *int
+	}{
+		{"year_from", &filters.YearFrom},
+		{"year_to", &filters.YearTo},
+		{"min_votes", &filters.MinVoteCount},
+		{"min_runtime", &filters.MinRuntime},
+		{"max_runtime", &filters.MaxRuntime},
+	}
+	for _, param := range ints {
+		value := c.Query(param.name)
+		if value == "" {
+			continue
+		}
+		if *param.target, err = strconv.Atoi(value); err != nil {
+			return filters, fmt.Errorf("invalid %s", param.name)
+		}
+	}
+
+	if value := c.Query("min_rating"); value != "" {
+		if filters.MinVoteAverage, err = strconv.ParseFloat(value, 64); err != nil {
+			return filters, fmt.Errorf("invalid min_rating")
+		}
+	}
+
+	return filters, nil
+}
+
+// queryIntList parses a comma separated list of IDs
+func queryIntList(c *gin.Context, name string) ([]int, error) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	var ids []int
+	for _, part := range strings.Split(value, ",") {
+		id, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || id <= 0 {
+			return nil, fmt.Errorf("invalid %s, expected comma separated IDs", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// GetGenres returns TMDB's genres for the discover filters
+// Query params: media_type (movie/tv)
+func (h *discoverHandler) GetGenres(c *gin.Context) {
+	mediaType := c.DefaultQuery("media_type", "movie")
+	if mediaType != "movie" && mediaType != "tv" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid media_type, must be 'movie' or 'tv'",
+		})
+		return
+	}
+
+	genres, err := h.tmdbService.GetGenresContext(c.Request.Context(), mediaType)
+	if err != nil {
+		c.JSON(upstreamErrorStatus(err), gin.H{
+			"error":   "failed to get genres",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"media_type": mediaType,
+		"genres":     genres,
+	})
+}
+
+// GetForYou returns titles recommended from the current user's request history
+// @Summary Get the For You feed
+// @Description Get titles ranked by TMDB recommendations for the current user's recent requests, the cast members and directors they request most, genre affinity and release year. Titles already requested or in Plex are left out. Users without TMDB requests get trending titles.
+// @Tags discover
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Number of titles (default: 20, max: 50)"
+// @Success 200 {object} services.ForYouFeed
+// @Failure 500 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /discover/for-you [get]
+func (h *discoverHandler) GetForYou(c *gin.Context) {
+	if h.forYouService == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "the For You feed is not available",
+		})
+		return
+	}
+
+	userID, _ := c.Get("userID")
+
+	limit := 20
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
+			limit = l
+		}
+	}
+	if limit > 50 {
+		limit = 50
+	}
+
+	feed, err := h.forYouService.Feed(c.Request.Context(), userID.(uint), limit)
+	if err != nil {
+		c.JSON(upstreamErrorStatus(err), gin.H{
+			"error":   "failed to get recommendations",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, feed)
+}
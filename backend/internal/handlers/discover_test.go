@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jacob-fain/MRS/internal/services"
+	"github.com/jacob-fain/MRS/internal/testutil"
+)
+
+func TestDiscover(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		checkFilters   func(t *testing.T, mediaType string, filters services.TMDBDiscoverFilters, page int)
+		checkResponse  func(t *testing.T, response map[string]interface{})
+	}{
+		{
+			name:           "passes filters to TMDB",
+			path:           "/discover?media_type=tv&genres=18,80&year_from=1990&year_to=2010&min_rating=7.5&min_votes=100&min_runtime=30&max_runtime=60&language=en&providers=8&region=gb&sort_by=vote_average.desc&page=2",
+			expectedStatus: http.StatusOK,
+			checkFilters: func(t *testing.T, mediaType string, filters services.TMDBDiscoverFilters, page int) {
+				testutil.AssertEqual(t, "tv", mediaType)
+				testutil.AssertEqual(t, 2, page)
+				testutil.AssertEqual(t, 2, len(filters.Genres))
+				testutil.AssertEqual(t, 80, filters.Genres[1])
+				testutil.AssertEqual(t, 1990, filters.YearFrom)
+				testutil.AssertEqual(t, 2010, filters.YearTo)
+				testutil.AssertEqual(t, 7.5, filters.MinVoteAverage)
+				testutil.AssertEqual(t, 100, filters.MinVoteCount)
+				testutil.AssertEqual(t, 30, filters.MinRuntime)
+				testutil.AssertEqual(t, 60, filters.MaxRuntime)
+				testutil.AssertEqual(t, "en", filters.OriginalLanguage)
+				testutil.AssertEqual(t, 8, filters.WatchProviders[0])
+				testutil.AssertEqual(t, "GB", filters.WatchRegion)
+				testutil.AssertEqual(t, "vote_average.desc", filters.SortBy)
+			},
+			checkResponse: func(t *testing.T, response map[string]interface{}) {
+				results := response["results"].([]interface{})
+				testutil.AssertEqual(t, 2, len(results))
+				testutil.AssertEqual(t, true, results[0].(map[string]interface{})["in_plex"])
+			},
+		},
+		{
+			name:           "hides titles in Plex",
+			path:           "/discover?hide_in_plex=true",
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, response map[string]interface{}) {
+				results := response["results"].([]interface{})
+				testutil.AssertEqual(t, 1, len(results))
+				testutil.AssertEqual(t, "Zodiac", results[0].(map[string]interface{})["title"])
+			},
+		},
+		{
+			name:           "invalid media type",
+			path:           "/discover?media_type=person",
+			expectedStatus: http.StatusBadRequest,
+			checkResponse: func(t *testing.T, response map[string]interface{}) {
+				testutil.AssertEqual(t, "invalid media_type, must be 'movie' or 'tv'", response["error"])
+			},
+		},
+		{
+			name:           "invalid genre list",
+			path:           "/discover?genres=18,drama",
+			expectedStatus: http.StatusBadRequest,
+			checkResponse: func(t *testing.T, response map[string]interface{}) {
+				testutil.AssertEqual(t, "invalid genres, expected comma separated IDs", response["error"])
+			},
+		},
+		{
+			name:           "invalid year range",
+			path:           "/discover?year_from=2010&year_to=1990",
+			expectedStatus: http.StatusBadRequest,
+			checkResponse: func(t *testing.T, response map[string]interface{}) {
+				testutil.AssertEqual(t, "year_from must not be after year_to", response["error"])
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+
+			mockTMDB := &mockTMDBService{
+				discoverFunc: func(mediaType string, filters services.TMDBDiscoverFilters, page int) (*services.TMDBSearchResult, error) {
+					if tt.checkFilters != nil {
+						tt.checkFilters(t, mediaType, filters, page)
+					}
+					return &services.TMDBSearchResult{
+						Page: page,
+						Results: []services.TMDBResult{
+							{ID: 807, Title: "Se7en", MediaType: "movie", ReleaseDate: "1995-09-22"},
+							{ID: 1949, Title: "Zodiac", MediaType: "movie", ReleaseDate: "2007-03-02"},
+						},
+					}, nil
+				},
+			}
+			mockPlex := &mockPlexService{
+				checkIfExistsFunc: func(title string, year int, mediaType string) (bool, error) {
+					return title == "Se7en", nil
+				},
+			}
+
+			handler := NewDiscoverHandler(mockTMDB, mockPlex, nil)
+			router.GET("/discover", handler.Discover)
+
+			req, err := http.NewRequest("GET", tt.path, nil)
+			testutil.AssertNoError(t, err)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			testutil.AssertEqual(t, tt.expectedStatus, w.Code)
+
+			var response map[string]interface{}
+			testutil.AssertNoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			tt.checkResponse(t, response)
+		})
+	}
+}
+
+func TestGetGenres(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	mockTMDB := &mockTMDBService{
+		genresFunc: func(mediaType string) ([]services.TMDBGenre, error) {
+			testutil.AssertEqual(t, "tv", mediaType)
+			return []services.TMDBGenre{{ID: 18, Name: "Drama"}}, nil
+		},
+	}
+	handler := NewDiscoverHandler(mockTMDB, nil, nil)
+	router.GET("/genres", handler.GetGenres)
+
+	req, err := http.NewRequest("GET", "/genres?media_type=tv", nil)
+	testutil.AssertNoError(t, err)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	testutil.AssertEqual(t, http.StatusOK, w.Code)
+
+	var response map[string]interface{}
+	testutil.AssertNoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	genres := response["genres"].([]interface{})
+	testutil.AssertEqual(t, 1, len(genres))
+	testutil.AssertEqual(t, "Drama", genres[0].(map[string]interface{})["name"])
+}
+
+func TestGetForYou_NotConfigured(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", uint(1))
+	})
+
+	handler := NewDiscoverHandler(&mockTMDBService{}, nil, nil)
+	router.GET("/discover/for-you", handler.GetForYou)
+
+	req, err := http.NewRequest("GET", "/discover/for-you", nil)
+	testutil.AssertNoError(t, err)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	testutil.AssertEqual(t, http.StatusServiceUnavailable, w.Code)
+}
@@ -0,0 +1,375 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jacob-fain/MRS/internal/models"
+	"gorm.io/gorm"
+)
+
+// exportBatchSize is how many rows are loaded at once while streaming an export
+const exportBatchSize = 500
+
+// exportRecord is a row of an export. It is written as is in JSON exports.
+type exportRecord interface {
+	csvRecord() []string
+}
+
+// exportWriter streams records as a CSV file or a JSON array
+type exportWriter struct {
+	c      *gin.Context
+	name   string // Base name of the downloaded file
+	format string
+	csv    *csv.Writer
+}
+
+// newExportWriter validates the format query parameter (csv by default) and
+// prepares the download. It writes the error response and returns false on failure.
+func newExportWriter(c *gin.Context, name string) (*exportWriter, bool) {
+	format := c.DefaultQuery("format", "csv")
+	if format != "csv" && format != "json" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid format, must be 'csv' or 'json'",
+		})
+		return nil, false
+	}
+	return &exportWriter{c: c, name: name, format: format}, true
+}
+
+// start sends the headers, and the CSV header row or the opening bracket
+func (e *exportWriter) start(header []string) error {
+	filename := fmt.Sprintf("%s-%s.%s", e.name, time.Now().UTC().Format("20060102-150405"), e.format)
+	e.c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	e.c.Header("Cache-Control", "no-store")
+
+	if e.format == "csv" {
+		e.c.Header("Content-Type", "text/csv; charset=utf-8")
+		e.c.Status(http.StatusOK)
+		e.csv = csv.NewWriter(e.c.Writer)
+		return e.csv.Write(header)
+	}
+
+	e.c.Header("Content-Type", "application/json; charset=utf-8")
+	e.c.Status(http.StatusOK)
+	_, err := e.c.Writer.WriteString("[")
+	return err
+}
+
+// write adds a record to the export
+func (e *exportWriter) write(record exportRecord, first bool) error {
+	if e.format == "csv" {
+		return e.csv.Write(record.csvRecord())
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	separator := ",\n"
+	if first {
+		separator = "\n"
+	}
+	if _, err := e.c.Writer.WriteString(separator); err != nil {
+		return err
+	}
+	_, err = e.c.Writer.Write(data)
+	return err
+}
+
+// flush sends the buffered rows to the client
+func (e *exportWriter) flush() error {
+	if e.csv != nil {
+		e.csv.Flush()
+		if err := e.csv.Error(); err != nil {
+			return err
+		}
+	}
+	e.c.Writer.Flush()
+	return nil
+}
+
+// finish closes the JSON array and flushes the remaining rows
+func (e *exportWriter) finish() error {
+	if e.format == "json" {
+		if _, err := e.c.Writer.WriteString("\n]\n"); err != nil {
+			return err
+		}
+	}
+	return e.flush()
+}
+
+// streamExport writes the rows of query in batches, converting each batch with
+// toRecords. Errors after the first byte can't change the status code anymore,
+// so they are logged and the response is cut short.
+func streamExport[T any](c *gin.Context, query *gorm.DB, name string, header []string, toRecords func([]T) []exportRecord) {
+	export, ok := newExportWriter(c, name)
+	if !ok {
+		return
+	}
+
+	if err := export.start(header); err != nil {
+		log.Printf("Failed to start %s export: %v", name, err)
+		return
+	}
+
+	count := 0
+	var batch []T
+	result := query.FindInBatches(&batch, exportBatchSize, func(tx *gorm.DB, _ int) error {
+		for _, record := range toRecords(batch) {
+			if err := export.write(record, count == 0); err != nil {
+				return err
+			}
+			count++
+		}
+		return export.flush()
+	})
+	if result.Error != nil {
+		log.Printf("Failed to stream %s export after %d rows: %v", name, count, result.Error)
+		c.Abort()
+		return
+	}
+
+	if err := export.finish(); err != nil {
+		log.Printf("Failed to finish %s export: %v", name, err)
+	}
+}
+
+// csvSafe keeps spreadsheet apps from evaluating user input as a formula
+func csvSafe(value string) string {
+	if value == "" {
+		return value
+	}
+	switch value[0] {
+	case '=', '+', '-', '@', '\t', '\r':
+		return "'" + value
+	}
+	return value
+}
+
+// exportTime formats a timestamp for exports
+func exportTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// RequestExportRow is a request in CSV and JSON exports
+type RequestExportRow struct {
+	ID         uint                 `json:"id"`
+	Title      string               `json:"title"`
+	Year       int                  `json:"year"`
+	MediaType  models.MediaType     `json:"media_type"`
+	Status     models.RequestStatus `json:"status"`
+	TMDBId     int                  `json:"tmdb_id"`
+	IMDBId     string               `json:"imdb_id"`
+	UserID     uint                 `json:"user_id"`
+	Username   string               `json:"username"`
+	Email      string               `json:"email"`
+	Notes      string               `json:"notes"`
+	AdminNotes string               `json:"admin_notes"`
+	CreatedAt  string               `json:"created_at"`
+	UpdatedAt  string               `json:"updated_at"`
+}
+
+var requestExportHeader = []string{
+	"id", "title", "year", "media_type", "status", "tmdb_id", "imdb_id",
+	"user_id", "username", "email", "notes", "admin_notes", "created_at", "updated_at",
+}
+
+func (r RequestExportRow) csvRecord() []string {
+	return []string{
+		strconv.FormatUint(uint64(r.ID), 10),
+		csvSafe(r.Title),
+		strconv.Itoa(r.Year),
+		string(r.MediaType),
+		string(r.Status),
+		strconv.Itoa(r.TMDBId),
+		csvSafe(r.IMDBId),
+		strconv.FormatUint(uint64(r.UserID), 10),
+		csvSafe(r.Username),
+		csvSafe(r.Email),
+		csvSafe(r.Notes),
+		csvSafe(r.AdminNotes),
+		r.CreatedAt,
+		r.UpdatedAt,
+	}
+}
+
+func toRequestExportRows(requests []models.Request) []exportRecord {
+	records := make([]exportRecord, len(requests))
+	for i, request := range requests {
+		records[i] = RequestExportRow{
+			ID:         request.ID,
+			Title:      request.Title,
+			Year:       request.Year,
+			MediaType:  request.MediaType,
+			Status:     request.Status,
+			TMDBId:     request.TMDBId,
+			IMDBId:     request.IMDBId,
+			UserID:     request.UserID,
+			Username:   request.User.Username,
+			Email:      request.User.Email,
+			Notes:      request.Notes,
+			AdminNotes: request.AdminNotes,
+			CreatedAt:  exportTime(request.CreatedAt),
+			UpdatedAt:  exportTime(request.UpdatedAt),
+		}
+	}
+	return records
+}
+
+// ExportRequests streams requests as CSV or JSON
+// @Summary Export requests
+// @Description Download requests as CSV or JSON (admin only). Accepts the same filters as GET /requests.
+// @Tags requests
+// @Produce text/csv
+// @Produce json
+// @Security BearerAuth
+// @Param format query string false "Export format (csv, json)" default(csv)
+// @Param status query string false "Filter by status (pending, approved, completed, rejected)"
+// @Param user_id query int false "Filter by user ID"
+// @Param media_type query string false "Filter by media type (movie, tv)"
+// @Param q query string false "Search request titles"
+// @Param from query string false "Only requests created on or after this date (YYYY-MM-DD or RFC3339)"
+// @Param to query string false "Only requests created on or before this date (YYYY-MM-DD or RFC3339)"
+// @Success 200 {array} RequestExportRow
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /requests/export [get]
+func (h *requestHandler) ExportRequests(c *gin.Context) {
+	query, err := applyRequestFilters(h.db.Model(&models.Request{}), c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	// Deleted users still own their requests
+	query = query.Preload("User", func(db *gorm.DB) *gorm.DB {
+		return db.Unscoped()
+	})
+
+	streamExport(c, query, "requests", requestExportHeader, toRequestExportRows)
+}
+
+// AuditLogExportRow is an audit log entry in CSV and JSON exports
+type AuditLogExportRow struct {
+	ID           uint                   `json:"id"`
+	EntityType   models.AuditEntityType `json:"entity_type"`
+	EntityID     uint                   `json:"entity_id"`
+	RequestID    *uint                  `json:"request_id"`
+	RequestTitle string                 `json:"request_title"`
+	UserID       *uint                  `json:"user_id"` // Null for system actions
+	Username     string                 `json:"username"`
+	Action       models.AuditAction     `json:"action"`
+	OldValue     string                 `json:"old_value"`
+	NewValue     string                 `json:"new_value"`
+	Notes        string                 `json:"notes"`
+	IPAddress    string                 `json:"ip_address"`
+	UserAgent    string                 `json:"user_agent"`
+	CreatedAt    string                 `json:"created_at"`
+}
+
+var auditLogExportHeader = []string{
+	"id", "entity_type", "entity_id", "request_id", "request_title", "user_id", "username", "action",
+	"old_value", "new_value", "notes", "ip_address", "user_agent", "created_at",
+}
+
+// optionalID formats a nullable ID, empty when null
+func optionalID(id *uint) string {
+	if id == nil {
+		return ""
+	}
+	return strconv.FormatUint(uint64(*id), 10)
+}
+
+func (r AuditLogExportRow) csvRecord() []string {
+	return []string{
+		strconv.FormatUint(uint64(r.ID), 10),
+		string(r.EntityType),
+		strconv.FormatUint(uint64(r.EntityID), 10),
+		optionalID(r.RequestID),
+		csvSafe(r.RequestTitle),
+		optionalID(r.UserID),
+		csvSafe(r.Username),
+		string(r.Action),
+		csvSafe(r.OldValue),
+		csvSafe(r.NewValue),
+		csvSafe(r.Notes),
+		csvSafe(r.IPAddress),
+		csvSafe(r.UserAgent),
+		r.CreatedAt,
+	}
+}
+
+func toAuditLogExportRows(logs []models.AuditLog) []exportRecord {
+	records := make([]exportRecord, len(logs))
+	for i, entry := range logs {
+		row := AuditLogExportRow{
+			ID:         entry.ID,
+			EntityType: entry.EntityType,
+			EntityID:   entry.EntityID,
+			RequestID:  entry.RequestID,
+			UserID:     entry.UserID,
+			Username:   "System",
+			Action:     entry.Action,
+			OldValue:   entry.OldValue,
+			NewValue:   entry.NewValue,
+			Notes:      entry.Notes,
+			IPAddress:  entry.IPAddress,
+			UserAgent:  entry.UserAgent,
+			CreatedAt:  exportTime(entry.CreatedAt),
+		}
+		if entry.Request != nil {
+			row.RequestTitle = entry.Request.Title
+		}
+		if entry.User != nil {
+			row.Username = entry.User.Username
+		}
+		records[i] = row
+	}
+	return records
+}
+
+// ExportAuditLogs streams audit logs as CSV or JSON
+// @Summary Export audit logs
+// @Description Download audit logs as CSV or JSON (admin only), including the history of deleted requests and users
+// @Tags audit
+// @Produce text/csv
+// @Produce json
+// @Security BearerAuth
+// @Param format query string false "Export format (csv, json)" default(csv)
+// @Param user_id query string false "Filter by acting user ID, or 'system' for system actions"
+// @Param action query string false "Filter by action (e.g. approved, rejected, login_failed)"
+// @Param entity_type query string false "Filter by entity type (request, user)"
+// @Param entity_id query int false "Filter by entity ID"
+// @Param request_id query int false "Filter by request ID"
+// @Param from query string false "Only entries on or after this date (YYYY-MM-DD or RFC3339)"
+// @Param to query string false "Only entries on or before this date (YYYY-MM-DD or RFC3339)"
+// @Success 200 {array} AuditLogExportRow
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /audit-logs/export [get]
+func (h *auditHandler) ExportAuditLogs(c *gin.Context) {
+	query, err := applyAuditLogFilters(h.db.Model(&models.AuditLog{}), c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	// Keep the history of deleted requests and users readable
+	unscoped := func(db *gorm.DB) *gorm.DB {
+		return db.Unscoped()
+	}
+	query = query.Preload("Request", unscoped).Preload("User", unscoped)
+
+	streamExport(c, query, "audit-logs", auditLogExportHeader, toAuditLogExportRows)
+}
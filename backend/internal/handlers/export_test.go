@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jacob-fain/MRS/internal/models"
+	"github.com/jacob-fain/MRS/internal/services"
+	"github.com/jacob-fain/MRS/internal/testutil"
+)
+
+func TestRequestHandler_ExportRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := testutil.SetupTestDB(t)
+	admin := testutil.CreateTestUser(t, db, "admin@example.com", "admin", "hashedpass", true)
+	user := testutil.CreateTestUser(t, db, "user@example.com", "user", "hashedpass", false)
+
+	dune := testutil.CreateTestRequest(t, db, user.ID, "Dune", models.MediaTypeMovie)
+	db.Model(dune).Updates(map[string]interface{}{"tmdb_id": 438631, "imdb_id": "tt1160419", "notes": "=HYPERLINK(\"x\")"})
+	testutil.CreateTestRequest(t, db, user.ID, "Severance", models.MediaTypeTV)
+	testutil.CreateTestRequest(t, db, admin.ID, "Arrival", models.MediaTypeMovie)
+
+	// The owner being deleted doesn't hide their requests
+	db.Delete(user)
+
+	router := newTestRouter(admin.ID, true)
+	router.GET("/requests/export", NewRequestHandler(db, nil, nil).ExportRequests)
+
+	t.Run("CSV with filters", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/requests/export?user_id="+fmt.Sprint(user.ID)+"&media_type=movie", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		testutil.AssertEqual(t, http.StatusOK, w.Code)
+		testutil.AssertEqual(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+		testutil.AssertTrue(t, strings.HasPrefix(w.Header().Get("Content-Disposition"), `attachment; filename="requests-`), "should be a download")
+
+		records, err := csv.NewReader(w.Body).ReadAll()
+		testutil.AssertNoError(t, err)
+		testutil.AssertEqual(t, 2, len(records))
+		testutil.AssertEqual(t, strings.Join(requestExportHeader, ","), strings.Join(records[0], ","))
+		testutil.AssertEqual(t, "Dune", records[1][1])
+		testutil.AssertEqual(t, "438631", records[1][5])
+		testutil.AssertEqual(t, "tt1160419", records[1][6])
+		testutil.AssertEqual(t, "user", records[1][8])
+		testutil.AssertEqual(t, `'=HYPERLINK("x")`, records[1][10])
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/requests/export?format=json", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		testutil.AssertEqual(t, http.StatusOK, w.Code)
+
+		var rows []RequestExportRow
+		testutil.AssertNoError(t, json.Unmarshal(w.Body.Bytes(), &rows))
+		testutil.AssertEqual(t, 3, len(rows))
+		testutil.AssertEqual(t, "Dune", rows[0].Title)
+		testutil.AssertEqual(t, "user@example.com", rows[0].Email)
+		testutil.AssertEqual(t, "admin", rows[2].Username)
+	})
+
+	t.Run("empty JSON export is an empty array", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/requests/export?format=json&status=rejected", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var rows []RequestExportRow
+		testutil.AssertNoError(t, json.Unmarshal(w.Body.Bytes(), &rows))
+		testutil.AssertEqual(t, 0, len(rows))
+	})
+
+	t.Run("invalid format", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/requests/export?format=xml", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		testutil.AssertEqual(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("invalid filter", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/requests/export?from=yesterday", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		testutil.AssertEqual(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestAuditHandler_ExportAuditLogs(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := testutil.SetupTestDB(t)
+	auditService := services.NewAuditService(db)
+	admin := testutil.CreateTestUser(t, db, "admin@example.com", "admin", "hashedpass", true)
+	user := testutil.CreateTestUser(t, db, "user@example.com", "user", "hashedpass", false)
+
+	dune := testutil.CreateTestRequest(t, db, user.ID, "Dune", models.MediaTypeMovie)
+	arrival := testutil.CreateTestRequest(t, db, user.ID, "Arrival", models.MediaTypeMovie)
+	testutil.AssertNoError(t, auditService.LogRequestCreated(dune.ID, user.ID))
+	testutil.AssertNoError(t, auditService.LogRequestCreated(arrival.ID, user.ID))
+	testutil.AssertNoError(t, auditService.LogRequestStatusChange(dune.ID, &admin.ID, models.StatusPending, models.StatusRejected))
+	testutil.AssertNoError(t, auditService.LogRequestStatusChange(arrival.ID, nil, models.StatusPending, models.StatusApproved))
+
+	// History of deleted requests stays in the export
+	db.Delete(dune)
+
+	router := newTestRouter(admin.ID, true)
+	router.GET("/audit-logs/export", NewAuditHandler(db, nil).ExportAuditLogs)
+
+	tests := []struct {
+		name          string
+		query         string
+		expectedCount int
+		check         func(t *testing.T, rows []AuditLogExportRow)
+	}{
+		{
+			name:          "everything",
+			expectedCount: 4,
+			check: func(t *testing.T, rows []AuditLogExportRow) {
+				testutil.AssertEqual(t, "Dune", rows[0].RequestTitle)
+				testutil.AssertEqual(t, "user", rows[0].Username)
+			},
+		},
+		{
+			name:          "by actor",
+			query:         "&user_id=" + fmt.Sprint(admin.ID),
+			expectedCount: 1,
+			check: func(t *testing.T, rows []AuditLogExportRow) {
+				testutil.AssertEqual(t, models.ActionRejected, rows[0].Action)
+				testutil.AssertEqual(t, "Dune", rows[0].RequestTitle)
+			},
+		},
+		{
+			name:          "system actions",
+			query:         "&user_id=system",
+			expectedCount: 1,
+			check: func(t *testing.T, rows []AuditLogExportRow) {
+				testutil.AssertEqual(t, "System", rows[0].Username)
+				testutil.AssertTrue(t, rows[0].UserID == nil, "user_id should be null")
+			},
+		},
+		{
+			name:          "by action and request",
+			query:         "&action=created&request_id=" + fmt.Sprint(arrival.ID),
+			expectedCount: 1,
+		},
+		{
+			name:          "date range",
+			query:         "&from=2000-01-01&to=2000-12-31",
+			expectedCount: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/audit-logs/export?format=json"+tt.query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			testutil.AssertEqual(t, http.StatusOK, w.Code)
+
+			var rows []AuditLogExportRow
+			testutil.AssertNoError(t, json.Unmarshal(w.Body.Bytes(), &rows))
+			testutil.AssertEqual(t, tt.expectedCount, len(rows))
+			if tt.check != nil {
+				tt.check(t, rows)
+			}
+		})
+	}
+
+	req, _ := http.NewRequest("GET", "/audit-logs/export?user_id=someone", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	testutil.AssertEqual(t, http.StatusBadRequest, w.Code)
+}
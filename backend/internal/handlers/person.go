@@ -1,24 +1,44 @@
 package handlers
 
 import (
+	"context"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jacob-fain/MRS/internal/services"
+	"gorm.io/gorm"
 )
 
 type personHandler struct {
-	tmdbService services.TMDBServiceInterface
+	db           *gorm.DB
+	tmdbService  services.TMDBServiceInterface
+	plexService  services.PlexServiceInterface
+	auditService *services.AuditService
 }
 
 // NewPersonHandler creates a new person handler
-func NewPersonHandler(tmdbService services.TMDBServiceInterface) *personHandler {
+func NewPersonHandler(db *gorm.DB, tmdbService services.TMDBServiceInterface, plexService services.PlexServiceInterface, auditService *services.AuditService) *personHandler {
 	return &personHandler{
-		tmdbService: tmdbService,
+		db:           db,
+		tmdbService:  tmdbService,
+		plexService:  plexService,
+		auditService: auditService,
 	}
 }
 
+// RequestMissingCreditsInput selects the credits to request. Cast credits belong to
+// the "Acting" department with the job "Actor". At least one of department, job or
+// tmdb_ids is required, so a whole filmography isn't requested by accident.
+type RequestMissingCreditsInput struct {
+	MediaType  string `json:"media_type" binding:"omitempty,oneof=movie tv"`
+	Department string `json:"department"` // e.g. "Directing"
+	Job        string `json:"job"`        // e.g. "Director"
+	TMDBIds    []int  `json:"tmdb_ids"`   // Only request these titles
+}
+
 // SearchPerson searches for people (actors, directors, crew)
 // @Summary Search for people
 // @Description Search for actors, directors, and crew members
@@ -30,6 +50,7 @@ func NewPersonHandler(tmdbService services.TMDBServiceInterface) *personHandler
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
+// @Failure 503 {object} map[string]string
 // @Router /person/search [get]
 func (h *personHandler) SearchPerson(c *gin.Context) {
 	query := c.Query("q")
@@ -49,9 +70,9 @@ func (h *personHandler) SearchPerson(c *gin.Context) {
 	}
 
 	// Search TMDB for people
-	results, err := h.tmdbService.SearchPerson(query, page)
+	results, err := h.tmdbService.SearchPersonContext(c.Request.Context(), query, page)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
+		c.JSON(upstreamErrorStatus(err), gin.H{
 			"error":   "failed to search for people",
 			"details": err.Error(),
 		})
@@ -76,6 +97,7 @@ func (h *personHandler) SearchPerson(c *gin.Context) {
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
+// @Failure 503 {object} map[string]string
 // @Router /person/{id} [get]
 func (h *personHandler) GetPersonDetails(c *gin.Context) {
 	idStr := c.Param("id")
@@ -89,9 +111,9 @@ func (h *personHandler) GetPersonDetails(c *gin.Context) {
 	}
 
 	// Get person details from TMDB
-	person, err := h.tmdbService.GetPersonDetails(id)
+	person, err := h.tmdbService.GetPersonDetailsContext(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
+		c.JSON(upstreamErrorStatus(err), gin.H{
 			"error":   "failed to get person details",
 			"details": err.Error(),
 		})
@@ -103,14 +125,16 @@ func (h *personHandler) GetPersonDetails(c *gin.Context) {
 
 // GetPersonCredits fetches a person's complete filmography
 // @Summary Get person filmography
-// @Description Get complete filmography for a person (movies and TV shows)
+// @Description Get complete filmography for a person (movies and TV shows), with Plex availability and whether the current user already requested each title
 // @Tags person
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "Person ID"
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
+// @Failure 503 {object} map[string]string
 // @Router /person/{id}/credits [get]
 func (h *personHandler) GetPersonCredits(c *gin.Context) {
 	idStr := c.Param("id")
@@ -124,14 +148,191 @@ func (h *personHandler) GetPersonCredits(c *gin.Context) {
 	}
 
 	// Get person credits from TMDB
-	credits, err := h.tmdbService.GetPersonCredits(id)
+	credits, err := h.tmdbService.GetPersonCreditsContext(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
+		c.JSON(upstreamErrorStatus(err), gin.H{
 			"error":   "failed to get person credits",
 			"details": err.Error(),
 		})
 		return
 	}
 
+	userID, _ := c.Get("userID")
+	if err := h.annotateCredits(c.Request.Context(), userID, credits); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to check requested titles",
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, credits)
 }
+
+// RequestMissing requests a person's released titles that are neither in Plex nor
+// already requested by the current user, e.g. every film they directed
+// @Summary Request missing titles from a filmography
+// @Description Create one request per missing title among the selected credits, in a single transaction. Requests are auto-approved for admins.
+// @Tags person
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Person ID"
+// @Param request body RequestMissingCreditsInput true "Credit filters, at least one of department, job or tmdb_ids"
+// @Success 201 {object} map[string]interface{}
+// @Success 200 {object} map[string]interface{} "Nothing to request"
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /person/{id}/request-missing [post]
+func (h *personHandler) RequestMissing(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	isAdmin, _ := c.Get("isAdmin")
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid person ID",
+		})
+		return
+	}
+
+	var input RequestMissingCreditsInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid request data",
+		})
+		return
+	}
+	if input.Department == "" && input.Job == "" && len(input.TMDBIds) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "department, job or tmdb_ids is required",
+		})
+		return
+	}
+
+	person, err := h.tmdbService.GetPersonDetailsContext(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(upstreamErrorStatus(err), gin.H{
+			"error":   "failed to get person details",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	credits, err := h.tmdbService.GetPersonCreditsContext(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(upstreamErrorStatus(err), gin.H{
+			"error":   "failed to get person credits",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := h.annotateCredits(c.Request.Context(), userID, credits); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to check requested titles",
+		})
+		return
+	}
+
+	// A title can appear in several credits, e.g. as director and writer
+	seen := make(map[string]bool)
+	var missing []services.TMDBResult
+	addMissing := func(result services.TMDBResult, inPlex, requested bool, department, job string) {
+		key := requestKey(result.MediaType, result.ID)
+		if seen[key] || inPlex || requested || !isReleased(result) || !input.matches(result, department, job) {
+			return
+		}
+		seen[key] = true
+		missing = append(missing, result)
+	}
+	for _, credit := range credits.Cast {
+		addMissing(credit.Result(), credit.InPlex, credit.Requested, "Acting", "Actor")
+	}
+	for _, credit := range credits.Crew {
+		addMissing(credit.Result(), credit.InPlex, credit.Requested, credit.Department, credit.Job)
+	}
+
+	notes := fmt.Sprintf("Requested from the filmography of %s", person.Name)
+	requests, err := createRequestsForResults(h.db, auditFor(c, h.auditService), userID.(uint), isAdmin.(bool), missing, notes)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to create requests",
+		})
+		return
+	}
+
+	status := http.StatusCreated
+	if len(requests) == 0 {
+		status = http.StatusOK
+	}
+
+	responses := make([]RequestResponse, len(requests))
+	for i, request := range requests {
+		responses[i] = toRequestResponse(request)
+	}
+
+	c.JSON(status, gin.H{
+		"person_id": id,
+		"requests":  responses,
+		"count":     len(responses),
+	})
+}
+
+// matches reports whether a credit is selected by the input
+func (input RequestMissingCreditsInput) matches(result services.TMDBResult, department, job string) bool {
+	if result.MediaType != "movie" && result.MediaType != "tv" {
+		return false
+	}
+	if input.MediaType != "" && result.MediaType != input.MediaType {
+		return false
+	}
+	if input.Department != "" && !strings.EqualFold(input.Department, department) {
+		return false
+	}
+	if input.Job != "" && !strings.EqualFold(input.Job, job) {
+		return false
+	}
+	if len(input.TMDBIds) > 0 {
+		for _, id := range input.TMDBIds {
+			if id == result.ID {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// annotateCredits sets InPlex and Requested on every cast and crew credit
+func (h *personHandler) annotateCredits(ctx context.Context, userID interface{}, credits *services.TMDBPersonCredits) error {
+	results := make([]services.TMDBResult, 0, len(credits.Cast)+len(credits.Crew))
+	for _, credit := range credits.Cast {
+		results = append(results, credit.Result())
+	}
+	for _, credit := range credits.Crew {
+		results = append(results, credit.Result())
+	}
+
+	// Check Plex availability for all credits at once
+	markInPlex(ctx, h.plexService, results)
+
+	requested := make(map[string]bool)
+	if h.db != nil {
+		var err error
+		if requested, err = requestedTitles(h.db, userID, results); err != nil {
+			return err
+		}
+	}
+
+	for i := range credits.Cast {
+		credits.Cast[i].InPlex = results[i].InPlex
+		credits.Cast[i].Requested = requested[requestKey(credits.Cast[i].MediaType, credits.Cast[i].ID)]
+	}
+	offset := len(credits.Cast)
+	for i := range credits.Crew {
+		credits.Crew[i].InPlex = results[offset+i].InPlex
+		credits.Crew[i].Requested = requested[requestKey(credits.Crew[i].MediaType, credits.Crew[i].ID)]
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jacob-fain/MRS/internal/models"
+	"github.com/jacob-fain/MRS/internal/services"
+	"github.com/jacob-fain/MRS/internal/testutil"
+)
+
+func villeneuveTMDB() *mockTMDBService {
+	return &mockTMDBService{
+		personDetailsFunc: func(personID int) (*services.TMDBPersonDetails, error) {
+			return &services.TMDBPersonDetails{ID: personID, Name: "Denis Villeneuve"}, nil
+		},
+		personCreditsFunc: func(personID int) (*services.TMDBPersonCredits, error) {
+			return &services.TMDBPersonCredits{
+				Cast: []services.TMDBPersonCast{
+					{ID: 1000, Title: "Cameo", MediaType: "movie", Character: "Himself", ReleaseDate: "2015-01-01"},
+				},
+				Crew: []services.TMDBPersonCrew{
+					{ID: 27205, Title: "Incendies", MediaType: "movie", Department: "Directing", Job: "Director", ReleaseDate: "2010-09-17"},
+					{ID: 27205, Title: "Incendies", MediaType: "movie", Department: "Writing", Job: "Screenplay", ReleaseDate: "2010-09-17"},
+					{ID: 146233, Title: "Prisoners", MediaType: "movie", Department: "Directing", Job: "Director", ReleaseDate: "2013-09-19"},
+					{ID: 335984, Title: "Blade Runner 2049", MediaType: "movie", Department: "Directing", Job: "Director", ReleaseDate: "2017-10-04"},
+					{ID: 329865, Title: "Arrival", MediaType: "movie", Department: "Directing", Job: "Director", ReleaseDate: "2016-11-10"},
+					{ID: 9999999, Title: "Untitled Project", MediaType: "movie", Department: "Directing", Job: "Director"},
+				},
+			}, nil
+		},
+	}
+}
+
+func villeneuvePlex() *mockPlexService {
+	return &mockPlexService{
+		checkIfExistsFunc: func(title string, year int, mediaType string) (bool, error) {
+			return title == "Blade Runner 2049", nil
+		},
+	}
+}
+
+func TestPersonHandler_GetPersonCredits(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, db, "user@example.com", "testuser", "hashedpass", false)
+
+	arrival := testutil.CreateTestRequest(t, db, user.ID, "Arrival", models.MediaTypeMovie)
+	db.Model(arrival).Update("tmdb_id", 329865)
+
+	router := newTestRouter(user.ID, user.IsAdmin)
+	router.GET("/person/:id/credits", NewPersonHandler(db, villeneuveTMDB(), villeneuvePlex(), nil).GetPersonCredits)
+
+	req, err := http.NewRequest("GET", "/person/137427/credits", nil)
+	testutil.AssertNoError(t, err)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	testutil.AssertEqual(t, http.StatusOK, w.Code)
+
+	var response services.TMDBPersonCredits
+	testutil.AssertNoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	testutil.AssertEqual(t, false, response.Cast[0].InPlex)
+	testutil.AssertEqual(t, false, response.Crew[0].Requested)
+	testutil.AssertEqual(t, true, response.Crew[3].InPlex)
+	testutil.AssertEqual(t, true, response.Crew[4].Requested)
+}
+
+func TestPersonHandler_RequestMissing(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name                  string
+		body                  string
+		isAdmin               bool
+		expectedStatus        int
+		expectedTitles        []string
+		expectedRequestStatus models.RequestStatus
+	}{
+		{
+			name:                  "films directed",
+			body:                  `{"job": "director"}`,
+			expectedStatus:        http.StatusCreated,
+			expectedTitles:        []string{"Incendies", "Prisoners"},
+			expectedRequestStatus: models.StatusPending,
+		},
+		{
+			name:                  "selected credits only",
+			body:                  `{"department": "Writing", "tmdb_ids": [27205]}`,
+			expectedStatus:        http.StatusCreated,
+			expectedTitles:        []string{"Incendies"},
+			expectedRequestStatus: models.StatusPending,
+		},
+		{
+			name:                  "admins are auto-approved",
+			body:                  `{"tmdb_ids": [1000, 27205, 146233]}`,
+			isAdmin:               true,
+			expectedStatus:        http.StatusCreated,
+			expectedTitles:        []string{"Cameo", "Incendies", "Prisoners"},
+			expectedRequestStatus: models.StatusApproved,
+		},
+		{
+			name:           "no body",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "no credit filter",
+			body:           `{"media_type": "movie"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "nothing missing",
+			body:           `{"job": "Composer"}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "invalid media type",
+			body:           `{"media_type": "person"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := testutil.SetupTestDB(t)
+			user := testutil.CreateTestUser(t, db, "user@example.com", "testuser", "hashedpass", tt.isAdmin)
+			arrival := testutil.CreateTestRequest(t, db, user.ID, "Arrival", models.MediaTypeMovie)
+			db.Model(arrival).Update("tmdb_id", 329865)
+
+			handler := NewPersonHandler(db, villeneuveTMDB(), villeneuvePlex(), services.NewAuditService(db))
+			router := newTestRouter(user.ID, user.IsAdmin)
+			router.POST("/person/:id/request-missing", handler.RequestMissing)
+
+			req, err := http.NewRequest("POST", "/person/137427/request-missing", bytes.NewBufferString(tt.body))
+			testutil.AssertNoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			testutil.AssertEqual(t, tt.expectedStatus, w.Code)
+
+			var created []models.Request
+			db.Where("user_id = ? AND id <> ?", user.ID, arrival.ID).Order("title").Find(&created)
+			testutil.AssertEqual(t, len(tt.expectedTitles), len(created))
+			for i, request := range created {
+				testutil.AssertEqual(t, tt.expectedTitles[i], request.Title)
+				testutil.AssertEqual(t, tt.expectedRequestStatus, request.Status)
+				testutil.AssertEqual(t, "Requested from the filmography of Denis Villeneuve", request.Notes)
+			}
+		})
+	}
+}
@@ -56,7 +56,7 @@ func (h *plexHandler) CheckMedia(c *gin.Context) {
 		}
 	}
 
-	exists, err := h.plexService.CheckIfExists(title, year, mediaType)
+	exists, err := h.plexService.CheckIfExistsContext(c.Request.Context(), title, year, mediaType)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "failed to check Plex library",
@@ -92,7 +92,7 @@ func (h *plexHandler) SearchPlex(c *gin.Context) {
 		return
 	}
 
-	results, err := h.plexService.SearchLibrary(query)
+	results, err := h.plexService.SearchLibraryContext(c.Request.Context(), query)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "failed to search Plex library",
@@ -116,7 +116,7 @@ func (h *plexHandler) SearchPlex(c *gin.Context) {
 // @Failure 500 {object} map[string]string
 // @Router /plex/libraries [get]
 func (h *plexHandler) GetLibraries(c *gin.Context) {
-	libraries, err := h.plexService.GetLibraries()
+	libraries, err := h.plexService.GetLibrariesContext(c.Request.Context())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "failed to get Plex libraries",
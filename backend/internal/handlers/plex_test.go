@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"net/http"
@@ -17,23 +18,47 @@ type mockPlexService struct {
 	checkIfExistsFunc   func(title string, year int, mediaType string) (bool, error)
 	searchLibraryFunc   func(query string) ([]services.PlexSearchResult, error)
 	getLibrariesFunc    func() ([]services.PlexLibrary, error)
+	showSeasonsFunc     func(title string, year int) (map[int][]int, error)
+	seasonEpisodesFunc  func(title string, year int, seasonNumber int) ([]int, error)
 }
 
-func (m *mockPlexService) CheckIfExists(title string, year int, mediaType string) (bool, error) {
+func (m *mockPlexService) GetShowSeasonsContext(ctx context.Context, title string, year int) (map[int][]int, error) {
+	if m.showSeasonsFunc != nil {
+		return m.showSeasonsFunc(title, year)
+	}
+	return nil, nil
+}
+
+func (m *mockPlexService) GetSeasonEpisodesContext(ctx context.Context, title string, year int, seasonNumber int) ([]int, error) {
+	if m.seasonEpisodesFunc != nil {
+		return m.seasonEpisodesFunc(title, year, seasonNumber)
+	}
+	return nil, nil
+}
+
+func (m *mockPlexService) CheckIfExistsContext(ctx context.Context, title string, year int, mediaType string) (bool, error) {
 	if m.checkIfExistsFunc != nil {
 		return m.checkIfExistsFunc(title, year, mediaType)
 	}
 	return false, nil
 }
 
-func (m *mockPlexService) SearchLibrary(query string) ([]services.PlexSearchResult, error) {
+func (m *mockPlexService) CheckManyExistContext(ctx context.Context, refs []services.MediaRef) map[services.MediaRef]bool {
+	found := make(map[services.MediaRef]bool, len(refs))
+	for _, ref := range refs {
+		found[ref], _ = m.CheckIfExistsContext(ctx, ref.Title, ref.Year, ref.MediaType)
+	}
+	return found
+}
+
+func (m *mockPlexService) SearchLibraryContext(ctx context.Context, query string) ([]services.PlexSearchResult, error) {
 	if m.searchLibraryFunc != nil {
 		return m.searchLibraryFunc(query)
 	}
 	return []services.PlexSearchResult{}, nil
 }
 
-func (m *mockPlexService) GetLibraries() ([]services.PlexLibrary, error) {
+func (m *mockPlexService) GetLibrariesContext(ctx context.Context) ([]services.PlexLibrary, error) {
 	if m.getLibrariesFunc != nil {
 		return m.getLibrariesFunc()
 	}
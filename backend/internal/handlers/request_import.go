@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jacob-fain/MRS/internal/services"
+	"gorm.io/gorm"
+)
+
+// maxImportFileSize is the largest export accepted for a preview
+const maxImportFileSize = 5 << 20
+
+// importFormatNames are the display names used in the notes of imported requests
+var importFormatNames = map[services.ImportFormat]string{
+	services.ImportFormatIMDb:       "IMDb",
+	services.ImportFormatLetterboxd: "Letterboxd",
+	services.ImportFormatTrakt:      "Trakt",
+}
+
+type importHandler struct {
+	db            *gorm.DB
+	importService *services.ImportService
+	auditService  *services.AuditService
+}
+
+// NewImportHandler creates a new import handler
+func NewImportHandler(db *gorm.DB, importService *services.ImportService, auditService *services.AuditService) *importHandler {
+	return &importHandler{
+		db:            db,
+		importService: importService,
+		auditService:  auditService,
+	}
+}
+
+// ConfirmImportInput represents the titles picked from an import preview
+type ConfirmImportInput struct {
+	Format services.ImportFormat `json:"format" binding:"omitempty,oneof=imdb letterboxd trakt"`
+	Items  []CreateRequestInput  `json:"items" binding:"required,min=1,dive"`
+}
+
+// PreviewImport resolves an exported watchlist to TMDB titles without creating requests
+// @Summary Preview a watchlist import
+// @Description Upload an IMDb CSV, Letterboxd CSV or Trakt JSON export. Titles are matched on TMDB by IMDb ID where possible, then by title and year. Nothing is requested until the import is confirmed.
+// @Tags requests
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param file formData file true "Export file"
+// @Param format formData string false "Export format (imdb, letterboxd or trakt), detected when omitted"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 413 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /requests/import [post]
+func (h *importHandler) PreviewImport(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Export file is required",
+		})
+		return
+	}
+	if fileHeader.Size > maxImportFileSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error": "Export file is too large",
+		})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to read export file",
+		})
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to read export file",
+		})
+		return
+	}
+
+	format := services.ImportFormat(c.PostForm("format"))
+	if format == "" {
+		if format, err = services.DetectImportFormat(data); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Unrecognized export format",
+				"details": err.Error(),
+			})
+			return
+		}
+	}
+
+	rows, err := services.ParseImport(format, data)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid export file",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	preview, err := h.importService.Preview(c.Request.Context(), rows)
+	if err != nil {
+		if errors.Is(err, services.ErrImportTooManyRows) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+				"error":   "Export has too many titles",
+				"details": err.Error(),
+			})
+			return
+		}
+		c.JSON(upstreamErrorStatus(err), gin.H{
+			"error":   "Failed to match titles",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// Flag titles the user already requested
+	results := make([]services.TMDBResult, len(preview.Matched))
+	for i, match := range preview.Matched {
+		results[i] = match.Result
+	}
+	requested, err := requestedTitles(h.db, userID, results)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to check requested titles",
+		})
+		return
+	}
+	for i, match := range preview.Matched {
+		preview.Matched[i].Requested = requested[requestKey(match.Result.MediaType, match.Result.ID)]
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"format":    format,
+		"rows":      len(rows),
+		"matched":   preview.Matched,
+		"in_plex":   preview.InPlex,
+		"unmatched": preview.Unmatched,
+	})
+}
+
+// ConfirmImport creates requests for the titles picked from an import preview
+// @Summary Confirm a watchlist import
+// @Description Create one request per picked title, in a single transaction. Titles the user already requested are skipped. At most IMPORT_MAX_ROWS titles are accepted.
+// @Tags requests
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param import body ConfirmImportInput true "Picked titles"
+// @Success 201 {object} map[string]interface{}
+// @Success 200 {object} map[string]interface{} "Nothing to request"
+// @Failure 400 {object} map[string]string
+// @Failure 413 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /requests/import/confirm [post]
+func (h *importHandler) ConfirmImport(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	isAdmin, _ := c.Get("isAdmin")
+
+	var input ConfirmImportInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request data",
+		})
+		return
+	}
+	if err := h.importService.CheckRows(len(input.Items)); err != nil {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error":   "Import has too many titles",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	source := "a watchlist export"
+	if name, ok := importFormatNames[input.Format]; ok {
+		source = name
+	}
+	items := make([]services.NewRequest, len(input.Items))
+	for i, item := range input.Items {
+		if item.Notes == "" {
+			item.Notes = fmt.Sprintf("Imported from %s", source)
+		}
+		items[i] = item.newRequest()
+	}
+
+	requests, err := services.CreateRequests(h.db, auditFor(c, h.auditService), userID.(uint), isAdmin.(bool), items)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create requests",
+		})
+		return
+	}
+
+	status := http.StatusCreated
+	if len(requests) == 0 {
+		status = http.StatusOK
+	}
+
+	responses := make([]RequestResponse, len(requests))
+	for i, request := range requests {
+		responses[i] = toRequestResponse(request)
+	}
+
+	c.JSON(status, gin.H{
+		"requests": responses,
+		"count":    len(responses),
+		"skipped":  len(input.Items) - len(responses),
+	})
+}
@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jacob-fain/MRS/internal/models"
+	"github.com/jacob-fain/MRS/internal/services"
+	"github.com/jacob-fain/MRS/internal/testutil"
+)
+
+func newImportUpload(t *testing.T, format, content string) *http.Request {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	if format != "" {
+		testutil.AssertNoError(t, writer.WriteField("format", format))
+	}
+	part, err := writer.CreateFormFile("file", "export.csv")
+	testutil.AssertNoError(t, err)
+	part.Write([]byte(content))
+	testutil.AssertNoError(t, writer.Close())
+
+	req, err := http.NewRequest("POST", "/requests/import", &body)
+	testutil.AssertNoError(t, err)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+func TestImportHandler_PreviewImport(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, db, "user@example.com", "testuser", "hashedpass", false)
+
+	arrival := testutil.CreateTestRequest(t, db, user.ID, "Arrival", models.MediaTypeMovie)
+	db.Model(arrival).Update("tmdb_id", 329865)
+
+	mockTMDB := &mockTMDBService{
+		findByIMDbIDFunc: func(imdbID string) (*services.TMDBFindResult, error) {
+			results := map[string]services.TMDBResult{
+				"tt2543164": {ID: 329865, Title: "Arrival", MediaType: "movie", ReleaseDate: "2016-11-10"},
+				"tt1856101": {ID: 335984, Title: "Blade Runner 2049", MediaType: "movie", ReleaseDate: "2017-10-04"},
+				"tt1255953": {ID: 46738, Title: "Incendies", MediaType: "movie", ReleaseDate: "2010-09-17"},
+			}
+			result, ok := results[imdbID]
+			if !ok {
+				return &services.TMDBFindResult{}, nil
+			}
+			return &services.TMDBFindResult{MovieResults: []services.TMDBResult{result}}, nil
+		},
+	}
+	importService, err := services.NewImportService(mockTMDB, villeneuvePlex())
+	testutil.AssertNoError(t, err)
+	router := newTestRouter(user.ID, user.IsAdmin)
+	router.POST("/requests/import", NewImportHandler(db, importService, nil).PreviewImport)
+
+	export := "Const,Title,Title Type,Year\n" +
+		"tt2543164,Arrival,Movie,2016\n" +
+		"tt1856101,Blade Runner 2049,Movie,2017\n" +
+		"tt1255953,Incendies,Movie,2010\n" +
+		"tt0000000,Lost Film,Movie,1920\n"
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newImportUpload(t, "", export))
+
+	testutil.AssertEqual(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Format    string                     `json:"format"`
+		Rows      int                        `json:"rows"`
+		Matched   []services.ImportMatch     `json:"matched"`
+		InPlex    []services.ImportMatch     `json:"in_plex"`
+		Unmatched []services.ImportUnmatched `json:"unmatched"`
+	}
+	testutil.AssertNoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	testutil.AssertEqual(t, "imdb", response.Format)
+	testutil.AssertEqual(t, 4, response.Rows)
+	testutil.AssertEqual(t, 2, len(response.Matched))
+	testutil.AssertEqual(t, true, response.Matched[0].Requested)
+	testutil.AssertEqual(t, false, response.Matched[1].Requested)
+	testutil.AssertEqual(t, 1, len(response.InPlex))
+	testutil.AssertEqual(t, "Blade Runner 2049", response.InPlex[0].Result.Title)
+	testutil.AssertEqual(t, 1, len(response.Unmatched))
+	testutil.AssertEqual(t, "tt0000000", response.Unmatched[0].Row.IMDbID)
+
+	// Nothing is requested by a preview
+	var count int64
+	db.Model(&models.Request{}).Count(&count)
+	testutil.AssertEqual(t, int64(1), count)
+
+	// Unknown exports are rejected
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, newImportUpload(t, "", "title\nArrival\n"))
+	testutil.AssertEqual(t, http.StatusBadRequest, w.Code)
+
+	// So are exports that don't match the given format
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, newImportUpload(t, "letterboxd", export))
+	testutil.AssertEqual(t, http.StatusBadRequest, w.Code)
+}
+
+func TestImportHandler_ConfirmImport(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name                  string
+		body                  string
+		isAdmin               bool
+		expectedStatus        int
+		expectedTitles        []string
+		expectedRequestStatus models.RequestStatus
+		expectedNotes         string
+	}{
+		{
+			name: "creates requests for picked titles",
+			body: `{"format": "letterboxd", "items": [
+				{"title": "Incendies", "year": 2010, "media_type": "movie", "tmdb_id": 46738},
+				{"title": "Arrival", "year": 2016, "media_type": "movie", "tmdb_id": 329865}
+			]}`,
+			expectedStatus:        http.StatusCreated,
+			expectedTitles:        []string{"Incendies"},
+			expectedRequestStatus: models.StatusPending,
+			expectedNotes:         "Imported from Letterboxd",
+		},
+		{
+			name: "admins are auto-approved",
+			body: `{"items": [
+				{"title": "Severance", "media_type": "tv", "tmdb_id": 95396, "imdb_id": "tt11280740", "notes": "For the weekend"}
+			]}`,
+			isAdmin:               true,
+			expectedStatus:        http.StatusCreated,
+			expectedTitles:        []string{"Severance"},
+			expectedRequestStatus: models.StatusApproved,
+			expectedNotes:         "For the weekend",
+		},
+		{
+			name:           "everything already requested",
+			body:           `{"items": [{"title": "Arrival", "media_type": "movie", "tmdb_id": 329865}]}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "invalid item",
+			body:           `{"items": [{"title": "Arrival", "media_type": "person"}]}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "no items",
+			body:           `{"items": []}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "more items than IMPORT_MAX_ROWS",
+			body: `{"items": [
+				{"title": "Incendies", "media_type": "movie", "tmdb_id": 46738},
+				{"title": "Prisoners", "media_type": "movie", "tmdb_id": 146233},
+				{"title": "Enemy", "media_type": "movie", "tmdb_id": 181886}
+			]}`,
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	t.Setenv("IMPORT_MAX_ROWS", "2")
+	importService, err := services.NewImportService(nil, nil)
+	testutil.AssertNoError(t, err)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := testutil.SetupTestDB(t)
+			user := testutil.CreateTestUser(t, db, "user@example.com", "testuser", "hashedpass", tt.isAdmin)
+			arrival := testutil.CreateTestRequest(t, db, user.ID, "Arrival", models.MediaTypeMovie)
+
+			handler := NewImportHandler(db, importService, services.NewAuditService(db))
+			router := newTestRouter(user.ID, user.IsAdmin)
+			router.POST("/requests/import/confirm", handler.ConfirmImport)
+
+			req, err := http.NewRequest("POST", "/requests/import/confirm", bytes.NewBufferString(tt.body))
+			testutil.AssertNoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			testutil.AssertEqual(t, tt.expectedStatus, w.Code)
+
+			var created []models.Request
+			db.Where("user_id = ? AND id <> ?", user.ID, arrival.ID).Order("title").Find(&created)
+			testutil.AssertEqual(t, len(tt.expectedTitles), len(created))
+			for i, request := range created {
+				testutil.AssertEqual(t, tt.expectedTitles[i], request.Title)
+				testutil.AssertEqual(t, tt.expectedRequestStatus, request.Status)
+				testutil.AssertEqual(t, tt.expectedNotes, request.Notes)
+			}
+		})
+	}
+}
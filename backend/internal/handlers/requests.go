@@ -5,6 +5,8 @@ import (
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jacob-fain/MRS/internal/models"
@@ -37,6 +39,7 @@ type CreateRequestInput struct {
 	Overview    string             `json:"overview"`
 	PosterPath  string             `json:"poster_path"`
 	Notes       string             `json:"notes"`
+	GenreIDs    []int              `json:"genre_ids"`
 }
 
 // UpdateRequestInput represents the request update payload
@@ -65,45 +68,166 @@ type RequestResponse struct {
 	UpdatedAt   string               `json:"updated_at"`
 }
 
-// GetRequests returns all requests with optional filtering
+// requestSortFields maps the accepted sort query values to columns
+var requestSortFields = map[string]string{
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+	"title":      "title",
+	"year":       "year",
+}
+
+const (
+	defaultRequestLimit = 50
+	maxRequestLimit     = 100
+)
+
+// applyRequestFilters applies the list filters shared by the request endpoints.
+// Non-admin users are always scoped to their own requests.
+func applyRequestFilters(query *gorm.DB, c *gin.Context) (*gorm.DB, error) {
+	userID, _ := c.Get("userID")
+	isAdmin, _ := c.Get("isAdmin")
+
+	// Filter by status if provided
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	// Filter by media type if provided
+	if mediaType := c.Query("media_type"); mediaType != "" {
+		if mediaType != string(models.MediaTypeMovie) && mediaType != string(models.MediaTypeTV) {
+			return nil, errors.New("Invalid media_type, must be 'movie' or 'tv'")
+		}
+		query = query.Where("media_type = ?", mediaType)
+	}
+
+	// Filter by user_id if provided (admin only)
+	if userIDParam := c.Query("user_id"); userIDParam != "" && isAdmin.(bool) {
+		if uid, err := strconv.Atoi(userIDParam); err == nil {
+			query = query.Where("user_id = ?", uid)
+		}
+	} else if !isAdmin.(bool) {
+		// Non-admin users can only see their own requests
+		query = query.Where("user_id = ?", userID)
+	}
+
+	// Free-text title search (case-insensitive on both Postgres and SQLite)
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		query = query.Where("LOWER(title) LIKE ?", "%"+strings.ToLower(q)+"%")
+	}
+
+	// Filter by creation date range
+	if from := c.Query("from"); from != "" {
+		fromTime, err := parseDateParam(from)
+		if err != nil {
+			return nil, errors.New("Invalid from date, use YYYY-MM-DD or RFC3339")
+		}
+		query = query.Where("created_at >= ?", fromTime)
+	}
+	if to := c.Query("to"); to != "" {
+		toTime, err := parseDateParam(to)
+		if err != nil {
+			return nil, errors.New("Invalid to date, use YYYY-MM-DD or RFC3339")
+		}
+		// A bare date includes the whole day
+		if len(to) == len("2006-01-02") {
+			toTime = toTime.AddDate(0, 0, 1)
+		}
+		query = query.Where("created_at < ?", toTime)
+	}
+
+	return query, nil
+}
+
+// parseDateParam parses a query parameter as either a date or an RFC3339 timestamp
+func parseDateParam(value string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// GetRequests returns requests with optional filtering, sorting and pagination
 // @Summary Get all requests
-// @Description Get all media requests with optional status filtering
+// @Description Get media requests with optional filtering, sorting and pagination.
+// @Description Results are only paginated when limit or offset is given.
 // @Tags requests
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param status query string false "Filter by status (pending, approved, completed, rejected)"
 // @Param user_id query int false "Filter by user ID (admin only)"
+// @Param media_type query string false "Filter by media type (movie, tv)"
+// @Param q query string false "Search request titles"
+// @Param from query string false "Only requests created on or after this date (YYYY-MM-DD or RFC3339)"
+// @Param to query string false "Only requests created on or before this date (YYYY-MM-DD or RFC3339)"
+// @Param sort query string false "Sort field (created_at, updated_at, title, year)"
+// @Param order query string false "Sort order (asc, desc)"
+// @Param limit query int false "Maximum number of requests to return (max 100)"
+// @Param offset query int false "Number of requests to skip"
 // @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /requests [get]
 func (h *requestHandler) GetRequests(c *gin.Context) {
-	// Get user info from context
-	userID, _ := c.Get("userID")
-	isAdmin, _ := c.Get("isAdmin")
-
 	// Build query
-	query := h.db.Preload("User")
+	query, err := applyRequestFilters(h.db.Model(&models.Request{}), c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
 
-	// Filter by status if provided
-	if status := c.Query("status"); status != "" {
-		query = query.Where("status = ?", status)
+	// Resolve sort order
+	sortField := c.DefaultQuery("sort", "created_at")
+	column, ok := requestSortFields[sortField]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid sort field, must be one of created_at, updated_at, title, year",
+		})
+		return
+	}
+	order := strings.ToLower(c.DefaultQuery("order", "desc"))
+	if order != "asc" && order != "desc" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid order, must be 'asc' or 'desc'",
+		})
+		return
 	}
 
-	// Filter by user_id if provided (admin only)
-	if userIDParam := c.Query("user_id"); userIDParam != "" && isAdmin.(bool) {
-		if uid, err := strconv.Atoi(userIDParam); err == nil {
-			query = query.Where("user_id = ?", uid)
+	// Count all matching requests before paginating
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to count requests",
+		})
+		return
+	}
+
+	// Pagination is opt-in so existing clients keep receiving the full list
+	limit, offset := 0, 0
+	paginated := c.Query("limit") != "" || c.Query("offset") != ""
+	if paginated {
+		limit = defaultRequestLimit
+		if l, err := strconv.Atoi(c.Query("limit")); err == nil && l > 0 {
+			limit = l
 		}
-	} else if !isAdmin.(bool) {
-		// Non-admin users can only see their own requests
-		query = query.Where("user_id = ?", userID)
+		if limit > maxRequestLimit {
+			limit = maxRequestLimit
+		}
+		if o, err := strconv.Atoi(c.Query("offset")); err == nil && o > 0 {
+			offset = o
+		}
+		query = query.Limit(limit).Offset(offset)
 	}
 
-	// Get requests
+	// Get requests, using id as a tie-breaker so pages are stable
 	var requests []models.Request
-	if err := query.Order("created_at DESC").Find(&requests).Error; err != nil {
+	if err := query.Preload("User").
+		Order(column + " " + order).
+		Order("id " + order).
+		Find(&requests).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to fetch requests",
 		})
@@ -122,7 +246,7 @@ func (h *requestHandler) GetRequests(c *gin.Context) {
 			}
 
 			// Check if media exists in Plex
-			inPlex, err := h.plexService.CheckIfExists(
+			inPlex, err := h.plexService.CheckIfExistsContext(c.Request.Context(), 
 				requests[i].Title,
 				requests[i].Year,
 				string(requests[i].MediaType),
@@ -173,13 +297,21 @@ func (h *requestHandler) GetRequests(c *gin.Context) {
 	// Convert to response format
 	responses := make([]RequestResponse, len(requests))
 	for i, req := range requests {
-		responses[i] = h.toRequestResponse(req)
+		responses[i] = toRequestResponse(req)
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	response := gin.H{
 		"requests": responses,
 		"count":    len(responses),
-	})
+		"total":    total,
+	}
+	if paginated {
+		response["limit"] = limit
+		response["offset"] = offset
+		response["has_more"] = int64(offset+len(responses)) < total
+	}
+
+	c.JSON(http.StatusOK, response)
 }
 
 // CreateRequest creates a new media request
@@ -208,27 +340,31 @@ func (h *requestHandler) CreateRequest(c *gin.Context) {
 		return
 	}
 
-	// Check if request already exists for this user
-	var existingRequest models.Request
-	err := h.db.Where("user_id = ? AND title = ? AND media_type = ?",
-		userID, input.Title, input.MediaType).First(&existingRequest).Error
-
-	if err == nil {
+	// Titles the user already requested aren't created again
+	created, err := services.CreateRequests(h.db, auditFor(c, h.auditService), userID.(uint), isAdmin.(bool), []services.NewRequest{input.newRequest()})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create request",
+		})
+		return
+	}
+	if len(created) == 0 {
 		c.JSON(http.StatusConflict, gin.H{
 			"error": "You already have a request for this media",
 		})
 		return
 	}
+	request := created[0]
 
-	// Auto-approve requests created by admins
-	initialStatus := models.StatusPending
-	if isAdmin.(bool) {
-		initialStatus = models.StatusApproved
-	}
+	// Load user for response
+	h.db.Preload("User").First(&request, request.ID)
+
+	c.JSON(http.StatusCreated, toRequestResponse(request))
+}
 
-	// Create new request
-	request := models.Request{
-		UserID:     userID.(uint),
+// newRequest converts the input for services.CreateRequests
+func (input CreateRequestInput) newRequest() services.NewRequest {
+	return services.NewRequest{
 		Title:      input.Title,
 		Year:       input.Year,
 		MediaType:  input.MediaType,
@@ -237,34 +373,31 @@ func (h *requestHandler) CreateRequest(c *gin.Context) {
 		Overview:   input.Overview,
 		PosterPath: input.PosterPath,
 		Notes:      input.Notes,
-		Status:     initialStatus,
-	}
-
-	if err := h.db.Create(&request).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to create request",
-		})
-		return
+		GenreIDs:   input.GenreIDs,
 	}
+}
 
-	// Log audit entries
-	if h.auditService != nil {
-		if err := h.auditService.LogRequestCreated(request.ID, request.UserID); err != nil {
-			log.Printf("Failed to log audit entry for request creation (ID: %d): %v", request.ID, err)
+// createRequestsForResults creates a request for each movie or TV result with
+// services.CreateRequests, skipping titles the user already requested
+func createRequestsForResults(db *gorm.DB, auditService *services.AuditService, userID uint, isAdmin bool, results []services.TMDBResult, notes string) ([]models.Request, error) {
+	inputs := make([]services.NewRequest, len(results))
+	for i, result := range results {
+		inputs[i] = services.NewRequest{
+			Title:      result.Title,
+			Year:       result.Year(),
+			MediaType:  models.MediaType(result.MediaType),
+			TMDBId:     result.ID,
+			Overview:   result.Overview,
+			PosterPath: result.PosterPath,
+			Notes:      notes,
+			GenreIDs:   result.GenreIDs,
 		}
-
-		// If auto-approved, also log the approval action
-		if isAdmin.(bool) {
-			if err := h.auditService.LogRequestStatusChange(request.ID, &request.UserID, models.StatusPending, models.StatusApproved); err != nil {
-				log.Printf("Failed to log audit entry for auto-approval (ID: %d): %v", request.ID, err)
-			}
+		if result.MediaType == "tv" {
+			inputs[i].Title = result.Name
 		}
 	}
 
-	// Load user for response
-	h.db.Preload("User").First(&request, request.ID)
-
-	c.JSON(http.StatusCreated, h.toRequestResponse(request))
+	return services.CreateRequests(db, auditService, userID, isAdmin, inputs)
 }
 
 // UpdateRequest updates a media request
@@ -327,31 +460,26 @@ func (h *requestHandler) UpdateRequest(c *gin.Context) {
 		return
 	}
 
-	// Track old values for audit logging
-	oldStatus := request.Status
-
-	// Apply updates based on permissions
+	// Apply updates based on permissions, tracking old values for audit logging
 	updates := make(map[string]interface{})
-	statusChanged := false
-	notesChanged := false
-	adminNotesChanged := false
+	diff := services.NewAuditDiff()
 
 	if isAdmin.(bool) {
 		// Admins can update everything
-		if input.Status != "" && input.Status != oldStatus {
+		if input.Status != "" && input.Status != request.Status {
 			updates["status"] = input.Status
-			statusChanged = true
+			diff.Add("status", request.Status, input.Status)
 		}
 		if input.AdminNotes != "" {
 			updates["admin_notes"] = input.AdminNotes
-			adminNotesChanged = true
+			diff.Add("admin_notes", request.AdminNotes, input.AdminNotes)
 		}
 	}
 
 	// Users can update their own notes
 	if request.UserID == userID.(uint) && input.Notes != "" {
 		updates["notes"] = input.Notes
-		notesChanged = true
+		diff.Add("notes", request.Notes, input.Notes)
 	}
 
 	if len(updates) == 0 {
@@ -369,36 +497,18 @@ func (h *requestHandler) UpdateRequest(c *gin.Context) {
 		return
 	}
 
-	// Log audit entries
-	if h.auditService != nil {
-		var auditUserID *uint
-		if isAdmin.(bool) {
-			uid := userID.(uint)
-			auditUserID = &uid
-		}
-
-		if statusChanged {
-			if err := h.auditService.LogRequestStatusChange(request.ID, auditUserID, oldStatus, input.Status); err != nil {
-				log.Printf("Failed to log status change audit for request %d: %v", request.ID, err)
-			}
-		}
-		if adminNotesChanged {
-			if err := h.auditService.LogRequestNotesUpdate(request.ID, auditUserID, "Admin notes"); err != nil {
-				log.Printf("Failed to log admin notes update audit for request %d: %v", request.ID, err)
-			}
-		}
-		if notesChanged {
-			uid := userID.(uint)
-			if err := h.auditService.LogRequestNotesUpdate(request.ID, &uid, "User notes"); err != nil {
-				log.Printf("Failed to log user notes update audit for request %d: %v", request.ID, err)
-			}
+	// Log audit entry
+	if audit := auditFor(c, h.auditService); audit != nil {
+		uid := userID.(uint)
+		if err := audit.LogRequestUpdate(request.ID, &uid, diff); err != nil {
+			log.Printf("Failed to log update audit for request %d: %v", request.ID, err)
 		}
 	}
 
 	// Load user for response
 	h.db.Preload("User").First(&request, request.ID)
 
-	c.JSON(http.StatusOK, h.toRequestResponse(request))
+	c.JSON(http.StatusOK, toRequestResponse(request))
 }
 
 // DeleteRequest deletes a media request
@@ -453,8 +563,8 @@ func (h *requestHandler) DeleteRequest(c *gin.Context) {
 	}
 
 	// Log audit entry before deleting
-	if h.auditService != nil {
-		if err := h.auditService.LogRequestDeleted(request.ID, userID.(uint), request.Title); err != nil {
+	if audit := auditFor(c, h.auditService); audit != nil {
+		if err := audit.LogRequestDeleted(request.ID, userID.(uint), request.Title); err != nil {
 			log.Printf("Failed to log audit entry for request deletion (ID: %d): %v", request.ID, err)
 		}
 	}
@@ -505,9 +615,9 @@ func (h *requestHandler) GetRequestAuditLogs(c *gin.Context) {
 		return
 	}
 
-	// Check if request exists
+	// Check if request exists, deleted requests keep their history
 	var request models.Request
-	if err := h.db.First(&request, requestID).Error; err != nil {
+	if err := h.db.Unscoped().First(&request, requestID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{
 				"error": "Request not found",
@@ -538,42 +648,9 @@ func (h *requestHandler) GetRequestAuditLogs(c *gin.Context) {
 	}
 
 	// Convert to response format
-	type AuditLogResponse struct {
-		ID        uint        `json:"id"`
-		RequestID uint        `json:"request_id"`
-		User      interface{} `json:"user"`
-		Action    string      `json:"action"`
-		OldValue  string      `json:"old_value,omitempty"`
-		NewValue  string      `json:"new_value,omitempty"`
-		Notes     string      `json:"notes"`
-		CreatedAt string      `json:"created_at"`
-	}
-
 	responses := make([]AuditLogResponse, len(logs))
 	for i, log := range logs {
-		var user interface{}
-		if log.User != nil {
-			user = map[string]interface{}{
-				"id":       log.User.ID,
-				"username": log.User.Username,
-			}
-		} else {
-			user = map[string]interface{}{
-				"id":       nil,
-				"username": "System",
-			}
-		}
-
-		responses[i] = AuditLogResponse{
-			ID:        log.ID,
-			RequestID: log.RequestID,
-			User:      user,
-			Action:    string(log.Action),
-			OldValue:  log.OldValue,
-			NewValue:  log.NewValue,
-			Notes:     log.Notes,
-			CreatedAt: log.CreatedAt.Format("2006-01-02T15:04:05Z"),
-		}
+		responses[i] = toAuditLogResponse(log)
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -643,7 +720,7 @@ func (h *requestHandler) GetRequestStats(c *gin.Context) {
 }
 
 // Helper function to convert model to response
-func (h *requestHandler) toRequestResponse(req models.Request) RequestResponse {
+func toRequestResponse(req models.Request) RequestResponse {
 	resp := RequestResponse{
 		ID:         req.ID,
 		UserID:     req.UserID,
@@ -7,12 +7,25 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jacob-fain/MRS/internal/models"
+	"github.com/jacob-fain/MRS/internal/services"
 	"github.com/jacob-fain/MRS/internal/testutil"
 )
 
+// newTestRouter returns a router whose requests run as the given user, the way
+// the auth middleware sets them up
+func newTestRouter(userID uint, isAdmin bool) *gin.Engine {
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", userID)
+		c.Set("isAdmin", isAdmin)
+	})
+	return router
+}
+
 func TestRequestHandler_GetRequests(t *testing.T) {
 	// Setup
 	gin.SetMode(gin.TestMode)
@@ -112,6 +125,123 @@ func TestRequestHandler_GetRequests(t *testing.T) {
 	}
 }
 
+func TestRequestHandler_GetRequests_PaginationAndSorting(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	db := testutil.SetupTestDB(t)
+	handler := NewRequestHandler(db, nil, nil)
+
+	admin := testutil.CreateTestUser(t, db, "admin@example.com", "admin", "pass", true)
+	user := testutil.CreateTestUser(t, db, "user@example.com", "user", "pass", false)
+
+	titles := []string{"Alien", "Blade Runner", "Casablanca", "Dune", "Everest"}
+	for i, title := range titles {
+		req := testutil.CreateTestRequest(t, db, user.ID, title, models.MediaTypeMovie)
+		req.Year = 2000 + i
+		db.Save(req)
+	}
+	tvRequest := testutil.CreateTestRequest(t, db, user.ID, "Dark", models.MediaTypeTV)
+	oldRequest := testutil.CreateTestRequest(t, db, user.ID, "Old Request", models.MediaTypeMovie)
+	db.Model(oldRequest).UpdateColumn("created_at", time.Date(2020, 1, 15, 12, 0, 0, 0, time.UTC))
+
+	tests := []struct {
+		name           string
+		queryParams    string
+		expectedStatus int
+		checkResponse  func(t *testing.T, response map[string]interface{})
+	}{
+		{
+			name:           "limit and offset paginate with real total",
+			queryParams:    "?sort=title&order=asc&limit=2&offset=2",
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, response map[string]interface{}) {
+				requests := response["requests"].([]interface{})
+				testutil.AssertEqual(t, 2, len(requests))
+				testutil.AssertEqual(t, float64(7), response["total"])
+				testutil.AssertEqual(t, float64(2), response["limit"])
+				testutil.AssertEqual(t, float64(2), response["offset"])
+				testutil.AssertEqual(t, true, response["has_more"])
+				testutil.AssertEqual(t, "Casablanca", requests[0].(map[string]interface{})["title"])
+				testutil.AssertEqual(t, "Dark", requests[1].(map[string]interface{})["title"])
+			},
+		},
+		{
+			name:           "sort by year ascending",
+			queryParams:    "?media_type=movie&sort=year&order=asc&limit=1",
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, response map[string]interface{}) {
+				requests := response["requests"].([]interface{})
+				testutil.AssertEqual(t, "Alien", requests[0].(map[string]interface{})["title"])
+			},
+		},
+		{
+			name:           "filter by media type",
+			queryParams:    "?media_type=tv",
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, response map[string]interface{}) {
+				requests := response["requests"].([]interface{})
+				testutil.AssertEqual(t, 1, len(requests))
+				testutil.AssertEqual(t, float64(tvRequest.ID), requests[0].(map[string]interface{})["id"])
+			},
+		},
+		{
+			name:           "title search is case-insensitive",
+			queryParams:    "?q=RUNNER",
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, response map[string]interface{}) {
+				testutil.AssertEqual(t, float64(1), response["total"])
+			},
+		},
+		{
+			name:           "date range filter",
+			queryParams:    "?from=2020-01-01&to=2020-01-15",
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, response map[string]interface{}) {
+				requests := response["requests"].([]interface{})
+				testutil.AssertEqual(t, 1, len(requests))
+				testutil.AssertEqual(t, "Old Request", requests[0].(map[string]interface{})["title"])
+			},
+		},
+		{
+			name:           "invalid sort field",
+			queryParams:    "?sort=password",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid date",
+			queryParams:    "?from=yesterday",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/requests", func(c *gin.Context) {
+				c.Set("userID", admin.ID)
+				c.Set("isAdmin", true)
+				handler.GetRequests(c)
+			})
+
+			req, err := http.NewRequest("GET", "/requests"+tt.queryParams, nil)
+			testutil.AssertNoError(t, err)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			testutil.AssertEqual(t, tt.expectedStatus, w.Code)
+
+			var response map[string]interface{}
+			err = json.Unmarshal(w.Body.Bytes(), &response)
+			testutil.AssertNoError(t, err)
+
+			if tt.checkResponse != nil {
+				tt.checkResponse(t, response)
+			}
+		})
+	}
+}
+
 func TestRequestHandler_CreateRequest(t *testing.T) {
 	// Setup
 	gin.SetMode(gin.TestMode)
@@ -573,6 +703,8 @@ func TestRequestHandler_GetRequestAuditLogs(t *testing.T) {
 
 	// Create test request
 	req := testutil.CreateTestRequest(t, db, user.ID, "The Matrix", models.MediaTypeMovie)
+	deleted := testutil.CreateTestRequest(t, db, user.ID, "Dune", models.MediaTypeMovie)
+	db.Delete(deleted)
 
 	tests := []struct {
 		name           string
@@ -608,6 +740,13 @@ func TestRequestHandler_GetRequestAuditLogs(t *testing.T) {
 			isAdmin:        true,
 			expectedStatus: http.StatusBadRequest,
 		},
+		{
+			name:           "deleted request keeps its history",
+			requestID:      fmt.Sprintf("%d", deleted.ID),
+			userID:         admin.ID,
+			isAdmin:        true,
+			expectedStatus: http.StatusOK,
+		},
 		{
 			name:           "non-existent request",
 			requestID:      "9999",
@@ -643,3 +782,45 @@ func TestRequestHandler_GetRequestAuditLogs(t *testing.T) {
 		})
 	}
 }
+
+func TestRequestHandler_UpdateRequest_AuditDiff(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := testutil.SetupTestDB(t)
+	handler := NewRequestHandler(db, nil, services.NewAuditService(db))
+
+	admin := testutil.CreateTestUser(t, db, "admin@example.com", "admin", "pass", true)
+	user := testutil.CreateTestUser(t, db, "user@example.com", "user", "pass", false)
+	request := testutil.CreateTestRequest(t, db, user.ID, "The Matrix", models.MediaTypeMovie)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", admin.ID)
+		c.Set("isAdmin", true)
+	})
+	router.PUT("/requests/:id", handler.UpdateRequest)
+	router.GET("/requests/:id/audit-logs", handler.GetRequestAuditLogs)
+
+	body := `{"status": "rejected", "admin_notes": "Not on any service"}`
+	req, _ := http.NewRequest("PUT", fmt.Sprintf("/requests/%d", request.ID), bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	testutil.AssertEqual(t, http.StatusOK, w.Code)
+
+	req, _ = http.NewRequest("GET", fmt.Sprintf("/requests/%d/audit-logs", request.ID), nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	testutil.AssertEqual(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Logs []AuditLogResponse `json:"logs"`
+	}
+	testutil.AssertNoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+	// One entry for the whole update, with every changed field
+	testutil.AssertEqual(t, 1, len(response.Logs))
+	testutil.AssertEqual(t, string(models.ActionRejected), response.Logs[0].Action)
+	testutil.AssertEqual(t, 2, len(response.Logs[0].Changes))
+	testutil.AssertEqual(t, services.FieldChange{Field: "admin_notes", Old: "", New: "Not on any service"}, response.Logs[0].Changes[0])
+	testutil.AssertEqual(t, services.FieldChange{Field: "status", Old: "pending", New: "rejected"}, response.Logs[0].Changes[1])
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -40,6 +41,7 @@ func NewSearchHandler(tmdbService services.TMDBServiceInterface, plexService ser
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
+// @Failure 503 {object} map[string]string
 // @Router /search [get]
 func (h *searchHandler) SearchMedia(c *gin.Context) {
 	query := c.Query("q")
@@ -59,11 +61,11 @@ func (h *searchHandler) SearchMedia(c *gin.Context) {
 	}
 
 	// Search TMDB
-	results, err := h.tmdbService.SearchMulti(query, page)
+	results, err := h.tmdbService.SearchMultiContext(c.Request.Context(), query, page)
 	if err != nil {
 		// Log the actual error for debugging
 		gin.DefaultErrorWriter.Write([]byte(fmt.Sprintf("TMDB search error: %v\n", err)))
-		c.JSON(http.StatusInternalServerError, gin.H{
+		c.JSON(upstreamErrorStatus(err), gin.H{
 			"error": "failed to search TMDB",
 			"details": err.Error(),
 		})
@@ -72,31 +74,8 @@ func (h *searchHandler) SearchMedia(c *gin.Context) {
 
 	// Check Plex availability if requested and service is available
 	checkPlex := c.Query("check_plex") == "true"
-	if checkPlex && h.plexService != nil {
-		for i := range results.Results {
-			result := &results.Results[i]
-			
-			// Get title and year based on media type
-			var title string
-			var year int
-			
-			if result.MediaType == "movie" {
-				title = result.Title
-				if result.ReleaseDate != "" && len(result.ReleaseDate) >= 4 {
-					year, _ = strconv.Atoi(result.ReleaseDate[:4])
-				}
-			} else if result.MediaType == "tv" {
-				title = result.Name
-				if result.FirstAirDate != "" && len(result.FirstAirDate) >= 4 {
-					year, _ = strconv.Atoi(result.FirstAirDate[:4])
-				}
-			}
-			
-			if title != "" && h.plexService != nil {
-				exists, _ := h.plexService.CheckIfExists(title, year, result.MediaType)
-				result.InPlex = exists
-			}
-		}
+	if checkPlex {
+		markInPlex(c.Request.Context(), h.plexService, results.Results)
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -118,6 +97,7 @@ func (h *searchHandler) SearchMedia(c *gin.Context) {
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
+// @Failure 503 {object} map[string]string
 // @Router /search/{type}/{id} [get]
 func (h *searchHandler) GetMediaDetails(c *gin.Context) {
 	mediaType := c.Param("type")
@@ -141,9 +121,9 @@ func (h *searchHandler) GetMediaDetails(c *gin.Context) {
 	var details interface{}
 	
 	if mediaType == "movie" {
-		movieDetails, err := h.tmdbService.GetMovieDetails(id)
+		movieDetails, err := h.tmdbService.GetMovieDetailsContext(c.Request.Context(), id)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
+			c.JSON(upstreamErrorStatus(err), gin.H{
 				"error": "failed to get movie details",
 			})
 			return
@@ -155,36 +135,40 @@ func (h *searchHandler) GetMediaDetails(c *gin.Context) {
 			if movieDetails.ReleaseDate != "" && len(movieDetails.ReleaseDate) >= 4 {
 				year, _ = strconv.Atoi(movieDetails.ReleaseDate[:4])
 			}
-			movieDetails.InPlex, _ = h.plexService.CheckIfExists(movieDetails.Title, year, "movie")
+			movieDetails.InPlex, _ = h.plexService.CheckIfExistsContext(c.Request.Context(), movieDetails.Title, year, "movie")
 		}
 
 		// Fetch OMDB ratings if IMDB ID is available
 		if movieDetails.ExternalIDs.IMDBID != "" {
-			ratings := h.fetchOrCacheRatings(movieDetails.ExternalIDs.IMDBID)
+			ratings := h.fetchOrCacheRatings(c.Request.Context(), movieDetails.ExternalIDs.IMDBID)
 			if ratings != nil {
 				// Add ratings to response
 				response := gin.H{
-					"id":                   movieDetails.ID,
-					"title":                movieDetails.Title,
-					"overview":             movieDetails.Overview,
-					"release_date":         movieDetails.ReleaseDate,
-					"runtime":              movieDetails.Runtime,
-					"vote_average":         movieDetails.VoteAverage,
-					"vote_count":           movieDetails.VoteCount,
-					"popularity":           movieDetails.Popularity,
-					"poster_path":          movieDetails.PosterPath,
-					"backdrop_path":        movieDetails.BackdropPath,
-					"genres":               movieDetails.Genres,
-					"production_companies": movieDetails.ProductionCompanies,
-					"status":               movieDetails.Status,
-					"tagline":              movieDetails.Tagline,
-					"external_ids":         movieDetails.ExternalIDs,
-					"credits":              movieDetails.Credits,
-					"videos":               movieDetails.Videos,
-					"poster_url":           movieDetails.PosterURL,
-					"backdrop_url":         movieDetails.BackdropURL,
-					"in_plex":              movieDetails.InPlex,
-					"ratings":              ratings,
+					"id":                    movieDetails.ID,
+					"title":                 movieDetails.Title,
+					"overview":              movieDetails.Overview,
+					"release_date":          movieDetails.ReleaseDate,
+					"runtime":               movieDetails.Runtime,
+					"vote_average":          movieDetails.VoteAverage,
+					"vote_count":            movieDetails.VoteCount,
+					"popularity":            movieDetails.Popularity,
+					"poster_path":           movieDetails.PosterPath,
+					"backdrop_path":         movieDetails.BackdropPath,
+					"genres":                movieDetails.Genres,
+					"production_companies":  movieDetails.ProductionCompanies,
+					"status":                movieDetails.Status,
+					"tagline":               movieDetails.Tagline,
+					"external_ids":          movieDetails.ExternalIDs,
+					"credits":               movieDetails.Credits,
+					"videos":                movieDetails.Videos,
+					"release_dates":         movieDetails.ReleaseDates,
+					"certification":         movieDetails.Certification,
+					"local_release_date":    movieDetails.LocalReleaseDate,
+					"belongs_to_collection": movieDetails.BelongsToCollection,
+					"poster_url":            movieDetails.PosterURL,
+					"backdrop_url":          movieDetails.BackdropURL,
+					"in_plex":               movieDetails.InPlex,
+					"ratings":               ratings,
 				}
 				c.JSON(http.StatusOK, response)
 				return
@@ -193,9 +177,9 @@ func (h *searchHandler) GetMediaDetails(c *gin.Context) {
 
 		details = movieDetails
 	} else {
-		tvDetails, err := h.tmdbService.GetTVDetails(id)
+		tvDetails, err := h.tmdbService.GetTVDetailsContext(c.Request.Context(), id)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
+			c.JSON(upstreamErrorStatus(err), gin.H{
 				"error": "failed to get TV show details",
 			})
 			return
@@ -207,12 +191,19 @@ func (h *searchHandler) GetMediaDetails(c *gin.Context) {
 			if tvDetails.FirstAirDate != "" && len(tvDetails.FirstAirDate) >= 4 {
 				year, _ = strconv.Atoi(tvDetails.FirstAirDate[:4])
 			}
-			tvDetails.InPlex, _ = h.plexService.CheckIfExists(tvDetails.Name, year, "tv")
+			tvDetails.InPlex, _ = h.plexService.CheckIfExistsContext(c.Request.Context(), tvDetails.Name, year, "tv")
+
+			// Count each season's episodes in Plex, and mark the complete ones
+			if tvDetails.InPlex {
+				if seasons, err := h.plexService.GetShowSeasonsContext(c.Request.Context(), tvDetails.Name, year); err == nil {
+					markSeasonsInPlex(tvDetails.Seasons, seasons)
+				}
+			}
 		}
 
 		// Fetch OMDB ratings if IMDB ID is available
 		if tvDetails.ExternalIDs.IMDBID != "" {
-			ratings := h.fetchOrCacheRatings(tvDetails.ExternalIDs.IMDBID)
+			ratings := h.fetchOrCacheRatings(c.Request.Context(), tvDetails.ExternalIDs.IMDBID)
 			if ratings != nil {
 				// Add ratings to response
 				response := gin.H{
@@ -235,6 +226,9 @@ func (h *searchHandler) GetMediaDetails(c *gin.Context) {
 					"external_ids":        tvDetails.ExternalIDs,
 					"credits":             tvDetails.Credits,
 					"videos":              tvDetails.Videos,
+					"content_ratings":     tvDetails.ContentRatings,
+					"seasons":             tvDetails.Seasons,
+					"certification":       tvDetails.Certification,
 					"poster_url":          tvDetails.PosterURL,
 					"backdrop_url":        tvDetails.BackdropURL,
 					"in_plex":             tvDetails.InPlex,
@@ -251,8 +245,187 @@ func (h *searchHandler) GetMediaDetails(c *gin.Context) {
 	c.JSON(http.StatusOK, details)
 }
 
+// GetRecommendations returns titles to suggest alongside a movie or TV show
+// @Summary Get recommendations
+// @Description Get TMDB recommendations (or similar titles) for a movie or TV show, with Plex availability. Titles the current user already requested are left out.
+// @Tags search
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param type path string true "Media type (movie or tv)"
+// @Param id path int true "TMDB ID"
+// @Param source query string false "recommendations (default) or similar"
+// @Param page query int false "Page number (default: 1)"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /search/{type}/{id}/recommendations [get]
+func (h *searchHandler) GetRecommendations(c *gin.Context) {
+	mediaType := c.Param("type")
+	if mediaType != "movie" && mediaType != "tv" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid media type, must be 'movie' or 'tv'",
+		})
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid ID",
+		})
+		return
+	}
+
+	source := c.DefaultQuery("source", "recommendations")
+	if source != "recommendations" && source != "similar" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid source, must be 'recommendations' or 'similar'",
+		})
+		return
+	}
+
+	page := 1
+	if pageStr := c.Query("page"); pageStr != "" {
+		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
+			page = p
+		}
+	}
+
+	var results *services.TMDBSearchResult
+	if source == "recommendations" {
+		results, err = h.tmdbService.GetRecommendationsContext(c.Request.Context(), mediaType, id, page)
+		// Less popular titles often have no recommendations, fall back to similar titles
+		if err == nil && page == 1 && len(results.Results) == 0 {
+			source = "similar"
+		}
+	}
+	if source == "similar" {
+		results, err = h.tmdbService.GetSimilarContext(c.Request.Context(), mediaType, id, page)
+	}
+	if err != nil {
+		c.JSON(upstreamErrorStatus(err), gin.H{
+			"error":   "failed to get recommendations",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// Leave out titles the user already asked for
+	if h.db != nil {
+		userID, _ := c.Get("userID")
+		filtered, err := filterRequested(h.db, userID, results.Results)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "failed to check existing requests",
+			})
+			return
+		}
+		results.Results = filtered
+	}
+
+	markInPlex(c.Request.Context(), h.plexService, results.Results)
+
+	c.JSON(http.StatusOK, gin.H{
+		"source":        source,
+		"page":          results.Page,
+		"total_pages":   results.TotalPages,
+		"total_results": results.TotalResults,
+		"results":       results.Results,
+	})
+}
+
+// GetSeasonDetails fetches the episodes of a TV season
+// @Summary Get season details
+// @Description Get a TV season with its episodes, air dates and stills, with Plex availability per episode
+// @Tags search
+// @Accept json
+// @Produce json
+// @Param id path int true "TMDB TV show ID"
+// @Param n path int true "Season number"
+// @Success 200 {object} services.TMDBSeasonDetails
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /search/tv/{id}/season/{n} [get]
+func (h *searchHandler) GetSeasonDetails(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid ID",
+		})
+		return
+	}
+
+	seasonNumber, err := strconv.Atoi(c.Param("n"))
+	if err != nil || seasonNumber < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid season number",
+		})
+		return
+	}
+
+	season, err := h.tmdbService.GetSeasonDetailsContext(c.Request.Context(), id, seasonNumber)
+	if err != nil {
+		c.JSON(upstreamErrorStatus(err), gin.H{
+			"error": "failed to get season details",
+		})
+		return
+	}
+
+	// Check Plex availability per episode, Plex matches shows by name and year
+	if h.plexService != nil {
+		tvDetails, err := h.tmdbService.GetTVDetailsContext(c.Request.Context(), id)
+		if err == nil {
+			episodes, err := h.plexService.GetSeasonEpisodesContext(c.Request.Context(), tvDetails.Name, yearFromDate(tvDetails.FirstAirDate), seasonNumber)
+			if err == nil {
+				markEpisodesInPlex(season, episodes)
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, season)
+}
+
+// markSeasonsInPlex counts each season's episodes that are in Plex, and sets
+// InPlex when every episode is there, the same rule markEpisodesInPlex uses.
+// plexSeasons maps season numbers to the episode numbers Plex has.
+func markSeasonsInPlex(seasons []services.TMDBSeason, plexSeasons map[int][]int) {
+	for i := range seasons {
+		season := &seasons[i]
+		// TMDB numbers a season's episodes from 1 to its episode count
+		inPlex := make(map[int]bool)
+		for _, number := range plexSeasons[season.SeasonNumber] {
+			if number >= 1 && number <= season.EpisodeCount {
+				inPlex[number] = true
+			}
+		}
+		season.EpisodesInPlex = len(inPlex)
+		season.InPlex = season.EpisodeCount > 0 && season.EpisodesInPlex == season.EpisodeCount
+	}
+}
+
+// markEpisodesInPlex sets InPlex on the episodes whose numbers are in
+// plexEpisodes, and on the season when every episode is there
+func markEpisodesInPlex(season *services.TMDBSeasonDetails, plexEpisodes []int) {
+	inPlex := make(map[int]bool, len(plexEpisodes))
+	for _, number := range plexEpisodes {
+		inPlex[number] = true
+	}
+
+	season.EpisodesInPlex = 0
+	for i := range season.Episodes {
+		season.Episodes[i].InPlex = inPlex[season.Episodes[i].EpisodeNumber]
+		if season.Episodes[i].InPlex {
+			season.EpisodesInPlex++
+		}
+	}
+	season.InPlex = len(season.Episodes) > 0 && season.EpisodesInPlex == len(season.Episodes)
+}
+
 // fetchOrCacheRatings fetches ratings from cache or OMDB API
-func (h *searchHandler) fetchOrCacheRatings(imdbID string) *services.OMDBRatings {
+func (h *searchHandler) fetchOrCacheRatings(ctx context.Context, imdbID string) *services.OMDBRatings {
 	// Check if OMDB service is available
 	if h.omdbService == nil || h.db == nil {
 		return nil
@@ -273,7 +446,7 @@ func (h *searchHandler) fetchOrCacheRatings(imdbID string) *services.OMDBRatings
 	}
 
 	// Not in cache, fetch from OMDB
-	ratings, err := h.omdbService.GetRatingsByIMDB(imdbID)
+	ratings, err := h.omdbService.GetRatingsByIMDBContext(ctx, imdbID)
 	if err != nil {
 		// Failed to fetch, return nil
 		return nil
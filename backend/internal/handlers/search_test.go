@@ -1,13 +1,16 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jacob-fain/MRS/internal/models"
 	"github.com/jacob-fain/MRS/internal/services"
 	"github.com/jacob-fain/MRS/internal/testutil"
 )
@@ -17,33 +20,137 @@ type mockTMDBService struct {
 	searchMultiFunc     func(query string, page int) (*services.TMDBSearchResult, error)
 	getMovieDetailsFunc func(movieID int) (*services.TMDBMovieDetails, error)
 	getTVDetailsFunc    func(tvID int) (*services.TMDBTVDetails, error)
+	getSeasonFunc       func(tvID, seasonNumber int) (*services.TMDBSeasonDetails, error)
+	getCollectionFunc   func(collectionID int) (*services.TMDBCollection, error)
+	findByIMDbIDFunc    func(imdbID string) (*services.TMDBFindResult, error)
+	personDetailsFunc   func(personID int) (*services.TMDBPersonDetails, error)
+	personCreditsFunc   func(personID int) (*services.TMDBPersonCredits, error)
+	recommendationsFunc func(mediaType string, id, page int) (*services.TMDBSearchResult, error)
+	similarFunc         func(mediaType string, id, page int) (*services.TMDBSearchResult, error)
+	discoverFunc        func(mediaType string, filters services.TMDBDiscoverFilters, page int) (*services.TMDBSearchResult, error)
+	genresFunc          func(mediaType string) ([]services.TMDBGenre, error)
 }
 
-func (m *mockTMDBService) SearchMulti(query string, page int) (*services.TMDBSearchResult, error) {
+func (m *mockTMDBService) SearchMultiContext(ctx context.Context, query string, page int) (*services.TMDBSearchResult, error) {
 	if m.searchMultiFunc != nil {
 		return m.searchMultiFunc(query, page)
 	}
 	return &services.TMDBSearchResult{Results: []services.TMDBResult{}}, nil
 }
 
-func (m *mockTMDBService) GetMovieDetails(movieID int) (*services.TMDBMovieDetails, error) {
+func (m *mockTMDBService) GetMovieDetailsContext(ctx context.Context, movieID int) (*services.TMDBMovieDetails, error) {
 	if m.getMovieDetailsFunc != nil {
 		return m.getMovieDetailsFunc(movieID)
 	}
 	return &services.TMDBMovieDetails{}, nil
 }
 
-func (m *mockTMDBService) GetTVDetails(tvID int) (*services.TMDBTVDetails, error) {
+func (m *mockTMDBService) GetTVDetailsContext(ctx context.Context, tvID int) (*services.TMDBTVDetails, error) {
 	if m.getTVDetailsFunc != nil {
 		return m.getTVDetailsFunc(tvID)
 	}
 	return &services.TMDBTVDetails{}, nil
 }
 
+func (m *mockTMDBService) GetSeasonDetailsContext(ctx context.Context, tvID, seasonNumber int) (*services.TMDBSeasonDetails, error) {
+	if m.getSeasonFunc != nil {
+		return m.getSeasonFunc(tvID, seasonNumber)
+	}
+	return &services.TMDBSeasonDetails{}, nil
+}
+
+func (m *mockTMDBService) GetCollectionContext(ctx context.Context, collectionID int) (*services.TMDBCollection, error) {
+	if m.getCollectionFunc != nil {
+		return m.getCollectionFunc(collectionID)
+	}
+	return &services.TMDBCollection{}, nil
+}
+
+func (m *mockTMDBService) FindByIMDbIDContext(ctx context.Context, imdbID string) (*services.TMDBFindResult, error) {
+	if m.findByIMDbIDFunc != nil {
+		return m.findByIMDbIDFunc(imdbID)
+	}
+	return &services.TMDBFindResult{}, nil
+}
+
 func (m *mockTMDBService) GetImageURL(path string, size string) string {
 	return "https://image.tmdb.org/t/p/" + size + path
 }
 
+func (m *mockTMDBService) SearchPersonContext(ctx context.Context, query string, page int) (*services.TMDBPersonSearchResult, error) {
+	return &services.TMDBPersonSearchResult{}, nil
+}
+
+func (m *mockTMDBService) GetPersonDetailsContext(ctx context.Context, personID int) (*services.TMDBPersonDetails, error) {
+	if m.personDetailsFunc != nil {
+		return m.personDetailsFunc(personID)
+	}
+	return &services.TMDBPersonDetails{}, nil
+}
+
+func (m *mockTMDBService) GetPersonCreditsContext(ctx context.Context, personID int) (*services.TMDBPersonCredits, error) {
+	if m.personCreditsFunc != nil {
+		return m.personCreditsFunc(personID)
+	}
+	return &services.TMDBPersonCredits{}, nil
+}
+
+func (m *mockTMDBService) GetTrendingContext(ctx context.Context, mediaType, timeWindow string, page int) (*services.TMDBSearchResult, error) {
+	return &services.TMDBSearchResult{Results: []services.TMDBResult{}}, nil
+}
+
+func (m *mockTMDBService) GetPopularMoviesContext(ctx context.Context, page int) (*services.TMDBSearchResult, error) {
+	return &services.TMDBSearchResult{Results: []services.TMDBResult{}}, nil
+}
+
+func (m *mockTMDBService) GetPopularTVContext(ctx context.Context, page int) (*services.TMDBSearchResult, error) {
+	return &services.TMDBSearchResult{Results: []services.TMDBResult{}}, nil
+}
+
+func (m *mockTMDBService) GetTopRatedMoviesContext(ctx context.Context, page int) (*services.TMDBSearchResult, error) {
+	return &services.TMDBSearchResult{Results: []services.TMDBResult{}}, nil
+}
+
+func (m *mockTMDBService) GetTopRatedTVContext(ctx context.Context, page int) (*services.TMDBSearchResult, error) {
+	return &services.TMDBSearchResult{Results: []services.TMDBResult{}}, nil
+}
+
+func (m *mockTMDBService) GetUpcomingMoviesContext(ctx context.Context, page int) (*services.TMDBSearchResult, error) {
+	return &services.TMDBSearchResult{Results: []services.TMDBResult{}}, nil
+}
+
+func (m *mockTMDBService) GetUpcomingTVContext(ctx context.Context, page int) (*services.TMDBSearchResult, error) {
+	return &services.TMDBSearchResult{Results: []services.TMDBResult{}}, nil
+}
+
+func (m *mockTMDBService) GetRecommendationsContext(ctx context.Context, mediaType string, id, page int) (*services.TMDBSearchResult, error) {
+	if m.recommendationsFunc != nil {
+		return m.recommendationsFunc(mediaType, id, page)
+	}
+	return &services.TMDBSearchResult{Results: []services.TMDBResult{}}, nil
+}
+
+func (m *mockTMDBService) GetSimilarContext(ctx context.Context, mediaType string, id, page int) (*services.TMDBSearchResult, error) {
+	if m.similarFunc != nil {
+		return m.similarFunc(mediaType, id, page)
+	}
+	return &services.TMDBSearchResult{Results: []services.TMDBResult{}}, nil
+}
+
+func (m *mockTMDBService) DiscoverContext(ctx context.Context, mediaType string, filters services.TMDBDiscoverFilters, page int) (*services.TMDBSearchResult, error) {
+	if m.discoverFunc != nil {
+		return m.discoverFunc(mediaType, filters, page)
+	}
+	return &services.TMDBSearchResult{Results: []services.TMDBResult{}}, nil
+}
+
+func (m *mockTMDBService) GetGenresContext(ctx context.Context, mediaType string) ([]services.TMDBGenre, error) {
+	if m.genresFunc != nil {
+		return m.genresFunc(mediaType)
+	}
+	return []services.TMDBGenre{}, nil
+}
+
 func TestSearchMedia(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -133,6 +240,28 @@ func TestSearchMedia(t *testing.T) {
 				testutil.AssertEqual(t, "failed to search TMDB", response["error"])
 			},
 		},
+		{
+			name:  "TMDB rate limited",
+			query: "Matrix",
+			mockTMDB: func(query string, page int) (*services.TMDBSearchResult, error) {
+				return nil, &services.UpstreamError{Upstream: "TMDB", StatusCode: 429, Err: services.ErrUpstreamRateLimited}
+			},
+			expectedStatus: http.StatusServiceUnavailable,
+			checkResponse: func(t *testing.T, response map[string]interface{}) {
+				testutil.AssertEqual(t, "failed to search TMDB", response["error"])
+			},
+		},
+		{
+			name:  "request deadline exceeded",
+			query: "Matrix",
+			mockTMDB: func(query string, page int) (*services.TMDBSearchResult, error) {
+				return nil, fmt.Errorf("failed to search TMDB: %w", context.DeadlineExceeded)
+			},
+			expectedStatus: http.StatusGatewayTimeout,
+			checkResponse: func(t *testing.T, response map[string]interface{}) {
+				testutil.AssertEqual(t, "failed to search TMDB", response["error"])
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -151,7 +280,7 @@ func TestSearchMedia(t *testing.T) {
 				}
 			}
 
-			handler := NewSearchHandler(mockTMDB, mockPlex)
+			handler := NewSearchHandler(mockTMDB, mockPlex, nil, nil)
 			router.GET("/search", handler.SearchMedia)
 
 			// Build URL
@@ -193,6 +322,7 @@ func TestGetMediaDetails(t *testing.T) {
 		mockMovie      func(movieID int) (*services.TMDBMovieDetails, error)
 		mockTV         func(tvID int) (*services.TMDBTVDetails, error)
 		mockPlex       func(title string, year int, mediaType string) (bool, error)
+		mockSeasons    func(title string, year int) (map[int][]int, error)
 		expectedStatus int
 		checkResponse  func(t *testing.T, response map[string]interface{})
 	}{
@@ -234,6 +364,43 @@ func TestGetMediaDetails(t *testing.T) {
 				testutil.AssertEqual(t, float64(5), response["number_of_seasons"])
 			},
 		},
+		{
+			name:      "tv seasons in plex",
+			mediaType: "tv",
+			id:        "1396",
+			mockTV: func(tvID int) (*services.TMDBTVDetails, error) {
+				return &services.TMDBTVDetails{
+					ID:           1396,
+					Name:         "Breaking Bad",
+					FirstAirDate: "2008-01-20",
+					Seasons: []services.TMDBSeason{
+						{SeasonNumber: 1, EpisodeCount: 3},
+						{SeasonNumber: 2, EpisodeCount: 2},
+						{SeasonNumber: 3, EpisodeCount: 2},
+					},
+				}, nil
+			},
+			mockPlex: func(title string, year int, mediaType string) (bool, error) {
+				return true, nil
+			},
+			mockSeasons: func(title string, year int) (map[int][]int, error) {
+				// Episode 9 isn't in TMDB's season and doesn't count
+				return map[int][]int{1: {1, 3, 9}, 2: {1, 2}}, nil
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, response map[string]interface{}) {
+				seasons := response["seasons"].([]interface{})
+				partial := seasons[0].(map[string]interface{})
+				testutil.AssertEqual(t, nil, partial["in_plex"])
+				testutil.AssertEqual(t, float64(2), partial["episodes_in_plex"])
+				complete := seasons[1].(map[string]interface{})
+				testutil.AssertEqual(t, true, complete["in_plex"])
+				testutil.AssertEqual(t, float64(2), complete["episodes_in_plex"])
+				missing := seasons[2].(map[string]interface{})
+				testutil.AssertEqual(t, nil, missing["in_plex"])
+				testutil.AssertEqual(t, nil, missing["episodes_in_plex"])
+			},
+		},
 		{
 			name:           "invalid media type",
 			mediaType:      "invalid",
@@ -268,10 +435,11 @@ func TestGetMediaDetails(t *testing.T) {
 			if tt.mockPlex != nil {
 				mockPlex = &mockPlexService{
 					checkIfExistsFunc: tt.mockPlex,
+					showSeasonsFunc:   tt.mockSeasons,
 				}
 			}
 
-			handler := NewSearchHandler(mockTMDB, mockPlex)
+			handler := NewSearchHandler(mockTMDB, mockPlex, nil, nil)
 			router.GET("/search/:type/:id", handler.GetMediaDetails)
 
 			url := "/search/" + tt.mediaType + "/" + tt.id
@@ -292,4 +460,235 @@ func TestGetMediaDetails(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+func TestGetSeasonDetails(t *testing.T) {
+	season := func(tvID, seasonNumber int) (*services.TMDBSeasonDetails, error) {
+		return &services.TMDBSeasonDetails{
+			SeasonNumber: seasonNumber,
+			Episodes: []services.TMDBEpisode{
+				{EpisodeNumber: 1, Name: "Pilot", AirDate: "2008-01-20"},
+				{EpisodeNumber: 2, Name: "Cat's in the Bag...", AirDate: "2008-01-27"},
+				{EpisodeNumber: 3, Name: "...And the Bag's in the River", AirDate: "2008-02-10"},
+			},
+		}, nil
+	}
+
+	tests := []struct {
+		name           string
+		path           string
+		mockSeason     func(tvID, seasonNumber int) (*services.TMDBSeasonDetails, error)
+		mockEpisodes   func(title string, year int, seasonNumber int) ([]int, error)
+		expectedStatus int
+		checkResponse  func(t *testing.T, response map[string]interface{})
+	}{
+		{
+			name:       "some episodes in Plex",
+			path:       "/search/tv/1396/season/1",
+			mockSeason: season,
+			mockEpisodes: func(title string, year int, seasonNumber int) ([]int, error) {
+				testutil.AssertEqual(t, "Breaking Bad", title)
+				testutil.AssertEqual(t, 2008, year)
+				testutil.AssertEqual(t, 1, seasonNumber)
+				return []int{1, 3}, nil
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, response map[string]interface{}) {
+				testutil.AssertEqual(t, float64(2), response["episodes_in_plex"])
+				testutil.AssertEqual(t, nil, response["in_plex"])
+
+				episodes := response["episodes"].([]interface{})
+				testutil.AssertEqual(t, 3, len(episodes))
+				testutil.AssertEqual(t, true, episodes[0].(map[string]interface{})["in_plex"])
+				testutil.AssertEqual(t, nil, episodes[1].(map[string]interface{})["in_plex"])
+			},
+		},
+		{
+			name:       "whole season in Plex",
+			path:       "/search/tv/1396/season/1",
+			mockSeason: season,
+			mockEpisodes: func(title string, year int, seasonNumber int) ([]int, error) {
+				return []int{1, 2, 3}, nil
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, response map[string]interface{}) {
+				testutil.AssertEqual(t, true, response["in_plex"])
+				testutil.AssertEqual(t, float64(3), response["episodes_in_plex"])
+			},
+		},
+		{
+			name:           "invalid season number",
+			path:           "/search/tv/1396/season/first",
+			expectedStatus: http.StatusBadRequest,
+			checkResponse: func(t *testing.T, response map[string]interface{}) {
+				testutil.AssertEqual(t, "invalid season number", response["error"])
+			},
+		},
+		{
+			name: "TMDB error",
+			path: "/search/tv/1396/season/99",
+			mockSeason: func(tvID, seasonNumber int) (*services.TMDBSeasonDetails, error) {
+				return nil, errors.New("TMDB API returned status 404")
+			},
+			expectedStatus: http.StatusInternalServerError,
+			checkResponse: func(t *testing.T, response map[string]interface{}) {
+				testutil.AssertEqual(t, "failed to get season details", response["error"])
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+
+			mockTMDB := &mockTMDBService{
+				getSeasonFunc: tt.mockSeason,
+				getTVDetailsFunc: func(tvID int) (*services.TMDBTVDetails, error) {
+					return &services.TMDBTVDetails{ID: tvID, Name: "Breaking Bad", FirstAirDate: "2008-01-20"}, nil
+				},
+			}
+			mockPlex := &mockPlexService{seasonEpisodesFunc: tt.mockEpisodes}
+
+			handler := NewSearchHandler(mockTMDB, mockPlex, nil, nil)
+			router.GET("/search/:type/:id", handler.GetMediaDetails)
+			router.GET("/search/tv/:id/season/:n", handler.GetSeasonDetails)
+
+			req, err := http.NewRequest("GET", tt.path, nil)
+			testutil.AssertNoError(t, err)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			testutil.AssertEqual(t, tt.expectedStatus, w.Code)
+
+			var response map[string]interface{}
+			testutil.AssertNoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			tt.checkResponse(t, response)
+		})
+	}
+}
+
+func TestGetRecommendations(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := testutil.SetupTestDB(t)
+
+	user := testutil.CreateTestUser(t, db, "user@example.com", "testuser", "hashedpass", false)
+	other := testutil.CreateTestUser(t, db, "other@example.com", "otheruser", "hashedpass", false)
+
+	// The user requested Matrix Reloaded, someone else requested Revolutions
+	reloaded := testutil.CreateTestRequest(t, db, user.ID, "The Matrix Reloaded", models.MediaTypeMovie)
+	db.Model(reloaded).Update("tmdb_id", 604)
+	revolutions := testutil.CreateTestRequest(t, db, other.ID, "The Matrix Revolutions", models.MediaTypeMovie)
+	db.Model(revolutions).Update("tmdb_id", 605)
+
+	movies := func(mediaType string, id, page int) (*services.TMDBSearchResult, error) {
+		return &services.TMDBSearchResult{
+			Page: page,
+			Results: []services.TMDBResult{
+				{ID: 604, Title: "The Matrix Reloaded", MediaType: "movie", ReleaseDate: "2003-05-15"},
+				{ID: 605, Title: "The Matrix Revolutions", MediaType: "movie", ReleaseDate: "2003-11-05"},
+				{ID: 1396, Name: "Breaking Bad", MediaType: "tv", FirstAirDate: "2008-01-20"},
+			},
+		}, nil
+	}
+	empty := func(mediaType string, id, page int) (*services.TMDBSearchResult, error) {
+		return &services.TMDBSearchResult{Page: page, Results: []services.TMDBResult{}}, nil
+	}
+
+	tests := []struct {
+		name                string
+		path                string
+		mockRecommendations func(mediaType string, id, page int) (*services.TMDBSearchResult, error)
+		expectedStatus      int
+		checkResponse       func(t *testing.T, response map[string]interface{})
+	}{
+		{
+			name:                "filters requested titles and checks Plex",
+			path:                "/search/movie/603/recommendations",
+			mockRecommendations: movies,
+			expectedStatus:      http.StatusOK,
+			checkResponse: func(t *testing.T, response map[string]interface{}) {
+				testutil.AssertEqual(t, "recommendations", response["source"])
+
+				results := response["results"].([]interface{})
+				testutil.AssertEqual(t, 2, len(results))
+				first := results[0].(map[string]interface{})
+				testutil.AssertEqual(t, float64(605), first["id"])
+				testutil.AssertEqual(t, true, first["in_plex"])
+
+				// A TV show with the same TMDB ID as a requested movie is kept
+				testutil.AssertEqual(t, float64(1396), results[1].(map[string]interface{})["id"])
+			},
+		},
+		{
+			name:                "falls back to similar titles",
+			path:                "/search/tv/1396/recommendations",
+			mockRecommendations: empty,
+			expectedStatus:      http.StatusOK,
+			checkResponse: func(t *testing.T, response map[string]interface{}) {
+				testutil.AssertEqual(t, "similar", response["source"])
+				testutil.AssertEqual(t, 1, len(response["results"].([]interface{})))
+			},
+		},
+		{
+			name:           "invalid source",
+			path:           "/search/movie/603/recommendations?source=popular",
+			expectedStatus: http.StatusBadRequest,
+			checkResponse: func(t *testing.T, response map[string]interface{}) {
+				testutil.AssertEqual(t, "invalid source, must be 'recommendations' or 'similar'", response["error"])
+			},
+		},
+		{
+			name: "TMDB unavailable",
+			path: "/search/movie/603/recommendations",
+			mockRecommendations: func(mediaType string, id, page int) (*services.TMDBSearchResult, error) {
+				return nil, services.ErrUpstreamUnavailable
+			},
+			expectedStatus: http.StatusServiceUnavailable,
+			checkResponse: func(t *testing.T, response map[string]interface{}) {
+				testutil.AssertEqual(t, "failed to get recommendations", response["error"])
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+
+			mockTMDB := &mockTMDBService{
+				recommendationsFunc: tt.mockRecommendations,
+				similarFunc: func(mediaType string, id, page int) (*services.TMDBSearchResult, error) {
+					return &services.TMDBSearchResult{
+						Page:    page,
+						Results: []services.TMDBResult{{ID: 1398, Name: "The Sopranos", MediaType: "tv"}},
+					}, nil
+				},
+			}
+			mockPlex := &mockPlexService{
+				checkIfExistsFunc: func(title string, year int, mediaType string) (bool, error) {
+					return title == "The Matrix Revolutions", nil
+				},
+			}
+
+			handler := NewSearchHandler(mockTMDB, mockPlex, nil, db)
+			router.Use(func(c *gin.Context) {
+				c.Set("userID", user.ID)
+			})
+			router.GET("/search/:type/:id", handler.GetMediaDetails)
+			router.GET("/search/:type/:id/recommendations", handler.GetRecommendations)
+			router.GET("/search/tv/:id/season/:n", handler.GetSeasonDetails)
+
+			req, err := http.NewRequest("GET", tt.path, nil)
+			testutil.AssertNoError(t, err)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			testutil.AssertEqual(t, tt.expectedStatus, w.Code)
+
+			var response map[string]interface{}
+			testutil.AssertNoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			tt.checkResponse(t, response)
+		})
+	}
+}
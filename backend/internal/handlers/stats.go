@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jacob-fain/MRS/internal/services"
+)
+
+const (
+	defaultStatsRangeDays = 30
+	defaultStatsLimit     = 10
+	maxStatsLimit         = 50
+)
+
+// maxStatsRangeDays caps the range per interval, bounding the series and the
+// number of requests scanned
+var maxStatsRangeDays = map[services.StatsInterval]int{
+	services.StatsIntervalDay:  2 * 365,
+	services.StatsIntervalWeek: 10 * 365,
+}
+
+type statsHandler struct {
+	statsService *services.StatsService
+}
+
+// NewStatsHandler creates a new stats handler
+func NewStatsHandler(statsService *services.StatsService) *statsHandler {
+	return &statsHandler{
+		statsService: statsService,
+	}
+}
+
+// GetOverview returns request analytics for a date range (admin only)
+// @Summary Get stats overview
+// @Description Get requests per day or week, median time to approve and to complete, top requesters,
+// @Description top genres, approval rate per user and Plex fulfilment rate for the requests created
+// @Description in the range (admin only). Defaults to the last 30 days. Ranges are capped at 730 days
+// @Description with the day interval and 3650 days with the week interval.
+// @Description Genres are recorded from TMDB when a request is created; requests from before that
+// @Description are filled in by a backfill at startup, requests TMDB has no genres for aren't counted.
+// @Tags stats
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param from query string false "Only requests created on or after this date (YYYY-MM-DD or RFC3339)"
+// @Param to query string false "Only requests created on or before this date (YYYY-MM-DD or RFC3339)"
+// @Param interval query string false "Time series bucket size (day, week)" default(day)
+// @Param limit query int false "Number of top requesters and genres (default 10, max 50)"
+// @Success 200 {object} services.StatsOverview
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /stats/overview [get]
+func (h *statsHandler) GetOverview(c *gin.Context) {
+	now := time.Now().UTC()
+	query := services.StatsQuery{
+		To:       time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC),
+		Interval: services.StatsIntervalDay,
+		Limit:    defaultStatsLimit,
+	}
+
+	if to := c.Query("to"); to != "" {
+		toTime, err := parseDateParam(to)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid to date, use YYYY-MM-DD or RFC3339",
+			})
+			return
+		}
+		// A bare date includes the whole day
+		if len(to) == len("2006-01-02") {
+			toTime = toTime.AddDate(0, 0, 1)
+		}
+		query.To = toTime
+	}
+	query.From = query.To.AddDate(0, 0, -defaultStatsRangeDays)
+	if from := c.Query("from"); from != "" {
+		fromTime, err := parseDateParam(from)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid from date, use YYYY-MM-DD or RFC3339",
+			})
+			return
+		}
+		query.From = fromTime
+	}
+	if !query.From.Before(query.To) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid range, from must be before to",
+		})
+		return
+	}
+
+	if interval := c.Query("interval"); interval != "" {
+		switch services.StatsInterval(interval) {
+		case services.StatsIntervalDay, services.StatsIntervalWeek:
+			query.Interval = services.StatsInterval(interval)
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid interval, must be 'day' or 'week'",
+			})
+			return
+		}
+	}
+
+	maxDays := maxStatsRangeDays[query.Interval]
+	if query.To.Sub(query.From) > time.Duration(maxDays)*24*time.Hour {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("Invalid range, at most %d days with interval '%s'", maxDays, query.Interval),
+		})
+		return
+	}
+
+	if l, err := strconv.Atoi(c.Query("limit")); err == nil && l > 0 {
+		query.Limit = l
+	}
+	if query.Limit > maxStatsLimit {
+		query.Limit = maxStatsLimit
+	}
+
+	overview, err := h.statsService.Overview(c.Request.Context(), query)
+	if err != nil {
+		log.Printf("Failed to compute stats overview: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to compute stats",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, overview)
+}
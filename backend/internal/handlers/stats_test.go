@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jacob-fain/MRS/internal/models"
+	"github.com/jacob-fain/MRS/internal/services"
+	"github.com/jacob-fain/MRS/internal/testutil"
+)
+
+func TestStatsHandler_GetOverview(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := testutil.SetupTestDB(t)
+	admin := testutil.CreateTestUser(t, db, "admin@example.com", "admin", "hashedpass", true)
+	user := testutil.CreateTestUser(t, db, "user@example.com", "user", "hashedpass", false)
+
+	testutil.CreateTestRequest(t, db, user.ID, "Dune", models.MediaTypeMovie)
+	old := testutil.CreateTestRequest(t, db, user.ID, "Sicario", models.MediaTypeMovie)
+	db.Model(old).Update("created_at", time.Now().AddDate(0, -2, 0))
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", admin.ID)
+		c.Set("isAdmin", true)
+	})
+	router.GET("/stats/overview", NewStatsHandler(services.NewStatsService(db, nil)).GetOverview)
+
+	today := time.Now().UTC().Format("2006-01-02")
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		check          func(t *testing.T, overview services.StatsOverview)
+	}{
+		{
+			name:           "last 30 days by default",
+			expectedStatus: http.StatusOK,
+			check: func(t *testing.T, overview services.StatsOverview) {
+				testutil.AssertEqual(t, int64(1), overview.TotalRequests)
+				testutil.AssertEqual(t, 30, len(overview.Series))
+				testutil.AssertEqual(t, today, overview.Series[29].Date)
+				testutil.AssertEqual(t, services.StatsIntervalDay, overview.Interval)
+				testutil.AssertEqual(t, 1, len(overview.TopRequesters))
+				testutil.AssertEqual(t, "user", overview.TopRequesters[0].Username)
+			},
+		},
+		{
+			name:           "bare to date includes the day",
+			query:          "?from=" + time.Now().UTC().AddDate(0, -3, 0).Format("2006-01-02") + "&to=" + today + "&interval=week",
+			expectedStatus: http.StatusOK,
+			check: func(t *testing.T, overview services.StatsOverview) {
+				testutil.AssertEqual(t, int64(2), overview.TotalRequests)
+				testutil.AssertEqual(t, services.StatsIntervalWeek, overview.Interval)
+			},
+		},
+		{
+			name:           "invalid interval",
+			query:          "?interval=month",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid from date",
+			query:          "?from=yesterday",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "from after to",
+			query:          "?from=2026-03-10&to=2026-03-01",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "range too long for days",
+			query:          "?from=2024-01-01&to=2026-03-01",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "long range by week",
+			query:          "?from=2024-01-01&to=2026-03-01&interval=week",
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/stats/overview"+tt.query, nil)
+			router.ServeHTTP(w, req)
+
+			testutil.AssertEqual(t, tt.expectedStatus, w.Code)
+			if tt.check == nil {
+				return
+			}
+
+			var overview services.StatsOverview
+			testutil.AssertNoError(t, json.Unmarshal(w.Body.Bytes(), &overview))
+			tt.check(t, overview)
+		})
+	}
+}
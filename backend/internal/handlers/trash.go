@@ -0,0 +1,450 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jacob-fain/MRS/internal/models"
+	"github.com/jacob-fain/MRS/internal/services"
+	"gorm.io/gorm"
+)
+
+type trashHandler struct {
+	db           *gorm.DB
+	auditService *services.AuditService
+}
+
+// NewTrashHandler creates a new handler for soft-deleted requests and users
+func NewTrashHandler(db *gorm.DB, auditService *services.AuditService) *trashHandler {
+	return &trashHandler{
+		db:           db,
+		auditService: auditService,
+	}
+}
+
+// TrashedRequestResponse represents a soft-deleted request in API responses
+type TrashedRequestResponse struct {
+	RequestResponse
+	DeletedAt string `json:"deleted_at"`
+}
+
+// TrashedUserResponse represents a soft-deleted user in API responses
+type TrashedUserResponse struct {
+	UserResponse
+	RequestCount int64  `json:"request_count"`
+	DeletedAt    string `json:"deleted_at"`
+}
+
+// unscopedUser preloads users including soft-deleted ones
+func unscopedUser(db *gorm.DB) *gorm.DB {
+	return db.Unscoped()
+}
+
+// GetTrashedRequests returns all soft-deleted requests (admin only)
+// @Summary Get deleted requests
+// @Description Get all soft-deleted requests (admin only)
+// @Tags trash
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /trash/requests [get]
+func (h *trashHandler) GetTrashedRequests(c *gin.Context) {
+	var requests []models.Request
+	if err := h.db.Unscoped().
+		Preload("User", unscopedUser).
+		Where("deleted_at IS NOT NULL").
+		Order("deleted_at DESC").
+		Find(&requests).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch deleted requests",
+		})
+		return
+	}
+
+	responses := make([]TrashedRequestResponse, len(requests))
+	for i, req := range requests {
+		responses[i] = TrashedRequestResponse{
+			RequestResponse: toRequestResponse(req),
+			DeletedAt:       req.DeletedAt.Time.Format("2006-01-02T15:04:05Z"),
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"requests": responses,
+		"count":    len(responses),
+	})
+}
+
+// GetTrashedUsers returns all soft-deleted users (admin only)
+// @Summary Get deleted users
+// @Description Get all soft-deleted users with their request counts (admin only)
+// @Tags trash
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /trash/users [get]
+func (h *trashHandler) GetTrashedUsers(c *gin.Context) {
+	var users []models.User
+	if err := h.db.Unscoped().
+		Where("deleted_at IS NOT NULL").
+		Order("deleted_at DESC").
+		Find(&users).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch deleted users",
+		})
+		return
+	}
+
+	responses := make([]TrashedUserResponse, len(users))
+	for i, user := range users {
+		var requestCount int64
+		h.db.Unscoped().Model(&models.Request{}).Where("user_id = ?", user.ID).Count(&requestCount)
+
+		responses[i] = TrashedUserResponse{
+			UserResponse: UserResponse{
+				ID:        user.ID,
+				Email:     user.Email,
+				Username:  user.Username,
+				IsAdmin:   user.IsAdmin,
+				CreatedAt: user.CreatedAt.Format("2006-01-02T15:04:05Z"),
+			},
+			RequestCount: requestCount,
+			DeletedAt:    user.DeletedAt.Time.Format("2006-01-02T15:04:05Z"),
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"users": responses,
+		"count": len(responses),
+	})
+}
+
+// RestoreRequest restores a soft-deleted request (admin only)
+// @Summary Restore a deleted request
+// @Description Restore a soft-deleted request (admin only). The requester must not be deleted.
+// @Tags trash
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Request ID"
+// @Success 200 {object} RequestResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /trash/requests/{id}/restore [post]
+func (h *trashHandler) RestoreRequest(c *gin.Context) {
+	request, ok := h.findTrashedRequest(c)
+	if !ok {
+		return
+	}
+
+	// A request cannot be restored for a user who is still deleted
+	if request.User.DeletedAt.Valid {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "The requester is deleted, restore the user first",
+		})
+		return
+	}
+
+	if err := h.db.Unscoped().Model(request).Update("deleted_at", nil).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to restore request",
+		})
+		return
+	}
+
+	adminID, _ := c.Get("userID")
+	if audit := auditFor(c, h.auditService); audit != nil {
+		if err := audit.LogRequestRestored(request.ID, adminID.(uint), "Request restored"); err != nil {
+			log.Printf("Failed to log audit entry for request restore (ID: %d): %v", request.ID, err)
+		}
+	}
+
+	h.db.Preload("User").First(request, request.ID)
+
+	c.JSON(http.StatusOK, toRequestResponse(*request))
+}
+
+// RestoreUser restores a soft-deleted user and the requests deleted with them (admin only)
+// @Summary Restore a deleted user
+// @Description Restore a soft-deleted user along with the requests that were deleted with them (admin only)
+// @Tags trash
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /trash/users/{id}/restore [post]
+func (h *trashHandler) RestoreUser(c *gin.Context) {
+	user, ok := h.findTrashedUser(c)
+	if !ok {
+		return
+	}
+
+	// Requests deleted in the same cascade share the user's deletion timestamp
+	var restoredIDs []uint
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&models.Request{}).
+			Where("user_id = ? AND deleted_at = ?", user.ID, user.DeletedAt.Time).
+			Pluck("id", &restoredIDs).Error; err != nil {
+			return err
+		}
+
+		if len(restoredIDs) > 0 {
+			if err := tx.Unscoped().Model(&models.Request{}).
+				Where("id IN ?", restoredIDs).
+				Update("deleted_at", nil).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Unscoped().Model(user).Update("deleted_at", nil).Error
+	})
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to restore user",
+		})
+		return
+	}
+
+	adminID, _ := c.Get("userID")
+	log.Printf("User %d (%s) restored by admin %v with %d requests", user.ID, user.Username, adminID, len(restoredIDs))
+	if audit := auditFor(c, h.auditService); audit != nil {
+		if err := audit.LogUserRestored(user.ID, adminID.(uint), user.Username, len(restoredIDs)); err != nil {
+			log.Printf("Failed to log audit entry for user restore (ID: %d): %v", user.ID, err)
+		}
+		notes := fmt.Sprintf("Request restored with user %s", user.Username)
+		for _, requestID := range restoredIDs {
+			if err := audit.LogRequestRestored(requestID, adminID.(uint), notes); err != nil {
+				log.Printf("Failed to log audit entry for request restore (ID: %d): %v", requestID, err)
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":           "User restored successfully",
+		"restored_requests": len(restoredIDs),
+		"user": UserResponse{
+			ID:        user.ID,
+			Email:     user.Email,
+			Username:  user.Username,
+			IsAdmin:   user.IsAdmin,
+			CreatedAt: user.CreatedAt.Format("2006-01-02T15:04:05Z"),
+		},
+	})
+}
+
+// PurgeRequest permanently deletes a soft-deleted request (admin only)
+// @Summary Permanently delete a request
+// @Description Permanently delete a soft-deleted request along with its comments and audit history (admin only)
+// @Tags trash
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Request ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /trash/requests/{id} [delete]
+func (h *trashHandler) PurgeRequest(c *gin.Context) {
+	request, ok := h.findTrashedRequest(c)
+	if !ok {
+		return
+	}
+
+	adminID, _ := c.Get("userID")
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		return purgeRequests(tx, auditFor(c, h.auditService), adminID.(uint), []uint{request.ID})
+	})
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to purge request",
+		})
+		return
+	}
+
+	log.Printf("Request %d (%s) permanently deleted by admin %v", request.ID, request.Title, adminID)
+	if audit := auditFor(c, h.auditService); audit != nil {
+		if err := audit.LogRequestPurged(request.ID, adminID.(uint), request.Title); err != nil {
+			log.Printf("Failed to log audit entry for request purge (ID: %d): %v", request.ID, err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Request permanently deleted",
+	})
+}
+
+// PurgeUser permanently deletes a soft-deleted user and all their requests (admin only)
+// @Summary Permanently delete a user
+// @Description Permanently delete a soft-deleted user and all their requests (admin only)
+// @Tags trash
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /trash/users/{id} [delete]
+func (h *trashHandler) PurgeUser(c *gin.Context) {
+	user, ok := h.findTrashedUser(c)
+	if !ok {
+		return
+	}
+
+	adminID, _ := c.Get("userID")
+	audit := auditFor(c, h.auditService)
+	var requestIDs []uint
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&models.Request{}).Where("user_id = ?", user.ID).Pluck("id", &requestIDs).Error; err != nil {
+			return err
+		}
+		if err := purgeRequests(tx, audit, adminID.(uint), requestIDs); err != nil {
+			return err
+		}
+
+		// Remove the user's comments on other requests, and keep the history
+		// of actions they took as system actions
+		if err := tx.Unscoped().Where("user_id = ?", user.ID).Delete(&models.RequestComment{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&models.AuditLog{}).Where("user_id = ?", user.ID).Update("user_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", user.ID).Delete(&models.WatchlistItem{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Delete(user).Error; err != nil {
+			return err
+		}
+
+		// The purge entry is what lets VerifyChain accept the entries cleared
+		// above, so the purge is rolled back without it
+		return audit.LogUserPurged(tx, user.ID, adminID.(uint), user.Username, len(requestIDs))
+	})
+
+	if err != nil {
+		log.Printf("Failed to purge user %d: %v", user.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to purge user",
+		})
+		return
+	}
+
+	log.Printf("User %d (%s) permanently deleted by admin %v with %d requests", user.ID, user.Username, adminID, len(requestIDs))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "User permanently deleted",
+	})
+}
+
+// purgeRequests permanently deletes requests together with the comments that
+// reference them, and hides their audit logs
+func purgeRequests(tx *gorm.DB, audit *services.AuditService, adminID uint, requestIDs []uint) error {
+	if len(requestIDs) == 0 {
+		return nil
+	}
+	if err := tx.Unscoped().Where("request_id IN ?", requestIDs).Delete(&models.RequestComment{}).Error; err != nil {
+		return err
+	}
+	// Audit logs are part of the hash chain, so they are detached and hidden
+	// behind a marker entry rather than removed
+	var auditLogIDs []uint
+	if err := tx.Model(&models.AuditLog{}).Where("request_id IN ?", requestIDs).Pluck("id", &auditLogIDs).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Model(&models.AuditLog{}).Where("request_id IN ?", requestIDs).Update("request_id", nil).Error; err != nil {
+		return err
+	}
+	notes := fmt.Sprintf("Audit logs of %d purged requests hidden", len(requestIDs))
+	if err := audit.HideEntries(tx, auditLogIDs, &adminID, notes); err != nil {
+		return err
+	}
+	if err := tx.Model(&models.WatchlistItem{}).Where("request_id IN ?", requestIDs).Update("request_id", nil).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("request_id IN ?", requestIDs).Delete(&models.RequestGenre{}).Error; err != nil {
+		return err
+	}
+	return tx.Unscoped().Where("id IN ?", requestIDs).Delete(&models.Request{}).Error
+}
+
+// findTrashedRequest loads a soft-deleted request from the path. It writes the error response itself.
+func (h *trashHandler) findTrashedRequest(c *gin.Context) (*models.Request, bool) {
+	requestID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request ID",
+		})
+		return nil, false
+	}
+
+	var request models.Request
+	if err := h.db.Unscoped().
+		Preload("User", unscopedUser).
+		Where("deleted_at IS NOT NULL").
+		First(&request, requestID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Deleted request not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to find request",
+			})
+		}
+		return nil, false
+	}
+
+	return &request, true
+}
+
+// findTrashedUser loads a soft-deleted user from the path. It writes the error response itself.
+func (h *trashHandler) findTrashedUser(c *gin.Context) (*models.User, bool) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+		})
+		return nil, false
+	}
+
+	var user models.User
+	if err := h.db.Unscoped().Where("deleted_at IS NOT NULL").First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Deleted user not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to find user",
+			})
+		}
+		return nil, false
+	}
+
+	return &user, true
+}
@@ -0,0 +1,361 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jacob-fain/MRS/internal/models"
+	"github.com/jacob-fain/MRS/internal/services"
+	"github.com/jacob-fain/MRS/internal/testutil"
+	"gorm.io/gorm"
+)
+
+func TestTrashHandler_GetTrashedRequests(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	db := testutil.SetupTestDB(t)
+	handler := NewTrashHandler(db, services.NewAuditService(db))
+
+	admin := testutil.CreateTestUser(t, db, "admin@example.com", "admin", "pass", true)
+	user := testutil.CreateTestUser(t, db, "user@example.com", "user", "pass", false)
+
+	testutil.CreateTestRequest(t, db, user.ID, "Active Request", models.MediaTypeMovie)
+	deleted := testutil.CreateTestRequest(t, db, user.ID, "Deleted Request", models.MediaTypeMovie)
+	db.Delete(deleted)
+
+	router := newTestRouter(admin.ID, true)
+	router.GET("/trash/requests", handler.GetTrashedRequests)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/trash/requests", nil)
+	router.ServeHTTP(w, req)
+
+	testutil.AssertEqual(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Requests []TrashedRequestResponse `json:"requests"`
+		Count    int                      `json:"count"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	testutil.AssertEqual(t, 1, response.Count)
+	testutil.AssertEqual(t, "Deleted Request", response.Requests[0].Title)
+	testutil.AssertEqual(t, "user", response.Requests[0].User.Username)
+	testutil.AssertTrue(t, response.Requests[0].DeletedAt != "", "deleted_at should be set")
+}
+
+func TestTrashHandler_RestoreRequest(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	db := testutil.SetupTestDB(t)
+	handler := NewTrashHandler(db, services.NewAuditService(db))
+
+	admin := testutil.CreateTestUser(t, db, "admin@example.com", "admin", "pass", true)
+	user := testutil.CreateTestUser(t, db, "user@example.com", "user", "pass", false)
+	deletedUser := testutil.CreateTestUser(t, db, "gone@example.com", "gone", "pass", false)
+
+	active := testutil.CreateTestRequest(t, db, user.ID, "Active Request", models.MediaTypeMovie)
+	deleted := testutil.CreateTestRequest(t, db, user.ID, "Deleted Request", models.MediaTypeMovie)
+	db.Delete(deleted)
+	orphaned := testutil.CreateTestRequest(t, db, deletedUser.ID, "Orphaned Request", models.MediaTypeMovie)
+	db.Delete(orphaned)
+	db.Delete(deletedUser)
+
+	router := newTestRouter(admin.ID, true)
+	router.POST("/trash/requests/:id/restore", handler.RestoreRequest)
+
+	tests := []struct {
+		name           string
+		requestID      string
+		expectedStatus int
+	}{
+		{
+			name:           "restore deleted request",
+			requestID:      fmt.Sprintf("%d", deleted.ID),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "request that is not deleted",
+			requestID:      fmt.Sprintf("%d", active.ID),
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "requester is still deleted",
+			requestID:      fmt.Sprintf("%d", orphaned.ID),
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "invalid request ID",
+			requestID:      "invalid",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/trash/requests/"+tt.requestID+"/restore", nil)
+			router.ServeHTTP(w, req)
+
+			testutil.AssertEqual(t, tt.expectedStatus, w.Code)
+		})
+	}
+
+	// Verify the request is visible again and the restore was audited
+	var restored models.Request
+	testutil.AssertNoError(t, db.First(&restored, deleted.ID).Error)
+
+	var auditLog models.AuditLog
+	err := db.Where("request_id = ? AND action = ?", deleted.ID, models.ActionRestored).First(&auditLog).Error
+	testutil.AssertNoError(t, err)
+	testutil.AssertEqual(t, admin.ID, *auditLog.UserID)
+}
+
+func TestTrashHandler_RestoreUser(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	db := testutil.SetupTestDB(t)
+	auditService := services.NewAuditService(db)
+	handler := NewTrashHandler(db, auditService)
+	userHandler := NewUserHandler(db, auditService)
+
+	admin := testutil.CreateTestUser(t, db, "admin@example.com", "admin", "pass", true)
+	user := testutil.CreateTestUser(t, db, "user@example.com", "user", "pass", false)
+
+	// Deleted on its own before the user was deleted, so it stays in the trash
+	earlier := testutil.CreateTestRequest(t, db, user.ID, "Deleted Earlier", models.MediaTypeMovie)
+	db.Model(earlier).Update("deleted_at", time.Now().Add(-24*time.Hour))
+
+	req1 := testutil.CreateTestRequest(t, db, user.ID, "Request 1", models.MediaTypeMovie)
+	req2 := testutil.CreateTestRequest(t, db, user.ID, "Request 2", models.MediaTypeTV)
+
+	router := newTestRouter(admin.ID, true)
+	router.DELETE("/users/:id", userHandler.DeleteUser)
+	router.GET("/trash/users", handler.GetTrashedUsers)
+	router.POST("/trash/users/:id/restore", handler.RestoreUser)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", fmt.Sprintf("/users/%d", user.ID), nil)
+	router.ServeHTTP(w, req)
+	testutil.AssertEqual(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/trash/users", nil)
+	router.ServeHTTP(w, req)
+	testutil.AssertEqual(t, http.StatusOK, w.Code)
+
+	var listResponse struct {
+		Users []TrashedUserResponse `json:"users"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &listResponse)
+	testutil.AssertEqual(t, 1, len(listResponse.Users))
+	testutil.AssertEqual(t, int64(3), listResponse.Users[0].RequestCount)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", fmt.Sprintf("/trash/users/%d/restore", user.ID), nil)
+	router.ServeHTTP(w, req)
+	testutil.AssertEqual(t, http.StatusOK, w.Code)
+
+	var response map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	testutil.AssertEqual(t, float64(2), response["restored_requests"])
+
+	// Verify the user and the requests deleted with them are back
+	var restoredUser models.User
+	testutil.AssertNoError(t, db.First(&restoredUser, user.ID).Error)
+
+	var count int64
+	db.Model(&models.Request{}).Where("id IN ?", []uint{req1.ID, req2.ID}).Count(&count)
+	testutil.AssertEqual(t, int64(2), count)
+
+	db.Model(&models.Request{}).Where("id = ?", earlier.ID).Count(&count)
+	testutil.AssertEqual(t, int64(0), count)
+
+	db.Model(&models.AuditLog{}).Where("entity_type = ? AND action = ?", models.AuditEntityRequest, models.ActionRestored).Count(&count)
+	testutil.AssertEqual(t, int64(2), count)
+
+	// The deletion and the restore are on the user's history
+	var actions []models.AuditAction
+	db.Model(&models.AuditLog{}).Where("entity_type = ? AND entity_id = ?", models.AuditEntityUser, user.ID).Order("id").Pluck("action", &actions)
+	testutil.AssertEqual(t, 2, len(actions))
+	testutil.AssertEqual(t, models.ActionDeleted, actions[0])
+	testutil.AssertEqual(t, models.ActionRestored, actions[1])
+}
+
+func TestTrashHandler_PurgeRequest(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	db := testutil.SetupTestDB(t)
+	auditService := services.NewAuditService(db)
+	handler := NewTrashHandler(db, auditService)
+
+	admin := testutil.CreateTestUser(t, db, "admin@example.com", "admin", "pass", true)
+	user := testutil.CreateTestUser(t, db, "user@example.com", "user", "pass", false)
+
+	active := testutil.CreateTestRequest(t, db, user.ID, "Active Request", models.MediaTypeMovie)
+	deleted := testutil.CreateTestRequest(t, db, user.ID, "Deleted Request", models.MediaTypeMovie)
+	auditService.LogRequestCreated(deleted.ID, user.ID)
+	db.Create(&models.RequestComment{RequestID: deleted.ID, UserID: user.ID, Body: "Please add this"})
+	db.Delete(deleted)
+
+	router := newTestRouter(admin.ID, true)
+	router.DELETE("/trash/requests/:id", handler.PurgeRequest)
+
+	// Active requests must be deleted before they can be purged
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", fmt.Sprintf("/trash/requests/%d", active.ID), nil)
+	router.ServeHTTP(w, req)
+	testutil.AssertEqual(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("DELETE", fmt.Sprintf("/trash/requests/%d", deleted.ID), nil)
+	router.ServeHTTP(w, req)
+	testutil.AssertEqual(t, http.StatusOK, w.Code)
+
+	var count int64
+	db.Unscoped().Model(&models.Request{}).Where("id = ?", deleted.ID).Count(&count)
+	testutil.AssertEqual(t, int64(0), count)
+	db.Model(&models.RequestComment{}).Where("request_id = ?", deleted.ID).Count(&count)
+	testutil.AssertEqual(t, int64(0), count)
+	db.Model(&models.AuditLog{}).Where("request_id = ?", deleted.ID).Count(&count)
+	testutil.AssertEqual(t, int64(0), count)
+
+	// The purge itself is recorded against the request ID
+	var purged models.AuditLog
+	testutil.AssertNoError(t, db.Where("entity_type = ? AND entity_id = ? AND action = ?", models.AuditEntityRequest, deleted.ID, models.ActionPurged).First(&purged).Error)
+	testutil.AssertTrue(t, purged.RequestID == nil, "purge entry should not reference the purged request")
+	testutil.AssertEqual(t, admin.ID, *purged.UserID)
+
+	// Purging hides the request's history behind a marker entry and keeps the
+	// audit log hash chain intact
+	var marker models.AuditLog
+	testutil.AssertNoError(t, db.Where("entity_type = ?", models.AuditEntityAuditLog).First(&marker).Error)
+	testutil.AssertEqual(t, admin.ID, *marker.UserID)
+
+	report, err := auditService.VerifyChain(nil)
+	testutil.AssertNoError(t, err)
+	testutil.AssertTrue(t, report.Valid, "audit chain should stay valid")
+	testutil.AssertEqual(t, int64(1), report.Deleted)
+}
+
+func TestTrashHandler_PurgeUser(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	db := testutil.SetupTestDB(t)
+	auditService := services.NewAuditService(db)
+	handler := NewTrashHandler(db, auditService)
+
+	admin := testutil.CreateTestUser(t, db, "admin@example.com", "admin", "pass", true)
+	user := testutil.CreateTestUser(t, db, "user@example.com", "user", "pass", false)
+	other := testutil.CreateTestUser(t, db, "other@example.com", "other", "pass", false)
+
+	own := testutil.CreateTestRequest(t, db, user.ID, "Own Request", models.MediaTypeMovie)
+	otherRequest := testutil.CreateTestRequest(t, db, other.ID, "Other Request", models.MediaTypeMovie)
+
+	// The user commented on and changed another user's request
+	db.Create(&models.RequestComment{RequestID: otherRequest.ID, UserID: user.ID, Body: "Me too"})
+	diff := services.NewAuditDiff()
+	diff.Add("notes", "", "Me too")
+	auditService.LogRequestUpdate(otherRequest.ID, &user.ID, diff)
+
+	db.Delete(own)
+	db.Delete(user)
+
+	router := newTestRouter(admin.ID, true)
+	router.DELETE("/trash/users/:id", handler.PurgeUser)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", fmt.Sprintf("/trash/users/%d", user.ID), nil)
+	router.ServeHTTP(w, req)
+	testutil.AssertEqual(t, http.StatusOK, w.Code)
+
+	var count int64
+	db.Unscoped().Model(&models.User{}).Where("id = ?", user.ID).Count(&count)
+	testutil.AssertEqual(t, int64(0), count)
+	db.Unscoped().Model(&models.Request{}).Where("user_id = ?", user.ID).Count(&count)
+	testutil.AssertEqual(t, int64(0), count)
+	db.Model(&models.RequestComment{}).Where("user_id = ?", user.ID).Count(&count)
+	testutil.AssertEqual(t, int64(0), count)
+
+	// History on other requests is kept without the actor
+	var auditLog models.AuditLog
+	testutil.AssertNoError(t, db.Where("request_id = ?", otherRequest.ID).First(&auditLog).Error)
+	testutil.AssertTrue(t, auditLog.UserID == nil, "actor should be cleared")
+
+	var purged models.AuditLog
+	testutil.AssertNoError(t, db.Where("entity_type = ? AND entity_id = ? AND action = ?", models.AuditEntityUser, user.ID, models.ActionPurged).First(&purged).Error)
+	testutil.AssertEqual(t, admin.ID, *purged.UserID)
+
+	report, err := auditService.VerifyChain(nil)
+	testutil.AssertNoError(t, err)
+	testutil.AssertTrue(t, report.Valid, "audit chain should stay valid")
+	testutil.AssertEqual(t, int64(1), report.Redacted)
+
+	// Purging an active user is not allowed
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("DELETE", fmt.Sprintf("/trash/users/%d", other.ID), nil)
+	router.ServeHTTP(w, req)
+	testutil.AssertEqual(t, http.StatusNotFound, w.Code)
+}
+
+func TestTrashHandler_PurgeUser_RolledBackWithoutPurgeEntry(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name  string
+		setup func(db *gorm.DB) *services.AuditService
+	}{
+		{
+			name: "purge entry fails to append",
+			setup: func(db *gorm.DB) *services.AuditService {
+				db.Callback().Create().Before("gorm:create").Register("fail_purge_entry", func(tx *gorm.DB) {
+					if log, ok := tx.Statement.Dest.(*models.AuditLog); ok && log.Action == models.ActionPurged {
+						tx.AddError(errors.New("audit log unavailable"))
+					}
+				})
+				return services.NewAuditService(db)
+			},
+		},
+		{
+			name:  "no audit service",
+			setup: func(db *gorm.DB) *services.AuditService { return nil },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := testutil.SetupTestDB(t)
+			auditService := services.NewAuditService(db)
+
+			admin := testutil.CreateTestUser(t, db, "admin@example.com", "admin", "pass", true)
+			user := testutil.CreateTestUser(t, db, "user@example.com", "user", "pass", false)
+			testutil.AssertNoError(t, auditService.LogUserLogin(user.ID))
+			db.Delete(user)
+
+			router := newTestRouter(admin.ID, true)
+			router.DELETE("/trash/users/:id", NewTrashHandler(db, tt.setup(db)).PurgeUser)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("DELETE", fmt.Sprintf("/trash/users/%d", user.ID), nil)
+			router.ServeHTTP(w, req)
+			testutil.AssertEqual(t, http.StatusInternalServerError, w.Code)
+
+			// Nothing was purged or redacted
+			var count int64
+			db.Unscoped().Model(&models.User{}).Where("id = ?", user.ID).Count(&count)
+			testutil.AssertEqual(t, int64(1), count)
+			db.Model(&models.AuditLog{}).Where("user_id = ?", user.ID).Count(&count)
+			testutil.AssertEqual(t, int64(1), count)
+
+			report, err := auditService.VerifyChain(nil)
+			testutil.AssertNoError(t, err)
+			testutil.AssertTrue(t, report.Valid, "audit chain should stay valid")
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/jacob-fain/MRS/internal/services"
+)
+
+// upstreamErrorStatus returns 503 when an upstream API is rate limiting us or
+// unavailable, so clients know to retry later, 504 when the request deadline
+// passed, and 500 for everything else
+func upstreamErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, services.ErrUpstreamRateLimited), errors.Is(err, services.ErrUpstreamUnavailable):
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
@@ -2,35 +2,57 @@ package handlers
 
 import (
 	"errors"
+	"log"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jacob-fain/MRS/internal/models"
+	"github.com/jacob-fain/MRS/internal/services"
 	"gorm.io/gorm"
 )
 
 type userHandler struct {
-	db *gorm.DB
+	db           *gorm.DB
+	auditService *services.AuditService
 }
 
 // NewUserHandler creates a new user handler
-func NewUserHandler(db *gorm.DB) *userHandler {
-	return &userHandler{db: db}
+func NewUserHandler(db *gorm.DB, auditService *services.AuditService) *userHandler {
+	return &userHandler{
+		db:           db,
+		auditService: auditService,
+	}
 }
 
 // UserResponse represents a user in API responses
 type UserResponse struct {
-	ID       uint   `json:"id"`
-	Email    string `json:"email"`
-	Username string `json:"username"`
-	IsAdmin  bool   `json:"is_admin"`
-	CreatedAt string `json:"created_at"`
+	ID           uint   `json:"id"`
+	Email        string `json:"email"`
+	Username     string `json:"username"`
+	IsAdmin      bool   `json:"is_admin"`
+	CreatedAt    string `json:"created_at"`
+	IncludeAdult bool   `json:"include_adult"`
+}
+
+// toUserResponse converts a user model to its admin response format
+func toUserResponse(user models.User) UserResponse {
+	return UserResponse{
+		ID:           user.ID,
+		Email:        user.Email,
+		Username:     user.Username,
+		IsAdmin:      user.IsAdmin,
+		CreatedAt:    user.CreatedAt.Format("2006-01-02T15:04:05Z"),
+		IncludeAdult: user.IncludeAdult,
+	}
 }
 
 // UpdateUserInput represents the user update payload
 type UpdateUserInput struct {
 	IsAdmin *bool `json:"is_admin"`
+	// Content restriction, only admins can change it
+	IncludeAdult *bool `json:"include_adult"`
 }
 
 // GetUsers returns all users with their request counts (admin only)
@@ -65,13 +87,7 @@ func (h *userHandler) GetUsers(c *gin.Context) {
 		h.db.Model(&models.Request{}).Where("user_id = ?", user.ID).Count(&requestCount)
 
 		usersWithCounts[i] = UserWithCount{
-			UserResponse: UserResponse{
-				ID:       user.ID,
-				Email:    user.Email,
-				Username: user.Username,
-				IsAdmin:  user.IsAdmin,
-				CreatedAt: user.CreatedAt.Format("2006-01-02T15:04:05Z"),
-			},
+			UserResponse: toUserResponse(user),
 			RequestCount: requestCount,
 		}
 	}
@@ -119,18 +135,13 @@ func (h *userHandler) GetUser(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, UserResponse{
-		ID:       user.ID,
-		Email:    user.Email,
-		Username: user.Username,
-		IsAdmin:  user.IsAdmin,
-		CreatedAt: user.CreatedAt.Format("2006-01-02T15:04:05Z"),
-	})
+	c.JSON(http.StatusOK, toUserResponse(user))
 }
 
 // UpdateUser updates a user (admin only)
 // @Summary Update a user
-// @Description Update user details (admin only - currently only admin status)
+// @Description Update a user's admin status and adult content restriction (admin only).
+// @Description include_adult applies to the user's TMDB results and can't be changed by the user.
 // @Tags users
 // @Accept json
 // @Produce json
@@ -152,15 +163,6 @@ func (h *userHandler) UpdateUser(c *gin.Context) {
 		return
 	}
 
-	// Prevent user from modifying themselves
-	currentUserID, _ := c.Get("userID")
-	if uint(userID) == currentUserID.(uint) {
-		c.JSON(http.StatusForbidden, gin.H{
-			"error": "You cannot modify your own admin status",
-		})
-		return
-	}
-
 	var user models.User
 	if err := h.db.First(&user, userID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -183,10 +185,27 @@ func (h *userHandler) UpdateUser(c *gin.Context) {
 		return
 	}
 
-	// Update user
+	// Prevent user from modifying themselves
+	currentUserID, _ := c.Get("userID")
+	if input.IsAdmin != nil && uint(userID) == currentUserID.(uint) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "You cannot modify your own admin status",
+		})
+		return
+	}
+
+	// Update user, tracking old values for audit logging
 	updates := make(map[string]interface{})
+	diff := services.NewAuditDiff()
 	if input.IsAdmin != nil {
 		updates["is_admin"] = *input.IsAdmin
+		diff.Add("is_admin", user.IsAdmin, *input.IsAdmin)
+		user.IsAdmin = *input.IsAdmin
+	}
+	if input.IncludeAdult != nil {
+		updates["include_adult"] = *input.IncludeAdult
+		diff.Add("include_adult", user.IncludeAdult, *input.IncludeAdult)
+		user.IncludeAdult = *input.IncludeAdult
 	}
 
 	if len(updates) == 0 {
@@ -203,13 +222,13 @@ func (h *userHandler) UpdateUser(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, UserResponse{
-		ID:       user.ID,
-		Email:    user.Email,
-		Username: user.Username,
-		IsAdmin:  user.IsAdmin,
-		CreatedAt: user.CreatedAt.Format("2006-01-02T15:04:05Z"),
-	})
+	if audit := auditFor(c, h.auditService); audit != nil {
+		if err := audit.LogUserUpdate(user.ID, currentUserID.(uint), diff); err != nil {
+			log.Printf("Failed to log audit entry for update of user %d: %v", user.ID, err)
+		}
+	}
+
+	c.JSON(http.StatusOK, toUserResponse(user))
 }
 
 // DeleteUser deletes a user (admin only)
@@ -258,15 +277,20 @@ func (h *userHandler) DeleteUser(c *gin.Context) {
 		return
 	}
 
-	// Delete user and their requests in a transaction for atomicity
+	// Delete user and their requests in a transaction for atomicity. Both share
+	// one deletion timestamp so a restore brings back exactly the cascaded requests.
+	deletedAt := time.Now()
+	var deletedRequests int64
 	err = h.db.Transaction(func(tx *gorm.DB) error {
 		// Delete user's requests first (cascade delete)
-		if err := tx.Where("user_id = ?", userID).Delete(&models.Request{}).Error; err != nil {
-			return err
+		result := tx.Model(&models.Request{}).Where("user_id = ?", userID).Update("deleted_at", deletedAt)
+		if result.Error != nil {
+			return result.Error
 		}
+		deletedRequests = result.RowsAffected
 
 		// Delete user
-		if err := tx.Delete(&user).Error; err != nil {
+		if err := tx.Model(&user).Update("deleted_at", deletedAt).Error; err != nil {
 			return err
 		}
 
@@ -280,6 +304,12 @@ func (h *userHandler) DeleteUser(c *gin.Context) {
 		return
 	}
 
+	if audit := auditFor(c, h.auditService); audit != nil {
+		if err := audit.LogUserDeleted(user.ID, currentUserID.(uint), user.Username, int(deletedRequests)); err != nil {
+			log.Printf("Failed to log audit entry for user deletion (ID: %d): %v", user.ID, err)
+		}
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "User deleted successfully",
 	})
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jacob-fain/MRS/internal/models"
+	"github.com/jacob-fain/MRS/internal/services"
+	"github.com/jacob-fain/MRS/internal/testutil"
+)
+
+func TestUserHandler_UpdateUser_LogsAdminChanges(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := testutil.SetupTestDB(t)
+	handler := NewUserHandler(db, services.NewAuditService(db))
+
+	admin := testutil.CreateTestUser(t, db, "admin@example.com", "admin", "pass", true)
+	user := testutil.CreateTestUser(t, db, "user@example.com", "user", "pass", false)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", admin.ID)
+		c.Set("isAdmin", true)
+	})
+	router.PUT("/users/:id", handler.UpdateUser)
+
+	update := func(body string) {
+		req, _ := http.NewRequest("PUT", fmt.Sprintf("/users/%d", user.ID), bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		testutil.AssertEqual(t, http.StatusOK, w.Code)
+	}
+
+	update(`{"is_admin": true}`)
+	// Setting the same value again isn't a change
+	update(`{"is_admin": true}`)
+	update(`{"is_admin": false}`)
+
+	var logs []models.AuditLog
+	testutil.AssertNoError(t, db.Where("entity_type = ? AND entity_id = ?", models.AuditEntityUser, user.ID).Order("id").Find(&logs).Error)
+	testutil.AssertEqual(t, 2, len(logs))
+	testutil.AssertEqual(t, models.ActionAdminGranted, logs[0].Action)
+	testutil.AssertEqual(t, `{"is_admin":false}`, logs[0].OldValue)
+	testutil.AssertEqual(t, `{"is_admin":true}`, logs[0].NewValue)
+	testutil.AssertEqual(t, admin.ID, *logs[0].UserID)
+	testutil.AssertEqual(t, models.ActionAdminRevoked, logs[1].Action)
+}
+
+func TestUserHandler_UpdateUser_ContentRestrictions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := testutil.SetupTestDB(t)
+	handler := NewUserHandler(db, services.NewAuditService(db))
+
+	admin := testutil.CreateTestUser(t, db, "admin@example.com", "admin", "pass", true)
+	child := testutil.CreateTestUser(t, db, "child@example.com", "child", "pass", false)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", admin.ID)
+		c.Set("isAdmin", true)
+	})
+	router.PUT("/users/:id", handler.UpdateUser)
+
+	update := func(userID uint, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("PUT", fmt.Sprintf("/users/%d", userID), bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := update(child.ID, `{"include_adult": true}`)
+	testutil.AssertEqual(t, http.StatusOK, w.Code)
+	var response UserResponse
+	testutil.AssertNoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	testutil.AssertEqual(t, true, response.IncludeAdult)
+
+	var stored models.User
+	testutil.AssertNoError(t, db.First(&stored, child.ID).Error)
+	testutil.AssertEqual(t, true, stored.IncludeAdult)
+
+	var log models.AuditLog
+	testutil.AssertNoError(t, db.Where("entity_type = ? AND entity_id = ?", models.AuditEntityUser, child.ID).First(&log).Error)
+	testutil.AssertEqual(t, models.ActionUpdated, log.Action)
+	testutil.AssertEqual(t, `{"include_adult":true}`, log.NewValue)
+
+	// The discover certification is the user's own preference
+	testutil.AssertEqual(t, http.StatusBadRequest, update(child.ID, `{"max_certification": "PG"}`).Code)
+
+	// Admins can set their own restrictions, but not their own admin status
+	testutil.AssertEqual(t, http.StatusOK, update(admin.ID, `{"include_adult": true}`).Code)
+	testutil.AssertEqual(t, http.StatusForbidden, update(admin.ID, `{"is_admin": false}`).Code)
+}
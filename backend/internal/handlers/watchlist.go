@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jacob-fain/MRS/internal/models"
+	"gorm.io/gorm"
+)
+
+type watchlistHandler struct {
+	db *gorm.DB
+}
+
+// NewWatchlistHandler creates a new watchlist handler
+func NewWatchlistHandler(db *gorm.DB) *watchlistHandler {
+	return &watchlistHandler{
+		db: db,
+	}
+}
+
+// AddToWatchlistInput represents the watchlist payload
+type AddToWatchlistInput struct {
+	Title       string           `json:"title" binding:"required"`
+	Year        int              `json:"year"`
+	MediaType   models.MediaType `json:"media_type" binding:"required,oneof=movie tv"`
+	TMDBId      int              `json:"tmdb_id" binding:"required"`
+	Overview    string           `json:"overview"`
+	PosterPath  string           `json:"poster_path"`
+	ReleaseDate string           `json:"release_date"`
+}
+
+// GetWatchlist returns the current user's watchlist
+// @Summary Get watchlist
+// @Description Get the titles the current user is waiting for, including the ones already turned into requests
+// @Tags watchlist
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {object} map[string]string
+// @Router /watchlist [get]
+func (h *watchlistHandler) GetWatchlist(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var items []models.WatchlistItem
+	if err := h.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&items).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch watchlist",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"items": items,
+		"count": len(items),
+	})
+}
+
+// AddToWatchlist saves an unreleased title. It is requested automatically once released.
+// @Summary Add to watchlist
+// @Description Save an upcoming title, a request is created for it once a digital or physical release exists
+// @Tags watchlist
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param item body AddToWatchlistInput true "Title details"
+// @Success 201 {object} models.WatchlistItem
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /watchlist [post]
+func (h *watchlistHandler) AddToWatchlist(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var input AddToWatchlistInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid watchlist data",
+		})
+		return
+	}
+
+	var count int64
+	h.db.Model(&models.WatchlistItem{}).
+		Where("user_id = ? AND media_type = ? AND tmdb_id = ?", userID, input.MediaType, input.TMDBId).
+		Count(&count)
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "This title is already on your watchlist",
+		})
+		return
+	}
+
+	h.db.Model(&models.Request{}).
+		Where("user_id = ? AND media_type = ? AND (tmdb_id = ? OR title = ?)", userID, input.MediaType, input.TMDBId, input.Title).
+		Count(&count)
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "You already have a request for this media",
+		})
+		return
+	}
+
+	item := models.WatchlistItem{
+		UserID:      userID.(uint),
+		Title:       input.Title,
+		Year:        input.Year,
+		MediaType:   input.MediaType,
+		TMDBId:      input.TMDBId,
+		Overview:    input.Overview,
+		PosterPath:  input.PosterPath,
+		ReleaseDate: input.ReleaseDate,
+	}
+	if err := h.db.Create(&item).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to add to watchlist",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, item)
+}
+
+// RemoveFromWatchlist removes a title from the current user's watchlist
+// @Summary Remove from watchlist
+// @Description Remove a title from the current user's watchlist. Requests it was turned into are kept.
+// @Tags watchlist
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Watchlist item ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /watchlist/{id} [delete]
+func (h *watchlistHandler) RemoveFromWatchlist(c *gin.Context) {
+	itemID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid watchlist item ID",
+		})
+		return
+	}
+
+	userID, _ := c.Get("userID")
+
+	// Users only see their own items, so other items are reported as missing
+	var item models.WatchlistItem
+	if err := h.db.Where("user_id = ?", userID).First(&item, itemID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Watchlist item not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to find watchlist item",
+			})
+		}
+		return
+	}
+
+	if err := h.db.Delete(&item).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to remove from watchlist",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Removed from watchlist",
+	})
+}
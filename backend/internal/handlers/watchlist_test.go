@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jacob-fain/MRS/internal/models"
+	"github.com/jacob-fain/MRS/internal/testutil"
+)
+
+func TestWatchlistHandler_AddToWatchlist(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, db, "user@example.com", "testuser", "hashedpass", false)
+	testutil.CreateTestRequest(t, db, user.ID, "Dune", models.MediaTypeMovie)
+
+	handler := NewWatchlistHandler(db)
+	router := newTestRouter(user.ID, user.IsAdmin)
+	router.GET("/watchlist", handler.GetWatchlist)
+	router.POST("/watchlist", handler.AddToWatchlist)
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+	}{
+		{
+			name:           "adds upcoming title",
+			body:           `{"title": "Dune: Part Three", "year": 2026, "media_type": "movie", "tmdb_id": 1170608, "release_date": "2026-12-18"}`,
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "already on the watchlist",
+			body:           `{"title": "Dune: Part Three", "media_type": "movie", "tmdb_id": 1170608}`,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "already requested",
+			body:           `{"title": "Dune", "media_type": "movie", "tmdb_id": 438631}`,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "missing TMDB ID",
+			body:           `{"title": "Untitled", "media_type": "movie"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("POST", "/watchlist", bytes.NewBufferString(tt.body))
+			testutil.AssertNoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			testutil.AssertEqual(t, tt.expectedStatus, w.Code)
+		})
+	}
+
+	req, err := http.NewRequest("GET", "/watchlist", nil)
+	testutil.AssertNoError(t, err)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	testutil.AssertEqual(t, http.StatusOK, w.Code)
+
+	var response map[string]interface{}
+	testutil.AssertNoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	testutil.AssertEqual(t, float64(1), response["count"])
+	item := response["items"].([]interface{})[0].(map[string]interface{})
+	testutil.AssertEqual(t, "Dune: Part Three", item["title"])
+	testutil.AssertEqual(t, "2026-12-18", item["release_date"])
+}
+
+func TestWatchlistHandler_RemoveFromWatchlist(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, db, "user@example.com", "testuser", "hashedpass", false)
+	other := testutil.CreateTestUser(t, db, "other@example.com", "other", "hashedpass", false)
+
+	own := models.WatchlistItem{UserID: user.ID, Title: "Dune: Part Three", MediaType: models.MediaTypeMovie, TMDBId: 1170608}
+	theirs := models.WatchlistItem{UserID: other.ID, Title: "Dune: Part Three", MediaType: models.MediaTypeMovie, TMDBId: 1170608}
+	testutil.AssertNoError(t, db.Create(&own).Error)
+	testutil.AssertNoError(t, db.Create(&theirs).Error)
+
+	router := newTestRouter(user.ID, user.IsAdmin)
+	router.DELETE("/watchlist/:id", NewWatchlistHandler(db).RemoveFromWatchlist)
+
+	tests := []struct {
+		name           string
+		id             string
+		expectedStatus int
+	}{
+		{"own item", fmt.Sprint(own.ID), http.StatusOK},
+		{"other user's item", fmt.Sprint(theirs.ID), http.StatusNotFound},
+		{"invalid ID", "abc", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("DELETE", "/watchlist/"+tt.id, nil)
+			testutil.AssertNoError(t, err)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			testutil.AssertEqual(t, tt.expectedStatus, w.Code)
+		})
+	}
+
+	var count int64
+	db.Model(&models.WatchlistItem{}).Count(&count)
+	testutil.AssertEqual(t, int64(1), count)
+}
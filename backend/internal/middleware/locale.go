@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jacob-fain/MRS/internal/models"
+	"github.com/jacob-fain/MRS/internal/services"
+	"gorm.io/gorm"
+)
+
+// TMDBLocale loads the authenticated user's language, region and content
+// preferences and puts them in the request context for TMDB calls
+func TMDBLocale(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userID")
+		if !exists {
+			c.Next()
+			return
+		}
+
+		var user models.User
+		err := db.Select("id", "language", "region", "include_adult", "max_certification").
+			First(&user, userID).Error
+		if err != nil {
+			// TMDB calls still work with the server defaults
+			log.Printf("Failed to load TMDB preferences for user %v: %v", userID, err)
+			c.Next()
+			return
+		}
+
+		ctx := services.WithTMDBLocale(c.Request.Context(), services.TMDBLocale{
+			Language:         user.Language,
+			Region:           user.Region,
+			IncludeAdult:     user.IncludeAdult,
+			MaxCertification: user.MaxCertification,
+		})
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
@@ -15,22 +15,48 @@ const (
 	ActionRejected      AuditAction = "rejected"
 	ActionCompleted     AuditAction = "completed"
 	ActionNotesUpdated  AuditAction = "notes_updated"
+	ActionUpdated       AuditAction = "updated"
 	ActionDeleted       AuditAction = "deleted"
 	ActionStatusChanged AuditAction = "status_changed"
+	ActionCommented     AuditAction = "commented"
+	ActionReminderSent  AuditAction = "reminder_sent"
+	ActionRestored      AuditAction = "restored"
+	ActionPurged        AuditAction = "purged"
+	ActionRegistered    AuditAction = "registered"
+	ActionLogin         AuditAction = "login"
+	ActionLoginFailed   AuditAction = "login_failed"
+	ActionAdminGranted  AuditAction = "admin_granted"
+	ActionAdminRevoked  AuditAction = "admin_revoked"
 )
 
-// AuditLog represents an audit log entry for request changes
+// AuditEntityType is the kind of record an audit log entry is about
+type AuditEntityType string
+
+const (
+	AuditEntityRequest  AuditEntityType = "request"
+	AuditEntityUser     AuditEntityType = "user"
+	AuditEntityAuditLog AuditEntityType = "audit_log" // Marker entries listing hidden audit log entries
+)
+
+// AuditLog represents an audit log entry for a change to a request, a user or
+// another entity
 type AuditLog struct {
-	ID         uint           `gorm:"primaryKey" json:"id"`
-	RequestID  uint           `gorm:"not null;index" json:"request_id"`
-	Request    *Request       `gorm:"foreignKey:RequestID" json:"request,omitempty"`
-	UserID     *uint          `gorm:"index" json:"user_id"` // Nullable for system actions
-	User       *User          `gorm:"foreignKey:UserID" json:"user,omitempty"`
-	Action     AuditAction    `gorm:"type:varchar(50);not null" json:"action"`
-	OldValue   string         `gorm:"type:text" json:"old_value,omitempty"` // JSON string
-	NewValue   string         `gorm:"type:text" json:"new_value,omitempty"` // JSON string
-	Notes      string         `gorm:"type:text" json:"notes,omitempty"`     // Human-readable description
-	CreatedAt  time.Time      `json:"created_at"`
-	UpdatedAt  time.Time      `json:"updated_at"`
-	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`
+	ID         uint            `gorm:"primaryKey" json:"id"`
+	EntityType AuditEntityType `gorm:"type:varchar(50);not null;default:request;index:idx_audit_logs_entity" json:"entity_type"`
+	EntityID   uint            `gorm:"not null;default:0;index:idx_audit_logs_entity" json:"entity_id"`
+	RequestID  *uint           `gorm:"index" json:"request_id"` // Set for live requests, null once purged or for other entities
+	Request    *Request        `gorm:"foreignKey:RequestID" json:"request,omitempty"`
+	UserID     *uint           `gorm:"index" json:"user_id"` // Nullable for system actions
+	User       *User           `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	Action     AuditAction     `gorm:"type:varchar(50);not null" json:"action"`
+	IPAddress  string          `gorm:"type:varchar(45)" json:"ip_address,omitempty"`
+	UserAgent  string          `gorm:"type:text" json:"user_agent,omitempty"`
+	OldValue   string          `gorm:"type:text" json:"old_value,omitempty"`        // JSON string
+	NewValue   string          `gorm:"type:text" json:"new_value,omitempty"`        // JSON string
+	Notes      string          `gorm:"type:text" json:"notes,omitempty"`            // Human-readable description
+	PrevHash   string          `gorm:"type:varchar(64)" json:"prev_hash,omitempty"` // Hash of the previous entry
+	Hash       string          `gorm:"type:varchar(64)" json:"hash,omitempty"`      // HMAC-SHA256 of the content and PrevHash
+	CreatedAt  time.Time       `json:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt  `gorm:"index" json:"-"`
 }
@@ -43,4 +43,8 @@ type Request struct {
 	Status      RequestStatus `json:"status" gorm:"default:'pending'"`
 	Notes       string        `json:"notes" gorm:"type:text"`
 	AdminNotes  string        `json:"admin_notes" gorm:"type:text"`
+
+	ReminderSentAt *time.Time `json:"reminder_sent_at,omitempty"` // Set when admins were reminded about a stale pending request
+
+	Genres []RequestGenre `json:"-" gorm:"foreignKey:RequestID"` // TMDB genres, used for statistics
 }
\ No newline at end of file
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RequestComment represents a message in a request's discussion thread
+type RequestComment struct {
+	ID        uint           `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+
+	RequestID uint     `json:"request_id" gorm:"not null;index"`
+	Request   *Request `json:"request,omitempty" gorm:"foreignKey:RequestID"`
+	UserID    uint     `json:"user_id" gorm:"not null;index"`
+	User      User     `json:"user,omitempty" gorm:"foreignKey:UserID"`
+
+	Body     string `json:"body" gorm:"type:text;not null"`
+	Internal bool   `json:"internal" gorm:"default:false"` // Only visible to admins
+}
@@ -0,0 +1,7 @@
+package models
+
+// RequestGenre links a request to one of its TMDB genres
+type RequestGenre struct {
+	RequestID uint `json:"request_id" gorm:"primaryKey"`
+	GenreID   int  `json:"genre_id" gorm:"primaryKey;index"`
+}
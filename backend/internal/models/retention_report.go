@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+)
+
+// RetentionReport records the outcome of a single retention policy run
+type RetentionReport struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Policy    string    `gorm:"type:varchar(100);not null;index" json:"policy"`
+	Target    string    `gorm:"type:varchar(50);not null" json:"target"`
+	Cutoff    time.Time `json:"cutoff"`
+	Matched   int64     `json:"matched"`
+	Purged    int64     `json:"purged"`
+	DryRun    bool      `json:"dry_run"`
+	Error     string    `gorm:"type:text" json:"error,omitempty"`
+	RecordIDs string    `gorm:"type:text" json:"record_ids,omitempty"` // JSON array of affected IDs, the first 1000 when more matched
+	CreatedAt time.Time `json:"created_at"`
+}
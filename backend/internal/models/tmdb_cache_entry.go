@@ -0,0 +1,14 @@
+package models
+
+import (
+	"time"
+)
+
+// TMDBCacheEntry stores a cached TMDB response so the cache survives restarts
+type TMDBCacheEntry struct {
+	Key       string    `gorm:"column:cache_key;primaryKey;type:varchar(255)" json:"key"`
+	Value     string    `gorm:"type:text;not null" json:"-"` // JSON encoded response
+	ExpiresAt time.Time `gorm:"not null;index" json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -16,6 +16,12 @@ type User struct {
 	Username string `json:"username" gorm:"uniqueIndex;not null"`
 	Password string `json:"-" gorm:"not null"`
 	IsAdmin  bool   `json:"is_admin" gorm:"default:false"`
+
+	// TMDB preferences, empty values use the server defaults
+	Language         string `json:"language" gorm:"size:10"`
+	Region           string `json:"region" gorm:"size:2"`
+	IncludeAdult     bool   `json:"include_adult" gorm:"default:false"`
+	MaxCertification string `json:"max_certification" gorm:"size:10"`
 	
 	Requests []Request `json:"requests,omitempty" gorm:"foreignKey:UserID"`
 }
\ No newline at end of file
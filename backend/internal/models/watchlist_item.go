@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+)
+
+// WatchlistItem is an unreleased title a user is waiting for. The watchlist job
+// turns it into a request once a digital or physical release exists.
+type WatchlistItem struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	UserID uint `json:"user_id" gorm:"not null;uniqueIndex:idx_watchlist_user_title"`
+	User   User `json:"-" gorm:"foreignKey:UserID"`
+
+	Title      string    `json:"title" gorm:"not null"`
+	Year       int       `json:"year"`
+	MediaType  MediaType `json:"media_type" gorm:"not null;uniqueIndex:idx_watchlist_user_title"`
+	TMDBId     int       `json:"tmdb_id" gorm:"not null;uniqueIndex:idx_watchlist_user_title"`
+	Overview   string    `json:"overview" gorm:"type:text"`
+	PosterPath string    `json:"poster_path"`
+
+	ReleaseDate   string     `json:"release_date,omitempty"`    // Digital or physical release (first air date for TV), once known
+	LastCheckedAt *time.Time `json:"last_checked_at,omitempty"` // Last time the watchlist job checked TMDB
+	RequestID     *uint      `json:"request_id,omitempty" gorm:"index"`
+	ConvertedAt   *time.Time `json:"converted_at,omitempty"` // Set when the item was turned into a request
+}
@@ -2,94 +2,283 @@ package services
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
+	"strings"
 
 	"github.com/jacob-fain/MRS/internal/models"
 	"gorm.io/gorm"
 )
 
-// AuditService handles audit logging for requests
+// maxAuditUserAgentLength caps the user agent stored with each entry
+const maxAuditUserAgentLength = 512
+
+// ErrAuditRequired is returned by changes that must be recorded in the audit log
+// when no audit service is configured
+var ErrAuditRequired = errors.New("audit logging is required")
+
+// AuditService handles audit logging for requests, users and auth events
 type AuditService struct {
-	db *gorm.DB
+	db        *gorm.DB
+	key       []byte // Hash chain key
+	ipAddress string
+	userAgent string
 }
 
-// NewAuditService creates a new audit service
+// NewAuditService creates a new audit service. Entries are hash-chained with
+// the AUDIT_HASH_KEY secret.
 func NewAuditService(db *gorm.DB) *AuditService {
-	return &AuditService{db: db}
+	return &AuditService{db: db, key: []byte(os.Getenv("AUDIT_HASH_KEY"))}
+}
+
+// WithClient returns a copy of the service that records the IP address and user
+// agent of the client behind the logged actions. A nil service stays nil.
+func (s *AuditService) WithClient(ipAddress, userAgent string) *AuditService {
+	if s == nil {
+		return nil
+	}
+	if len(userAgent) > maxAuditUserAgentLength {
+		userAgent = userAgent[:maxAuditUserAgentLength]
+	}
+	client := *s
+	client.ipAddress = ipAddress
+	client.userAgent = userAgent
+	return &client
+}
+
+// create saves an entry with the client details, chained to the previous entry
+func (s *AuditService) create(log *models.AuditLog) error {
+	log.IPAddress = s.ipAddress
+	log.UserAgent = s.userAgent
+	return appendAuditLog(s.db, s.key, log)
+}
+
+// createWithDiff saves an entry with the old and new values of the changed fields
+func (s *AuditService) createWithDiff(log *models.AuditLog, diff *AuditDiff) error {
+	if !diff.Empty() {
+		log.OldValue, log.NewValue = diff.values()
+	}
+	return s.create(log)
+}
+
+// requestLog builds an entry about a request
+func requestLog(requestID uint, userID *uint, action models.AuditAction, notes string) models.AuditLog {
+	return models.AuditLog{
+		EntityType: models.AuditEntityRequest,
+		EntityID:   requestID,
+		RequestID:  &requestID,
+		UserID:     userID,
+		Action:     action,
+		Notes:      notes,
+	}
+}
+
+// userLog builds an entry about a user account
+func userLog(subjectID uint, actorID *uint, action models.AuditAction, notes string) models.AuditLog {
+	return models.AuditLog{
+		EntityType: models.AuditEntityUser,
+		EntityID:   subjectID,
+		UserID:     actorID,
+		Action:     action,
+		Notes:      notes,
+	}
 }
 
 // LogRequestCreated logs when a request is created
 func (s *AuditService) LogRequestCreated(requestID, userID uint) error {
-	log := models.AuditLog{
-		RequestID: requestID,
-		UserID:    &userID,
-		Action:    models.ActionCreated,
-		Notes:     "Request created",
-	}
-	return s.db.Create(&log).Error
+	log := requestLog(requestID, &userID, models.ActionCreated, "Request created")
+	return s.create(&log)
 }
 
 // LogRequestStatusChange logs when a request status changes
 func (s *AuditService) LogRequestStatusChange(requestID uint, userID *uint, oldStatus, newStatus models.RequestStatus) error {
-	oldValueJSON, _ := json.Marshal(map[string]interface{}{"status": oldStatus})
-	newValueJSON, _ := json.Marshal(map[string]interface{}{"status": newStatus})
-
-	action := models.ActionStatusChanged
-	notes := fmt.Sprintf("Status changed from %s to %s", oldStatus, newStatus)
-
-	// Use more specific actions for common status changes
-	switch newStatus {
-	case models.StatusApproved:
-		action = models.ActionApproved
-		notes = "Request approved"
-	case models.StatusRejected:
-		action = models.ActionRejected
-		notes = "Request rejected"
-	case models.StatusCompleted:
-		action = models.ActionCompleted
-		notes = "Request marked as completed"
+	diff := NewAuditDiff()
+	diff.Add("status", oldStatus, newStatus)
+
+	action, notes := requestStatusAction(oldStatus, newStatus)
+	log := requestLog(requestID, userID, action, notes)
+	return s.createWithDiff(&log, diff)
+}
+
+// LogRequestUpdate logs the fields changed by an update of a request. A status
+// change gives the entry its action (e.g. approved), notes-only edits are
+// notes_updated and anything else is updated. Nothing is logged for an empty diff.
+func (s *AuditService) LogRequestUpdate(requestID uint, userID *uint, diff *AuditDiff) error {
+	if diff.Empty() {
+		return nil
 	}
 
-	log := models.AuditLog{
-		RequestID: requestID,
-		UserID:    userID,
-		Action:    action,
-		OldValue:  string(oldValueJSON),
-		NewValue:  string(newValueJSON),
-		Notes:     notes,
+	action := models.ActionUpdated
+	notes := fmt.Sprintf("Updated %s", strings.Join(diff.Fields(), ", "))
+
+	if change, ok := diff.get("status"); ok {
+		oldStatus, _ := change.Old.(models.RequestStatus)
+		newStatus, _ := change.New.(models.RequestStatus)
+		action, notes = requestStatusAction(oldStatus, newStatus)
+
+		var others []string
+		for _, field := range diff.Fields() {
+			if field != "status" {
+				others = append(others, field)
+			}
+		}
+		if len(others) > 0 {
+			notes = fmt.Sprintf("%s, updated %s", notes, strings.Join(others, ", "))
+		}
+	} else if onlyFields(diff, "notes", "admin_notes") {
+		action = models.ActionNotesUpdated
 	}
-	return s.db.Create(&log).Error
+
+	log := requestLog(requestID, userID, action, notes)
+	return s.createWithDiff(&log, diff)
 }
 
-// LogRequestNotesUpdate logs when request notes are updated
-func (s *AuditService) LogRequestNotesUpdate(requestID uint, userID *uint, fieldName string) error {
-	notes := fmt.Sprintf("%s updated", fieldName)
-	log := models.AuditLog{
-		RequestID: requestID,
-		UserID:    userID,
-		Action:    models.ActionNotesUpdated,
-		Notes:     notes,
+// requestStatusAction picks the action and description of a status change. More
+// specific actions are used for common changes, and an unchanged status records
+// that the request was checked and is still waiting (e.g. reminders).
+func requestStatusAction(oldStatus, newStatus models.RequestStatus) (models.AuditAction, string) {
+	switch {
+	case oldStatus == newStatus:
+		return models.ActionReminderSent, fmt.Sprintf("Request still %s, reminder sent", newStatus)
+	case newStatus == models.StatusApproved:
+		return models.ActionApproved, "Request approved"
+	case newStatus == models.StatusRejected:
+		return models.ActionRejected, "Request rejected"
+	case newStatus == models.StatusCompleted:
+		return models.ActionCompleted, "Request marked as completed"
 	}
-	return s.db.Create(&log).Error
+	return models.ActionStatusChanged, fmt.Sprintf("Status changed from %s to %s", oldStatus, newStatus)
+}
+
+// onlyFields reports whether every changed field is one of fields
+func onlyFields(diff *AuditDiff, fields ...string) bool {
+	for _, changed := range diff.Fields() {
+		found := false
+		for _, field := range fields {
+			if changed == field {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
 }
 
 // LogRequestDeleted logs when a request is deleted
 func (s *AuditService) LogRequestDeleted(requestID, userID uint, title string) error {
 	notes := fmt.Sprintf("Request deleted: %s", title)
-	log := models.AuditLog{
-		RequestID: requestID,
-		UserID:    &userID,
-		Action:    models.ActionDeleted,
-		Notes:     notes,
+	log := requestLog(requestID, &userID, models.ActionDeleted, notes)
+	return s.create(&log)
+}
+
+// LogRequestRestored logs when a soft-deleted request is restored
+func (s *AuditService) LogRequestRestored(requestID, userID uint, notes string) error {
+	log := requestLog(requestID, &userID, models.ActionRestored, notes)
+	return s.create(&log)
+}
+
+// LogRequestPurged logs when a request is permanently deleted. The entry outlives
+// the request, so it only keeps the request ID as the entity.
+func (s *AuditService) LogRequestPurged(requestID, userID uint, title string) error {
+	log := requestLog(requestID, &userID, models.ActionPurged, fmt.Sprintf("Request permanently deleted: %s", title))
+	log.RequestID = nil
+	return s.create(&log)
+}
+
+// LogRequestComment logs when a comment is added to a request
+func (s *AuditService) LogRequestComment(requestID, userID, commentID uint, internal bool) error {
+	newValueJSON, _ := json.Marshal(map[string]interface{}{"comment_id": commentID, "internal": internal})
+
+	notes := "Comment added"
+	if internal {
+		notes = "Internal comment added"
+	}
+
+	log := requestLog(requestID, &userID, models.ActionCommented, notes)
+	log.NewValue = string(newValueJSON)
+	return s.create(&log)
+}
+
+// LogUserRegistered logs when a user creates an account
+func (s *AuditService) LogUserRegistered(userID uint, username string) error {
+	log := userLog(userID, &userID, models.ActionRegistered, fmt.Sprintf("User %s registered", username))
+	return s.create(&log)
+}
+
+// LogUserLogin logs a successful login
+func (s *AuditService) LogUserLogin(userID uint) error {
+	log := userLog(userID, &userID, models.ActionLogin, "Logged in")
+	return s.create(&log)
+}
+
+// LogUserLoginFailed logs a failed login for an email. userID is 0 when no
+// account uses the email. The actor is unknown, so the entry has no user.
+func (s *AuditService) LogUserLoginFailed(userID uint, email string) error {
+	newValueJSON, _ := json.Marshal(map[string]interface{}{"email": email})
+
+	log := userLog(userID, nil, models.ActionLoginFailed, fmt.Sprintf("Failed login for %s", email))
+	log.NewValue = string(newValueJSON)
+	return s.create(&log)
+}
+
+// LogUserUpdate logs the fields an admin changed on a user. Granting or revoking
+// admin rights gets its own action. Nothing is logged for an empty diff.
+func (s *AuditService) LogUserUpdate(userID, adminID uint, diff *AuditDiff) error {
+	if diff.Empty() {
+		return nil
+	}
+
+	action := models.ActionUpdated
+	notes := fmt.Sprintf("Updated %s", strings.Join(diff.Fields(), ", "))
+	if change, ok := diff.get("is_admin"); ok && onlyFields(diff, "is_admin") {
+		action = models.ActionAdminRevoked
+		notes = "Admin rights revoked"
+		if isAdmin, _ := change.New.(bool); isAdmin {
+			action = models.ActionAdminGranted
+			notes = "Admin rights granted"
+		}
+	}
+
+	log := userLog(userID, &adminID, action, notes)
+	return s.createWithDiff(&log, diff)
+}
+
+// LogUserDeleted logs when a user is deleted along with their requests
+func (s *AuditService) LogUserDeleted(userID, adminID uint, username string, requestCount int) error {
+	log := userLog(userID, &adminID, models.ActionDeleted, fmt.Sprintf("User deleted: %s (%d requests)", username, requestCount))
+	return s.create(&log)
+}
+
+// LogUserRestored logs when a deleted user is restored along with their requests
+func (s *AuditService) LogUserRestored(userID, adminID uint, username string, requestCount int) error {
+	log := userLog(userID, &adminID, models.ActionRestored, fmt.Sprintf("User restored: %s (%d requests)", username, requestCount))
+	return s.create(&log)
+}
+
+// LogUserPurged logs when a deleted user is permanently deleted with their
+// requests. It is written within tx, the transaction that clears the user from
+// their audit entries, since VerifyChain needs it to accept those entries.
+func (s *AuditService) LogUserPurged(tx *gorm.DB, userID, adminID uint, username string, requestCount int) error {
+	if s == nil {
+		return ErrAuditRequired
 	}
-	return s.db.Create(&log).Error
+	log := userLog(userID, &adminID, models.ActionPurged, fmt.Sprintf("User permanently deleted: %s (%d requests)", username, requestCount))
+	log.IPAddress = s.ipAddress
+	log.UserAgent = s.userAgent
+	return appendAuditLog(tx, s.key, &log)
 }
 
 // GetRequestAuditLogs retrieves all audit logs for a specific request
 func (s *AuditService) GetRequestAuditLogs(requestID uint) ([]models.AuditLog, error) {
 	var logs []models.AuditLog
 	err := s.db.Where("request_id = ?", requestID).
-		Preload("User").
+		Preload("User", func(db *gorm.DB) *gorm.DB {
+			return db.Unscoped()
+		}).
 		Order("created_at DESC").
 		Find(&logs).Error
 	return logs, err
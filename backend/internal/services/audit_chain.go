@@ -0,0 +1,295 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jacob-fain/MRS/internal/models"
+	"gorm.io/gorm"
+)
+
+// auditChainLockKey is the Postgres advisory lock serializing appends across API instances
+const auditChainLockKey = 7_403_118
+
+// maxAuditChainBreaks caps how many breaks a verification lists
+const maxAuditChainBreaks = 100
+
+// auditVerifyBatchSize is how many entries are loaded at once while verifying
+const auditVerifyBatchSize = 500
+
+// auditChainMu serializes appends within this process, so each entry links to
+// the one inserted right before it
+var auditChainMu sync.Mutex
+
+// auditHashContent is the part of an entry covered by its hash. request_id isn't
+// included because purging a request clears it, entity_id keeps the same ID.
+type auditHashContent struct {
+	PrevHash   string `json:"prev_hash"`
+	EntityType string `json:"entity_type"`
+	EntityID   uint   `json:"entity_id"`
+	UserID     *uint  `json:"user_id"`
+	Action     string `json:"action"`
+	OldValue   string `json:"old_value"`
+	NewValue   string `json:"new_value"`
+	Notes      string `json:"notes"`
+	IPAddress  string `json:"ip_address"`
+	UserAgent  string `json:"user_agent"`
+	CreatedAt  string `json:"created_at"`
+}
+
+// auditLogHash computes the chained HMAC-SHA256 of an entry. The key lives
+// outside the database, so entries can't be rewritten from it.
+func auditLogHash(key []byte, log models.AuditLog) string {
+	content, _ := json.Marshal(auditHashContent{
+		PrevHash:   log.PrevHash,
+		EntityType: string(log.EntityType),
+		EntityID:   log.EntityID,
+		UserID:     log.UserID,
+		Action:     string(log.Action),
+		OldValue:   log.OldValue,
+		NewValue:   log.NewValue,
+		Notes:      log.Notes,
+		IPAddress:  log.IPAddress,
+		UserAgent:  log.UserAgent,
+		CreatedAt:  log.CreatedAt.UTC().Format(time.RFC3339Nano),
+	})
+	mac := hmac.New(sha256.New, key)
+	mac.Write(content)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// appendAuditLog inserts an entry at the end of the hash chain. The timestamp is
+// kept at microsecond precision so it reads back from the database unchanged.
+func appendAuditLog(db *gorm.DB, key []byte, log *models.AuditLog) error {
+	log.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+
+	auditChainMu.Lock()
+	defer auditChainMu.Unlock()
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if tx.Dialector.Name() == "postgres" {
+			if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", auditChainLockKey).Error; err != nil {
+				return err
+			}
+		}
+
+		// Soft-deleted entries are still part of the chain
+		var previous models.AuditLog
+		if err := tx.Unscoped().Select("hash").Order("id DESC").Limit(1).Find(&previous).Error; err != nil {
+			return err
+		}
+
+		log.PrevHash = previous.Hash
+		log.Hash = auditLogHash(key, *log)
+		return tx.Create(log).Error
+	})
+}
+
+// auditHiddenEntries is the new value of a marker entry, the entries it hid
+type auditHiddenEntries struct {
+	IDs []uint `json:"ids"`
+}
+
+// HideEntries soft-deletes audit log entries within tx and appends a marker entry
+// listing them. VerifyChain reports soft-deleted entries without a marker as breaks.
+// Without auditing, the entries are only soft-deleted.
+func (s *AuditService) HideEntries(tx *gorm.DB, ids []uint, actorID *uint, notes string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := tx.Where("id IN ?", ids).Delete(&models.AuditLog{}).Error; err != nil {
+		return err
+	}
+	if s == nil {
+		return nil
+	}
+
+	hidden, err := json.Marshal(auditHiddenEntries{IDs: ids})
+	if err != nil {
+		return err
+	}
+	return appendAuditLog(tx, s.key, &models.AuditLog{
+		EntityType: models.AuditEntityAuditLog,
+		UserID:     actorID,
+		Action:     models.ActionDeleted,
+		NewValue:   string(hidden),
+		Notes:      notes,
+		IPAddress:  s.ipAddress,
+		UserAgent:  s.userAgent,
+	})
+}
+
+// AuditChainBreak is an entry that doesn't match the hash chain
+type AuditChainBreak struct {
+	ID     uint   `json:"id"`
+	Reason string `json:"reason"`
+}
+
+// AuditChainReport is the result of verifying the audit log
+type AuditChainReport struct {
+	Valid       bool              `json:"valid"`
+	Entries     int64             `json:"entries"`
+	Verified    int64             `json:"verified"`
+	Unhashed    int64             `json:"unhashed"`    // Entries from before the chain started
+	Redacted    int64             `json:"redacted"`    // Entries whose actor was purged
+	Deleted     int64             `json:"deleted"`     // Soft-deleted entries, still verified and listed by a marker entry
+	BreakCount  int64             `json:"break_count"` // Total, Breaks lists the first ones
+	Breaks      []AuditChainBreak `json:"breaks"`
+	FirstHashed *uint             `json:"first_hashed,omitempty"` // Where the chain starts
+	HeadID      *uint             `json:"head_id,omitempty"`      // Last hashed entry, keep it with head_hash as a checkpoint
+	HeadHash    string            `json:"head_hash,omitempty"`
+}
+
+// AuditCheckpoint is a chain head from an earlier report. Verifying against it
+// detects entries removed from the end of the log, which leave the chain intact.
+type AuditCheckpoint struct {
+	ID   uint
+	Hash string
+}
+
+func (r *AuditChainReport) addBreak(id uint, reason string) {
+	r.BreakCount++
+	if len(r.Breaks) < maxAuditChainBreaks {
+		r.Breaks = append(r.Breaks, AuditChainBreak{ID: id, Reason: reason})
+	}
+}
+
+// VerifyChain walks the whole audit log, soft-deleted entries included, and
+// checks every hash and link. Entries written before hashing was added are
+// counted as unhashed as long as they all come before the first hashed entry.
+// Soft-deleted entries must be listed by a later marker entry from HideEntries.
+// A nil checkpoint skips the truncation check.
+func (s *AuditService) VerifyChain(checkpoint *AuditCheckpoint) (*AuditChainReport, error) {
+	report := &AuditChainReport{Breaks: []AuditChainBreak{}}
+
+	hiddenBy, purgedBy, err := s.chainMarkers()
+	if err != nil {
+		return nil, err
+	}
+
+	chained := false
+	checkpointFound := false
+	previousHash := ""
+	var batch []models.AuditLog
+	result := s.db.Unscoped().Order("id").FindInBatches(&batch, auditVerifyBatchSize, func(tx *gorm.DB, _ int) error {
+		for _, entry := range batch {
+			report.Entries++
+			if entry.DeletedAt.Valid {
+				report.Deleted++
+				if _, ok := hiddenBy[entry.ID]; !ok {
+					report.addBreak(entry.ID, "deleted without a marker entry")
+				}
+			}
+			if checkpoint != nil && entry.ID == checkpoint.ID {
+				checkpointFound = true
+				if entry.Hash != checkpoint.Hash {
+					report.addBreak(entry.ID, "hash doesn't match the checkpoint")
+				}
+			}
+
+			if entry.Hash == "" {
+				if chained {
+					report.addBreak(entry.ID, "missing hash")
+				} else {
+					report.Unhashed++
+				}
+				continue
+			}
+			if !chained {
+				chained = true
+				id := entry.ID
+				report.FirstHashed = &id
+			}
+
+			if entry.PrevHash != previousHash {
+				report.addBreak(entry.ID, "previous hash doesn't match, entries were removed or reordered")
+			}
+			previousHash = entry.Hash
+			id := entry.ID
+			report.HeadID = &id
+			report.HeadHash = entry.Hash
+
+			if auditLogHash(s.key, entry) == entry.Hash {
+				report.Verified++
+				continue
+			}
+			if entry.UserID == nil && s.matchesPurgedActor(entry, purgedBy) {
+				report.Verified++
+				report.Redacted++
+				continue
+			}
+			report.addBreak(entry.ID, "content doesn't match its hash")
+		}
+		return nil
+	})
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to verify audit log: %w", result.Error)
+	}
+
+	if checkpoint != nil && !checkpointFound {
+		report.addBreak(checkpoint.ID, "checkpoint entry is missing, entries were removed from the end")
+	}
+
+	report.Valid = report.BreakCount == 0
+	return report, nil
+}
+
+// chainMarkers returns the entries hidden by earlier marker entries, mapped to the
+// marker's ID, and the purged users, mapped to the ID of the purge entry. Only
+// markers and purge entries whose hash matches are used, so they can't be forged
+// without the key.
+func (s *AuditService) chainMarkers() (map[uint]uint, map[uint]uint, error) {
+	var markers []models.AuditLog
+	err := s.db.Unscoped().
+		Where("(entity_type = ? AND action = ?) OR (entity_type = ? AND action = ?)",
+			models.AuditEntityAuditLog, models.ActionDeleted, models.AuditEntityUser, models.ActionPurged).
+		Order("id").
+		Find(&markers).Error
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load audit log markers: %w", err)
+	}
+
+	hiddenBy := make(map[uint]uint)
+	purgedBy := make(map[uint]uint)
+	for _, marker := range markers {
+		if marker.Hash == "" || auditLogHash(s.key, marker) != marker.Hash {
+			continue
+		}
+		if marker.EntityType == models.AuditEntityUser {
+			purgedBy[marker.EntityID] = marker.ID
+			continue
+		}
+
+		var hidden auditHiddenEntries
+		if err := json.Unmarshal([]byte(marker.NewValue), &hidden); err != nil {
+			continue
+		}
+		for _, id := range hidden.IDs {
+			if id < marker.ID {
+				hiddenBy[id] = marker.ID
+			}
+		}
+	}
+	return hiddenBy, purgedBy, nil
+}
+
+// matchesPurgedActor reports whether an entry's hash matches with one of the
+// users purged after it as its actor
+func (s *AuditService) matchesPurgedActor(entry models.AuditLog, purgedBy map[uint]uint) bool {
+	for userID, purgeID := range purgedBy {
+		if purgeID <= entry.ID {
+			continue
+		}
+		id := userID
+		entry.UserID = &id
+		if auditLogHash(s.key, entry) == entry.Hash {
+			return true
+		}
+	}
+	return false
+}
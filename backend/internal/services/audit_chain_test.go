@@ -0,0 +1,156 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/jacob-fain/MRS/internal/models"
+	"github.com/jacob-fain/MRS/internal/testutil"
+)
+
+func TestAuditService_VerifyChain(t *testing.T) {
+	setup := func(t *testing.T) (*AuditService, []models.AuditLog) {
+		db := testutil.SetupTestDB(t)
+		service := NewAuditService(db)
+
+		admin := testutil.CreateTestUser(t, db, "admin@example.com", "admin", "pass", true)
+		user := testutil.CreateTestUser(t, db, "user@example.com", "user", "pass", false)
+		req := testutil.CreateTestRequest(t, db, user.ID, "The Matrix", models.MediaTypeMovie)
+
+		// An entry from before the chain existed
+		db.Create(&models.AuditLog{EntityType: models.AuditEntityRequest, EntityID: req.ID, RequestID: &req.ID, Action: models.ActionCreated})
+
+		testutil.AssertNoError(t, service.LogRequestCreated(req.ID, user.ID))
+		testutil.AssertNoError(t, service.WithClient("203.0.113.7", "agent").LogRequestStatusChange(req.ID, &admin.ID, models.StatusPending, models.StatusApproved))
+		testutil.AssertNoError(t, service.LogUserLogin(user.ID))
+
+		var logs []models.AuditLog
+		testutil.AssertNoError(t, db.Order("id").Find(&logs).Error)
+		testutil.AssertEqual(t, 4, len(logs))
+		return service, logs
+	}
+
+	t.Run("intact chain", func(t *testing.T) {
+		service, logs := setup(t)
+
+		testutil.AssertEqual(t, "", logs[1].PrevHash)
+		testutil.AssertEqual(t, logs[1].Hash, logs[2].PrevHash)
+		testutil.AssertEqual(t, logs[2].Hash, logs[3].PrevHash)
+
+		report, err := service.VerifyChain(nil)
+		testutil.AssertNoError(t, err)
+		testutil.AssertTrue(t, report.Valid, "chain should be valid")
+		testutil.AssertEqual(t, int64(4), report.Entries)
+		testutil.AssertEqual(t, int64(3), report.Verified)
+		testutil.AssertEqual(t, int64(1), report.Unhashed)
+		testutil.AssertEqual(t, logs[1].ID, *report.FirstHashed)
+	})
+
+	t.Run("edited entry", func(t *testing.T) {
+		service, logs := setup(t)
+		service.db.Model(&logs[2]).Update("user_id", logs[1].UserID)
+
+		report, err := service.VerifyChain(nil)
+		testutil.AssertNoError(t, err)
+		testutil.AssertTrue(t, !report.Valid, "chain should be broken")
+		testutil.AssertEqual(t, int64(1), report.BreakCount)
+		testutil.AssertEqual(t, logs[2].ID, report.Breaks[0].ID)
+	})
+
+	t.Run("removed entry", func(t *testing.T) {
+		service, logs := setup(t)
+		service.db.Unscoped().Delete(&logs[2])
+
+		report, err := service.VerifyChain(nil)
+		testutil.AssertNoError(t, err)
+		testutil.AssertTrue(t, !report.Valid, "chain should be broken")
+		testutil.AssertEqual(t, logs[3].ID, report.Breaks[0].ID)
+	})
+
+	t.Run("inserted entry", func(t *testing.T) {
+		service, logs := setup(t)
+		service.db.Create(&models.AuditLog{EntityType: models.AuditEntityRequest, EntityID: logs[0].EntityID, Action: models.ActionApproved})
+
+		report, err := service.VerifyChain(nil)
+		testutil.AssertNoError(t, err)
+		testutil.AssertEqual(t, int64(1), report.BreakCount)
+		testutil.AssertEqual(t, "missing hash", report.Breaks[0].Reason)
+	})
+
+	t.Run("soft-deleted entries need a marker entry", func(t *testing.T) {
+		service, logs := setup(t)
+		service.db.Delete(&logs[2])
+
+		report, err := service.VerifyChain(nil)
+		testutil.AssertNoError(t, err)
+		testutil.AssertTrue(t, !report.Valid, "chain should be broken")
+		testutil.AssertEqual(t, int64(1), report.BreakCount)
+		testutil.AssertEqual(t, "deleted without a marker entry", report.Breaks[0].Reason)
+	})
+
+	t.Run("entries hidden with a marker entry are still verified", func(t *testing.T) {
+		service, logs := setup(t)
+		testutil.AssertNoError(t, service.HideEntries(service.db, []uint{logs[2].ID}, nil, "test"))
+
+		report, err := service.VerifyChain(nil)
+		testutil.AssertNoError(t, err)
+		testutil.AssertTrue(t, report.Valid, "chain should be valid")
+		testutil.AssertEqual(t, int64(1), report.Deleted)
+		testutil.AssertEqual(t, int64(4), report.Verified)
+	})
+
+	t.Run("purged actors are redacted", func(t *testing.T) {
+		service, logs := setup(t)
+		admin := *logs[2].UserID
+		service.db.Model(&models.AuditLog{}).Where("user_id = ?", admin).Update("user_id", nil)
+		testutil.AssertNoError(t, service.LogUserPurged(service.db, admin, *logs[1].UserID, "admin", 0))
+
+		report, err := service.VerifyChain(nil)
+		testutil.AssertNoError(t, err)
+		testutil.AssertTrue(t, report.Valid, "chain should be valid")
+		testutil.AssertEqual(t, int64(1), report.Redacted)
+	})
+
+	t.Run("entries written without the key don't count", func(t *testing.T) {
+		service, logs := setup(t)
+		admin := *logs[2].UserID
+		service.db.Model(&models.AuditLog{}).Where("user_id = ?", admin).Update("user_id", nil)
+		service.db.Delete(&logs[3])
+
+		// Someone with database access but not the key adds a purge and a marker
+		forger := *service
+		forger.key = []byte("guessed")
+		testutil.AssertNoError(t, forger.LogUserPurged(service.db, admin, *logs[1].UserID, "admin", 0))
+		testutil.AssertNoError(t, forger.HideEntries(service.db, []uint{logs[3].ID}, nil, "forged"))
+
+		report, err := service.VerifyChain(nil)
+		testutil.AssertNoError(t, err)
+		testutil.AssertTrue(t, !report.Valid, "chain should be broken")
+		testutil.AssertEqual(t, int64(0), report.Redacted)
+		// The redacted entry, the unmarked deletion and both forged entries
+		testutil.AssertEqual(t, int64(4), report.BreakCount)
+	})
+
+	t.Run("removed tail is detected with a checkpoint", func(t *testing.T) {
+		service, logs := setup(t)
+
+		report, err := service.VerifyChain(nil)
+		testutil.AssertNoError(t, err)
+		testutil.AssertEqual(t, logs[3].ID, *report.HeadID)
+		checkpoint := &AuditCheckpoint{ID: *report.HeadID, Hash: report.HeadHash}
+
+		report, err = service.VerifyChain(checkpoint)
+		testutil.AssertNoError(t, err)
+		testutil.AssertTrue(t, report.Valid, "chain should be valid")
+
+		// Removing the last entry leaves the rest of the chain intact
+		service.db.Unscoped().Delete(&logs[3])
+		report, err = service.VerifyChain(nil)
+		testutil.AssertNoError(t, err)
+		testutil.AssertTrue(t, report.Valid, "chain without a checkpoint should look valid")
+
+		report, err = service.VerifyChain(checkpoint)
+		testutil.AssertNoError(t, err)
+		testutil.AssertTrue(t, !report.Valid, "chain should be broken")
+		testutil.AssertEqual(t, logs[3].ID, report.Breaks[0].ID)
+	})
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+)
+
+// FieldChange is the old and new value of a field changed by an update
+type FieldChange struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+// AuditDiff collects the fields changed by an update, in the order they were added
+type AuditDiff struct {
+	changes []FieldChange
+}
+
+// NewAuditDiff creates an empty diff
+func NewAuditDiff() *AuditDiff {
+	return &AuditDiff{}
+}
+
+// Add records a field if its value changed
+func (d *AuditDiff) Add(field string, oldValue, newValue interface{}) {
+	if reflect.DeepEqual(oldValue, newValue) {
+		return
+	}
+	d.changes = append(d.changes, FieldChange{Field: field, Old: oldValue, New: newValue})
+}
+
+// Empty reports whether nothing changed
+func (d *AuditDiff) Empty() bool {
+	return len(d.changes) == 0
+}
+
+// Has reports whether a field changed
+func (d *AuditDiff) Has(field string) bool {
+	_, ok := d.get(field)
+	return ok
+}
+
+// Fields returns the names of the changed fields
+func (d *AuditDiff) Fields() []string {
+	fields := make([]string, len(d.changes))
+	for i, change := range d.changes {
+		fields[i] = change.Field
+	}
+	return fields
+}
+
+func (d *AuditDiff) get(field string) (FieldChange, bool) {
+	for _, change := range d.changes {
+		if change.Field == field {
+			return change, true
+		}
+	}
+	return FieldChange{}, false
+}
+
+// values encodes the old and new values as JSON objects keyed by field, the
+// format stored in AuditLog.OldValue and AuditLog.NewValue
+func (d *AuditDiff) values() (string, string) {
+	oldValues := make(map[string]interface{}, len(d.changes))
+	newValues := make(map[string]interface{}, len(d.changes))
+	for _, change := range d.changes {
+		oldValues[change.Field] = change.Old
+		newValues[change.Field] = change.New
+	}
+	oldJSON, _ := json.Marshal(oldValues)
+	newJSON, _ := json.Marshal(newValues)
+	return string(oldJSON), string(newJSON)
+}
+
+// ParseAuditChanges turns the stored old and new values of an entry back into
+// field changes, sorted by field. Values that aren't JSON objects are ignored.
+func ParseAuditChanges(oldValue, newValue string) []FieldChange {
+	var oldValues, newValues map[string]interface{}
+	if oldValue != "" {
+		if err := json.Unmarshal([]byte(oldValue), &oldValues); err != nil {
+			return nil
+		}
+	}
+	if newValue != "" {
+		if err := json.Unmarshal([]byte(newValue), &newValues); err != nil {
+			return nil
+		}
+	}
+
+	fields := make([]string, 0, len(oldValues)+len(newValues))
+	for field := range oldValues {
+		fields = append(fields, field)
+	}
+	for field := range newValues {
+		if _, ok := oldValues[field]; !ok {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+
+	changes := make([]FieldChange, len(fields))
+	for i, field := range fields {
+		changes[i] = FieldChange{Field: field, Old: oldValues[field], New: newValues[field]}
+	}
+	return changes
+}
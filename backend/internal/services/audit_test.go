@@ -1,6 +1,7 @@
 package services
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/jacob-fain/MRS/internal/models"
@@ -21,7 +22,7 @@ func TestAuditService_LogRequestCreated(t *testing.T) {
 	var log models.AuditLog
 	err = db.Where("request_id = ? AND action = ?", req.ID, models.ActionCreated).First(&log).Error
 	testutil.AssertNoError(t, err)
-	testutil.AssertEqual(t, req.ID, log.RequestID)
+	testutil.AssertEqual(t, req.ID, *log.RequestID)
 	testutil.AssertEqual(t, user.ID, *log.UserID)
 	testutil.AssertEqual(t, string(models.ActionCreated), string(log.Action))
 }
@@ -40,29 +41,56 @@ func TestAuditService_LogRequestStatusChange(t *testing.T) {
 	var log models.AuditLog
 	err = db.Where("request_id = ? AND action = ?", req.ID, models.ActionApproved).First(&log).Error
 	testutil.AssertNoError(t, err)
-	testutil.AssertEqual(t, req.ID, log.RequestID)
+	testutil.AssertEqual(t, req.ID, *log.RequestID)
 	testutil.AssertEqual(t, user.ID, *log.UserID)
 	testutil.AssertEqual(t, string(models.ActionApproved), string(log.Action))
 	testutil.AssertNotNil(t, log.OldValue)
 	testutil.AssertNotNil(t, log.NewValue)
 }
 
-func TestAuditService_LogRequestNotesUpdate(t *testing.T) {
+func TestAuditService_LogRequestUpdate(t *testing.T) {
 	db := testutil.SetupTestDB(t)
 	service := NewAuditService(db)
 
 	user := testutil.CreateTestUser(t, db, "user@example.com", "user", "pass", false)
 	req := testutil.CreateTestRequest(t, db, user.ID, "The Matrix", models.MediaTypeMovie)
 
-	err := service.LogRequestNotesUpdate(req.ID, &user.ID, "Admin notes")
+	diff := NewAuditDiff()
+	diff.Add("admin_notes", "", "In the queue")
+	diff.Add("notes", "4K please", "4K please")
+	err := service.LogRequestUpdate(req.ID, &user.ID, diff)
 	testutil.AssertNoError(t, err)
 
-	// Verify log was created
+	// Verify log was created with only the changed field
 	var log models.AuditLog
 	err = db.Where("request_id = ? AND action = ?", req.ID, models.ActionNotesUpdated).First(&log).Error
 	testutil.AssertNoError(t, err)
-	testutil.AssertEqual(t, req.ID, log.RequestID)
+	testutil.AssertEqual(t, req.ID, *log.RequestID)
 	testutil.AssertEqual(t, user.ID, *log.UserID)
+	testutil.AssertEqual(t, `{"admin_notes":""}`, log.OldValue)
+	testutil.AssertEqual(t, `{"admin_notes":"In the queue"}`, log.NewValue)
+
+	// A status change gives the entry its action
+	diff = NewAuditDiff()
+	diff.Add("status", models.StatusPending, models.StatusRejected)
+	diff.Add("admin_notes", "In the queue", "Not available")
+	testutil.AssertNoError(t, service.LogRequestUpdate(req.ID, &user.ID, diff))
+
+	var rejected models.AuditLog
+	err = db.Where("request_id = ? AND action = ?", req.ID, models.ActionRejected).First(&rejected).Error
+	testutil.AssertNoError(t, err)
+	testutil.AssertEqual(t, "Request rejected, updated admin_notes", rejected.Notes)
+
+	changes := ParseAuditChanges(rejected.OldValue, rejected.NewValue)
+	testutil.AssertEqual(t, 2, len(changes))
+	testutil.AssertEqual(t, FieldChange{Field: "admin_notes", Old: "In the queue", New: "Not available"}, changes[0])
+	testutil.AssertEqual(t, FieldChange{Field: "status", Old: "pending", New: "rejected"}, changes[1])
+
+	// Nothing changed, nothing logged
+	var count int64
+	testutil.AssertNoError(t, service.LogRequestUpdate(req.ID, &user.ID, NewAuditDiff()))
+	db.Model(&models.AuditLog{}).Count(&count)
+	testutil.AssertEqual(t, int64(2), count)
 }
 
 func TestAuditService_LogRequestDeleted(t *testing.T) {
@@ -79,7 +107,7 @@ func TestAuditService_LogRequestDeleted(t *testing.T) {
 	var log models.AuditLog
 	err = db.Where("request_id = ? AND action = ?", req.ID, models.ActionDeleted).First(&log).Error
 	testutil.AssertNoError(t, err)
-	testutil.AssertEqual(t, req.ID, log.RequestID)
+	testutil.AssertEqual(t, req.ID, *log.RequestID)
 	testutil.AssertEqual(t, user.ID, *log.UserID)
 }
 
@@ -93,7 +121,9 @@ func TestAuditService_GetRequestAuditLogs(t *testing.T) {
 	// Create multiple audit logs
 	service.LogRequestCreated(req.ID, user.ID)
 	service.LogRequestStatusChange(req.ID, &user.ID, models.StatusPending, models.StatusApproved)
-	service.LogRequestNotesUpdate(req.ID, &user.ID, "User notes")
+	notesDiff := NewAuditDiff()
+	notesDiff.Add("notes", "", "4K please")
+	service.LogRequestUpdate(req.ID, &user.ID, notesDiff)
 
 	// Retrieve logs
 	logs, err := service.GetRequestAuditLogs(req.ID)
@@ -117,3 +147,34 @@ func TestAuditService_GetRequestAuditLogs(t *testing.T) {
 	}
 	testutil.AssertTrue(t, foundUserPreloaded, "at least one log should have User preloaded")
 }
+
+func TestAuditService_WithClient(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	service := NewAuditService(db)
+
+	admin := testutil.CreateTestUser(t, db, "admin@example.com", "admin", "pass", true)
+	user := testutil.CreateTestUser(t, db, "user@example.com", "user", "pass", false)
+
+	client := service.WithClient("198.51.100.4", strings.Repeat("a", 600))
+	testutil.AssertNoError(t, client.LogUserDeleted(user.ID, admin.ID, user.Username, 3))
+	testutil.AssertNoError(t, service.LogRequestPurged(42, admin.ID, "The Matrix"))
+
+	var logs []models.AuditLog
+	testutil.AssertNoError(t, db.Order("id").Find(&logs).Error)
+	testutil.AssertEqual(t, 2, len(logs))
+
+	testutil.AssertEqual(t, models.AuditEntityUser, logs[0].EntityType)
+	testutil.AssertEqual(t, user.ID, logs[0].EntityID)
+	testutil.AssertEqual(t, "198.51.100.4", logs[0].IPAddress)
+	testutil.AssertEqual(t, maxAuditUserAgentLength, len(logs[0].UserAgent))
+	testutil.AssertEqual(t, "User deleted: user (3 requests)", logs[0].Notes)
+
+	// The original service doesn't carry the client details
+	testutil.AssertEqual(t, models.AuditEntityRequest, logs[1].EntityType)
+	testutil.AssertEqual(t, uint(42), logs[1].EntityID)
+	testutil.AssertTrue(t, logs[1].RequestID == nil, "purged requests aren't referenced")
+	testutil.AssertEqual(t, "", logs[1].IPAddress)
+
+	var disabled *AuditService
+	testutil.AssertTrue(t, disabled.WithClient("198.51.100.4", "agent") == nil, "nil service should stay nil")
+}
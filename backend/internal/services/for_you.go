@@ -0,0 +1,523 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/jacob-fain/MRS/internal/models"
+	"gorm.io/gorm"
+)
+
+// Ranking weights for the For You feed
+const (
+	forYouRecommendationWeight = 1.0
+	forYouGenreWeight          = 0.5
+	forYouPeopleWeight         = 0.5
+	forYouYearWeight           = 0.2
+	forYouYearSpan             = 30.0 // Years apart at which the year score reaches 0
+	forYouHistorySize          = 50   // Most recent requests used for genre and year affinity
+	forYouBilledCast           = 5    // Top-billed cast members of each seed used for people affinity
+	forYouPeople               = 3    // Favorite cast members and directors whose credits are used
+)
+
+// ForYouService builds a personalized feed from a user's request history
+type ForYouService struct {
+	db          *gorm.DB
+	tmdbService TMDBServiceInterface
+	plexService PlexServiceInterface
+	seeds       int // Most recent requests whose TMDB recommendations are used
+}
+
+// ForYouResult is a recommended title with its ranking score
+type ForYouResult struct {
+	TMDBResult
+	Score   float64 `json:"score"`
+	Because string  `json:"because,omitempty"` // Title of the request that led to the recommendation
+}
+
+// ForYouFeed is a ranked list of recommendations. Source is "requests", or
+// "trending" when the user hasn't requested anything from TMDB yet.
+type ForYouFeed struct {
+	Source  string         `json:"source"`
+	Results []ForYouResult `json:"results"`
+}
+
+// NewForYouService creates a For You feed service. FOR_YOU_SEEDS sets how many
+// recent requests are used as recommendation seeds, at least 1.
+func NewForYouService(db *gorm.DB, tmdbService TMDBServiceInterface, plexService PlexServiceInterface) (*ForYouService, error) {
+	seeds, err := envInt("FOR_YOU_SEEDS", 5)
+	if err != nil {
+		return nil, err
+	}
+	if seeds < 1 {
+		return nil, fmt.Errorf("FOR_YOU_SEEDS must be at least 1, got %d", seeds)
+	}
+
+	return &ForYouService{
+		db:          db,
+		tmdbService: tmdbService,
+		plexService: plexService,
+		seeds:       seeds,
+	}, nil
+}
+
+// forYouCandidate collects the signals for one recommended title
+type forYouCandidate struct {
+	result         TMDBResult
+	recommendation float64
+	people         float64
+	because        string
+	bestSeedScore  float64
+}
+
+// forYouSeed holds the TMDB data of one recommendation seed
+type forYouSeed struct {
+	recommendations *TMDBSearchResult
+	err             error
+	genres          []TMDBGenre
+	credits         *TMDBCredits // nil when the details couldn't be loaded
+}
+
+// forYouPerson is a cast member or director of the user's recent requests
+type forYouPerson struct {
+	id       int
+	affinity float64 // Share of the seed weight of the requests crediting them
+	because  string  // Most recent request crediting them
+	cast     bool
+	director bool
+}
+
+// Feed returns up to limit titles for the user, ranked by how often and how
+// highly they are recommended for the user's recent requests, whether they
+// credit the cast members and directors the user requests most, how well their
+// genres match the user's requests and how close their release years are.
+// Titles the user already requested and titles in Plex are left out.
+func (s *ForYouService) Feed(ctx context.Context, userID uint, limit int) (*ForYouFeed, error) {
+	var history []models.Request
+	err := s.db.Preload("Genres").
+		Where("user_id = ? AND tmdb_id > 0", userID).
+		Order("created_at DESC").
+		Limit(forYouHistorySize).
+		Find(&history).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load request history: %w", err)
+	}
+
+	if len(history) == 0 {
+		return s.trendingFeed(ctx, userID, limit)
+	}
+
+	seeds := history
+	if len(seeds) > s.seeds {
+		seeds = seeds[:s.seeds]
+	}
+	loaded := s.loadSeeds(ctx, seeds)
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	candidates := make(map[string]*forYouCandidate)
+	var order []string
+
+	for seedIndex, seed := range seeds {
+		recommendations, err := loaded[seedIndex].recommendations, loaded[seedIndex].err
+		if err != nil {
+			// One failing seed shouldn't hide the whole feed
+			log.Printf("Failed to get recommendations for %s %d: %v", seed.MediaType, seed.TMDBId, err)
+			continue
+		}
+
+		// Recent requests and top recommendations count more
+		weight := seedWeight(seedIndex)
+		for rank, result := range recommendations.Results {
+			score := weight * (1 - float64(rank)/float64(len(recommendations.Results)+1))
+
+			key := forYouKey(result.MediaType, result.ID)
+			candidate, ok := candidates[key]
+			if !ok {
+				candidate = &forYouCandidate{result: result}
+				candidates[key] = candidate
+				order = append(order, key)
+			}
+			candidate.recommendation += score
+			if score > candidate.bestSeedScore {
+				candidate.bestSeedScore = score
+				candidate.because = seed.Title
+			}
+		}
+	}
+
+	people := favoritePeople(seeds, loaded)
+	if err := s.addPeopleCredits(ctx, people, candidates, &order); err != nil {
+		return nil, err
+	}
+
+	requested := make(map[string]bool, len(history))
+	if err := s.markRequested(userID, requested); err != nil {
+		return nil, err
+	}
+
+	genreAffinity, medianYear := affinity(history, loaded)
+
+	results := make([]ForYouResult, 0, len(order))
+	for _, key := range order {
+		if requested[key] {
+			continue
+		}
+		candidate := candidates[key]
+		score := forYouRecommendationWeight*candidate.recommendation +
+			forYouPeopleWeight*math.Min(candidate.people, 1) +
+			forYouGenreWeight*genreScore(candidate.result.GenreIDs, genreAffinity) +
+			forYouYearWeight*yearScore(candidate.result.Year(), medianYear)
+
+		results = append(results, ForYouResult{
+			TMDBResult: candidate.result,
+			Score:      score,
+			Because:    candidate.because,
+		})
+	}
+
+	sortForYou(results)
+	return &ForYouFeed{Source: "requests", Results: s.excludeInPlex(ctx, results, limit)}, nil
+}
+
+// loadSeeds fetches the recommendations and details of each seed in parallel.
+// Details are usually cached.
+func (s *ForYouService) loadSeeds(ctx context.Context, seeds []models.Request) []forYouSeed {
+	loaded := make([]forYouSeed, len(seeds))
+
+	var wg sync.WaitGroup
+	for i, seed := range seeds {
+		wg.Add(1)
+		go func(seed models.Request, loaded *forYouSeed) {
+			defer wg.Done()
+
+			loaded.recommendations, loaded.err = s.tmdbService.GetRecommendationsContext(ctx, string(seed.MediaType), seed.TMDBId, 1)
+
+			switch seed.MediaType {
+			case models.MediaTypeMovie:
+				if details, err := s.tmdbService.GetMovieDetailsContext(ctx, seed.TMDBId); err == nil {
+					loaded.genres, loaded.credits = details.Genres, &details.Credits
+				}
+			case models.MediaTypeTV:
+				if details, err := s.tmdbService.GetTVDetailsContext(ctx, seed.TMDBId); err == nil {
+					loaded.genres, loaded.credits = details.Genres, &details.Credits
+				}
+			}
+		}(seed, &loaded[i])
+	}
+	wg.Wait()
+
+	return loaded
+}
+
+// favoritePeople returns the top-billed cast members and directors credited on
+// the most seed weight, at most forYouPeople of them
+func favoritePeople(seeds []models.Request, loaded []forYouSeed) []forYouPerson {
+	byID := make(map[int]*forYouPerson)
+	var people []*forYouPerson
+	total := 0.0
+
+	credit := func(id int, title string) *forYouPerson {
+		person, ok := byID[id]
+		if !ok {
+			person = &forYouPerson{id: id, because: title}
+			byID[id] = person
+			people = append(people, person)
+		}
+		return person
+	}
+
+	for seedIndex, seed := range seeds {
+		credits := loaded[seedIndex].credits
+		if credits == nil {
+			continue
+		}
+		weight := seedWeight(seedIndex)
+		total += weight
+
+		// A person credited twice on one title still counts once
+		credited := make(map[int]bool)
+		for i, cast := range credits.Cast {
+			if i >= forYouBilledCast {
+				break
+			}
+			person := credit(cast.ID, seed.Title)
+			person.cast = true
+			if !credited[cast.ID] {
+				credited[cast.ID] = true
+				person.affinity += weight
+			}
+		}
+		for _, crew := range credits.Crew {
+			if crew.Job != "Director" {
+				continue
+			}
+			person := credit(crew.ID, seed.Title)
+			person.director = true
+			if !credited[crew.ID] {
+				credited[crew.ID] = true
+				person.affinity += weight
+			}
+		}
+	}
+
+	sort.SliceStable(people, func(i, j int) bool {
+		return people[i].affinity > people[j].affinity
+	})
+	if len(people) > forYouPeople {
+		people = people[:forYouPeople]
+	}
+
+	favorites := make([]forYouPerson, len(people))
+	for i, person := range people {
+		favorites[i] = *person
+		favorites[i].affinity /= total
+	}
+	return favorites
+}
+
+// addPeopleCredits adds the people score to the titles the favorite people
+// acted in or directed, adding the titles that aren't candidates yet
+func (s *ForYouService) addPeopleCredits(ctx context.Context, people []forYouPerson, candidates map[string]*forYouCandidate, order *[]string) error {
+	filmographies := make([]*TMDBPersonCredits, len(people))
+
+	var wg sync.WaitGroup
+	for i, person := range people {
+		wg.Add(1)
+		go func(person forYouPerson, credits **TMDBPersonCredits) {
+			defer wg.Done()
+
+			var err error
+			if *credits, err = s.tmdbService.GetPersonCreditsContext(ctx, person.id); err != nil {
+				log.Printf("Failed to get credits for person %d: %v", person.id, err)
+			}
+		}(person, &filmographies[i])
+	}
+	wg.Wait()
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	for i, person := range people {
+		credits := filmographies[i]
+		if credits == nil {
+			continue
+		}
+
+		var titles []TMDBResult
+		if person.cast {
+			for _, cast := range credits.Cast {
+				// Talk shows and documentaries credit people as themselves
+				if strings.Contains(strings.ToLower(cast.Character), "self") {
+					continue
+				}
+				titles = append(titles, cast.Result())
+			}
+		}
+		if person.director {
+			for _, crew := range credits.Crew {
+				if crew.Job == "Director" {
+					titles = append(titles, crew.Result())
+				}
+			}
+		}
+
+		seen := make(map[string]bool, len(titles))
+		for _, title := range titles {
+			if title.MediaType != "movie" && title.MediaType != "tv" {
+				continue
+			}
+			key := forYouKey(title.MediaType, title.ID)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			candidate, ok := candidates[key]
+			if !ok {
+				candidate = &forYouCandidate{result: title, because: person.because}
+				candidates[key] = candidate
+				*order = append(*order, key)
+			}
+			candidate.people += person.affinity
+		}
+	}
+	return nil
+}
+
+// trendingFeed is used until the user has requested something
+func (s *ForYouService) trendingFeed(ctx context.Context, userID uint, limit int) (*ForYouFeed, error) {
+	trending, err := s.tmdbService.GetTrendingContext(ctx, "all", "week", 1)
+	if err != nil {
+		return nil, err
+	}
+
+	requested := make(map[string]bool)
+	if err := s.markRequested(userID, requested); err != nil {
+		return nil, err
+	}
+
+	results := make([]ForYouResult, 0, len(trending.Results))
+	for rank, result := range trending.Results {
+		if result.MediaType != "movie" && result.MediaType != "tv" {
+			continue
+		}
+		if requested[forYouKey(result.MediaType, result.ID)] {
+			continue
+		}
+		results = append(results, ForYouResult{
+			TMDBResult: result,
+			Score:      1 - float64(rank)/float64(len(trending.Results)+1),
+		})
+	}
+
+	return &ForYouFeed{Source: "trending", Results: s.excludeInPlex(ctx, results, limit)}, nil
+}
+
+// markRequested adds every title the user has requested to requested
+func (s *ForYouService) markRequested(userID uint, requested map[string]bool) error {
+	var requests []models.Request
+	err := s.db.Select("media_type", "tmdb_id").
+		Where("user_id = ? AND tmdb_id > 0", userID).
+		Find(&requests).Error
+	if err != nil {
+		return fmt.Errorf("failed to load requested titles: %w", err)
+	}
+
+	for _, request := range requests {
+		requested[forYouKey(string(request.MediaType), request.TMDBId)] = true
+	}
+	return nil
+}
+
+// affinity returns the share of requests in each genre and the median release year.
+// Genres are stored with the requests. Requests saved without them use the
+// genres from the seed details when they are seeds.
+func affinity(history []models.Request, loaded []forYouSeed) (map[int]float64, int) {
+	genreCounts := make(map[int]float64)
+	var years []int
+	counted := 0
+
+	for i, request := range history {
+		if request.Year > 0 {
+			years = append(years, request.Year)
+		}
+
+		var genreIDs []int
+		for _, genre := range request.Genres {
+			genreIDs = append(genreIDs, genre.GenreID)
+		}
+		if len(genreIDs) == 0 && i < len(loaded) {
+			for _, genre := range loaded[i].genres {
+				genreIDs = append(genreIDs, genre.ID)
+			}
+		}
+		if len(genreIDs) == 0 {
+			continue
+		}
+
+		counted++
+		for _, id := range genreIDs {
+			genreCounts[id]++
+		}
+	}
+
+	if counted > 0 {
+		for id := range genreCounts {
+			genreCounts[id] /= float64(counted)
+		}
+	}
+
+	medianYear := 0
+	if len(years) > 0 {
+		sort.Ints(years)
+		medianYear = years[len(years)/2]
+	}
+	return genreCounts, medianYear
+}
+
+// excludeInPlex drops titles already in Plex and returns at most limit results
+func (s *ForYouService) excludeInPlex(ctx context.Context, results []ForYouResult, limit int) []ForYouResult {
+	if s.plexService == nil || len(results) == 0 {
+		return truncateForYou(results, limit)
+	}
+
+	// Check a few more than needed since some will be in Plex
+	checked := truncateForYou(results, limit*2)
+	refs := make([]MediaRef, len(checked))
+	for i, result := range checked {
+		refs[i] = result.MediaRef()
+	}
+	found := s.plexService.CheckManyExistContext(ctx, refs)
+
+	filtered := make([]ForYouResult, 0, limit)
+	for i, result := range checked {
+		if !found[refs[i]] {
+			filtered = append(filtered, result)
+		}
+	}
+	return truncateForYou(filtered, limit)
+}
+
+// seedWeight makes recent requests count more
+func seedWeight(seedIndex int) float64 {
+	return 1 / (1 + 0.5*float64(seedIndex))
+}
+
+// genreScore is the average affinity of the title's genres
+func genreScore(genreIDs []int, affinity map[int]float64) float64 {
+	if len(genreIDs) == 0 {
+		return 0
+	}
+	total := 0.0
+	for _, id := range genreIDs {
+		total += affinity[id]
+	}
+	return total / float64(len(genreIDs))
+}
+
+// yearScore is 1 for titles from the user's median year, falling to 0 over forYouYearSpan years
+func yearScore(year, medianYear int) float64 {
+	if year == 0 || medianYear == 0 {
+		return 0
+	}
+	distance := float64(year - medianYear)
+	if distance < 0 {
+		distance = -distance
+	}
+	if distance >= forYouYearSpan {
+		return 0
+	}
+	return 1 - distance/forYouYearSpan
+}
+
+// sortForYou orders results by score, then popularity and ID so the feed is stable
+func sortForYou(results []ForYouResult) {
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		if results[i].Popularity != results[j].Popularity {
+			return results[i].Popularity > results[j].Popularity
+		}
+		return results[i].ID < results[j].ID
+	})
+}
+
+func truncateForYou(results []ForYouResult, limit int) []ForYouResult {
+	if limit > 0 && len(results) > limit {
+		return results[:limit]
+	}
+	return results
+}
+
+// forYouKey identifies a title, TMDB IDs are only unique per media type
+func forYouKey(mediaType string, tmdbID int) string {
+	return fmt.Sprintf("%s:%d", mediaType, tmdbID)
+}
@@ -0,0 +1,265 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jacob-fain/MRS/internal/models"
+	"github.com/jacob-fain/MRS/internal/testutil"
+)
+
+// forYouTMDBService returns fixed recommendations per seed
+type forYouTMDBService struct {
+	TMDBServiceInterface
+	recommendations map[int][]TMDBResult
+	genres          map[int][]TMDBGenre
+	credits         map[int]TMDBCredits
+	personCredits   map[int]TMDBPersonCredits
+	trending        []TMDBResult
+}
+
+func (f *forYouTMDBService) GetRecommendationsContext(ctx context.Context, mediaType string, id int, page int) (*TMDBSearchResult, error) {
+	return &TMDBSearchResult{Page: page, Results: f.recommendations[id]}, nil
+}
+
+func (f *forYouTMDBService) GetMovieDetailsContext(ctx context.Context, movieID int) (*TMDBMovieDetails, error) {
+	return &TMDBMovieDetails{ID: movieID, Genres: f.genres[movieID], Credits: f.credits[movieID]}, nil
+}
+
+func (f *forYouTMDBService) GetPersonCreditsContext(ctx context.Context, personID int) (*TMDBPersonCredits, error) {
+	credits := f.personCredits[personID]
+	return &credits, nil
+}
+
+func (f *forYouTMDBService) GetTrendingContext(ctx context.Context, mediaType, timeWindow string, page int) (*TMDBSearchResult, error) {
+	return &TMDBSearchResult{Page: page, Results: f.trending}, nil
+}
+
+// forYouPlexService reports the given titles as in Plex
+type forYouPlexService struct {
+	PlexServiceInterface
+	inPlex map[string]bool
+}
+
+func (f *forYouPlexService) CheckManyExistContext(ctx context.Context, refs []MediaRef) map[MediaRef]bool {
+	found := make(map[MediaRef]bool)
+	for _, ref := range refs {
+		found[ref] = f.inPlex[ref.Title]
+	}
+	return found
+}
+
+func createForYouRequest(t *testing.T, service *ForYouService, userID uint, title string, tmdbID, year int, createdAt time.Time) {
+	request := &models.Request{
+		UserID:    userID,
+		Title:     title,
+		Year:      year,
+		MediaType: models.MediaTypeMovie,
+		TMDBId:    tmdbID,
+		Status:    models.StatusPending,
+		CreatedAt: createdAt,
+	}
+	if err := service.db.Create(request).Error; err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+}
+
+func TestNewForYouService(t *testing.T) {
+	for _, seeds := range []string{"0", "-1", "five"} {
+		t.Setenv("FOR_YOU_SEEDS", seeds)
+		_, err := NewForYouService(nil, nil, nil)
+		testutil.AssertError(t, err)
+	}
+
+	t.Setenv("FOR_YOU_SEEDS", "1")
+	service, err := NewForYouService(nil, nil, nil)
+	testutil.AssertNoError(t, err)
+	testutil.AssertEqual(t, 1, service.seeds)
+}
+
+func TestForYouService_Feed(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, db, "user@example.com", "user", "password123", false)
+
+	tmdb := &forYouTMDBService{
+		recommendations: map[int][]TMDBResult{
+			// Most recent request
+			603: {
+				{ID: 604, Title: "The Matrix Reloaded", MediaType: "movie", ReleaseDate: "2003-05-15", GenreIDs: []int{28, 878}},
+				{ID: 1891, Title: "The Empire Strikes Back", MediaType: "movie", ReleaseDate: "1980-05-20", GenreIDs: []int{12, 878}},
+				{ID: 11, Title: "Star Wars", MediaType: "movie", ReleaseDate: "1977-05-25", GenreIDs: []int{12, 878}},
+			},
+			11: {
+				{ID: 1891, Title: "The Empire Strikes Back", MediaType: "movie", ReleaseDate: "1980-05-20", GenreIDs: []int{12, 878}},
+				{ID: 862, Title: "Toy Story", MediaType: "movie", ReleaseDate: "1995-11-22", GenreIDs: []int{16}},
+			},
+		},
+		genres: map[int][]TMDBGenre{
+			603: {{ID: 28, Name: "Action"}, {ID: 878, Name: "Science Fiction"}},
+			11:  {{ID: 12, Name: "Adventure"}, {ID: 878, Name: "Science Fiction"}},
+		},
+	}
+	plex := &forYouPlexService{inPlex: map[string]bool{}}
+
+	service, err := NewForYouService(db, tmdb, plex)
+	if err != nil {
+		t.Fatalf("NewForYouService failed: %v", err)
+	}
+
+	now := time.Now()
+	createForYouRequest(t, service, user.ID, "Star Wars", 11, 1977, now.Add(-time.Hour))
+	createForYouRequest(t, service, user.ID, "The Matrix", 603, 1999, now)
+
+	t.Run("ranks titles recommended for several requests first and skips requested titles", func(t *testing.T) {
+		feed, err := service.Feed(context.Background(), user.ID, 10)
+		if err != nil {
+			t.Fatalf("Feed failed: %v", err)
+		}
+
+		if feed.Source != "requests" {
+			t.Errorf("expected source requests, got %q", feed.Source)
+		}
+		if len(feed.Results) != 3 {
+			t.Fatalf("expected 3 results, got %d: %+v", len(feed.Results), feed.Results)
+		}
+		if feed.Results[0].ID != 1891 {
+			t.Errorf("expected The Empire Strikes Back first, got %q", feed.Results[0].Title)
+		}
+		for _, result := range feed.Results {
+			if result.ID == 11 {
+				t.Error("expected requested title Star Wars to be excluded")
+			}
+		}
+		if feed.Results[len(feed.Results)-1].ID != 862 {
+			t.Errorf("expected Toy Story last, got %q", feed.Results[len(feed.Results)-1].Title)
+		}
+		if feed.Results[1].Because != "The Matrix" {
+			t.Errorf("expected The Matrix Reloaded because of The Matrix, got %q", feed.Results[1].Because)
+		}
+	})
+
+	t.Run("excludes titles in Plex and applies the limit", func(t *testing.T) {
+		plex.inPlex = map[string]bool{"The Empire Strikes Back": true}
+		defer func() { plex.inPlex = map[string]bool{} }()
+
+		feed, err := service.Feed(context.Background(), user.ID, 1)
+		if err != nil {
+			t.Fatalf("Feed failed: %v", err)
+		}
+		if len(feed.Results) != 1 || feed.Results[0].ID != 604 {
+			t.Errorf("expected only The Matrix Reloaded, got %+v", feed.Results)
+		}
+	})
+
+	t.Run("works without Plex", func(t *testing.T) {
+		var notConfigured *PlexService
+		for _, plexService := range []PlexServiceInterface{nil, notConfigured} {
+			service, err := NewForYouService(db, tmdb, plexService)
+			if err != nil {
+				t.Fatalf("NewForYouService failed: %v", err)
+			}
+
+			feed, err := service.Feed(context.Background(), user.ID, 10)
+			if err != nil {
+				t.Fatalf("Feed failed: %v", err)
+			}
+			if len(feed.Results) != 3 {
+				t.Errorf("expected 3 results, got %d: %+v", len(feed.Results), feed.Results)
+			}
+		}
+	})
+
+	t.Run("falls back to trending without request history", func(t *testing.T) {
+		newUser := testutil.CreateTestUser(t, db, "new@example.com", "newuser", "password123", false)
+		tmdb.trending = []TMDBResult{
+			{ID: 1, Name: "Someone", MediaType: "person"},
+			{ID: 2, Title: "Trending Movie", MediaType: "movie"},
+		}
+
+		feed, err := service.Feed(context.Background(), newUser.ID, 10)
+		if err != nil {
+			t.Fatalf("Feed failed: %v", err)
+		}
+		if feed.Source != "trending" {
+			t.Errorf("expected source trending, got %q", feed.Source)
+		}
+		if len(feed.Results) != 1 || feed.Results[0].ID != 2 {
+			t.Errorf("expected only the trending movie, got %+v", feed.Results)
+		}
+	})
+}
+
+func TestForYouService_FeedPeopleAndStoredGenres(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, db, "user@example.com", "user", "password123", false)
+
+	tmdb := &forYouTMDBService{
+		recommendations: map[int][]TMDBResult{
+			603: {
+				{ID: 862, Title: "Toy Story", MediaType: "movie", ReleaseDate: "1995-11-22", GenreIDs: []int{16}},
+				{ID: 278, Title: "The Shawshank Redemption", MediaType: "movie", ReleaseDate: "1994-09-23", GenreIDs: []int{18}},
+			},
+		},
+		credits: map[int]TMDBCredits{
+			603: {
+				Cast: []TMDBCast{{ID: 6384, Name: "Keanu Reeves"}},
+				Crew: []TMDBCrew{{ID: 9339, Name: "Lana Wachowski", Job: "Director"}, {ID: 1, Name: "Producer", Job: "Producer"}},
+			},
+		},
+		personCredits: map[int]TMDBPersonCredits{
+			6384: {Cast: []TMDBPersonCast{
+				{ID: 245891, Title: "John Wick", MediaType: "movie", ReleaseDate: "2014-10-22", Character: "John Wick"},
+				{ID: 999, Name: "Late Night", MediaType: "tv", Character: "Self"},
+				{ID: 603, Title: "The Matrix", MediaType: "movie", Character: "Neo"},
+			}},
+			9339: {Crew: []TMDBPersonCrew{
+				{ID: 245891, Title: "John Wick", MediaType: "movie", Job: "Writer"},
+				{ID: 278, Title: "The Shawshank Redemption", MediaType: "movie", Job: "Director"},
+			}},
+		},
+	}
+
+	service, err := NewForYouService(db, tmdb, nil)
+	if err != nil {
+		t.Fatalf("NewForYouService failed: %v", err)
+	}
+	service.seeds = 1
+
+	now := time.Now()
+	// Older requests aren't seeds, their stored genres still count
+	for i, id := range []int{10, 11, 12} {
+		request := &models.Request{
+			UserID:    user.ID,
+			Title:     fmt.Sprintf("Animated %d", id),
+			MediaType: models.MediaTypeMovie,
+			TMDBId:    id,
+			Status:    models.StatusPending,
+			CreatedAt: now.Add(-time.Duration(i+1) * time.Hour),
+			Genres:    []models.RequestGenre{{GenreID: 16}},
+		}
+		if err := db.Create(request).Error; err != nil {
+			t.Fatalf("failed to create request: %v", err)
+		}
+	}
+	createForYouRequest(t, service, user.ID, "The Matrix", 603, 1999, now)
+
+	feed, err := service.Feed(context.Background(), user.ID, 10)
+	if err != nil {
+		t.Fatalf("Feed failed: %v", err)
+	}
+
+	ids := make([]int, len(feed.Results))
+	for i, result := range feed.Results {
+		ids[i] = result.ID
+	}
+	// Toy Story matches the stored genres, Shawshank was directed by a favorite,
+	// John Wick stars a favorite but isn't recommended
+	if fmt.Sprint(ids) != "[862 278 245891]" {
+		t.Fatalf("expected Toy Story, The Shawshank Redemption and John Wick, got %v", ids)
+	}
+	if feed.Results[2].Because != "The Matrix" {
+		t.Errorf("expected John Wick because of The Matrix, got %q", feed.Results[2].Because)
+	}
+}
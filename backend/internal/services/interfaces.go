@@ -1,6 +1,10 @@
 package services
 
-import "github.com/golang-jwt/jwt/v5"
+import (
+	"context"
+
+	"github.com/golang-jwt/jwt/v5"
+)
 
 // AuthServiceInterface defines the interface for authentication operations
 type AuthServiceInterface interface {
@@ -14,25 +18,35 @@ type AuthServiceInterface interface {
 
 // PlexServiceInterface defines the interface for Plex operations
 type PlexServiceInterface interface {
-	SearchLibrary(query string) ([]PlexSearchResult, error)
-	CheckIfExists(title string, year int, mediaType string) (bool, error)
-	GetLibraries() ([]PlexLibrary, error)
+	SearchLibraryContext(ctx context.Context, query string) ([]PlexSearchResult, error)
+	CheckIfExistsContext(ctx context.Context, title string, year int, mediaType string) (bool, error)
+	CheckManyExistContext(ctx context.Context, refs []MediaRef) map[MediaRef]bool
+	GetShowSeasonsContext(ctx context.Context, title string, year int) (map[int][]int, error)
+	GetSeasonEpisodesContext(ctx context.Context, title string, year int, seasonNumber int) ([]int, error)
+	GetLibrariesContext(ctx context.Context) ([]PlexLibrary, error)
 }
 
 // TMDBServiceInterface defines the interface for TMDB operations
 type TMDBServiceInterface interface {
-	SearchMulti(query string, page int) (*TMDBSearchResult, error)
-	GetMovieDetails(movieID int) (*TMDBMovieDetails, error)
-	GetTVDetails(tvID int) (*TMDBTVDetails, error)
+	SearchMultiContext(ctx context.Context, query string, page int) (*TMDBSearchResult, error)
+	GetMovieDetailsContext(ctx context.Context, movieID int) (*TMDBMovieDetails, error)
+	GetTVDetailsContext(ctx context.Context, tvID int) (*TMDBTVDetails, error)
+	GetSeasonDetailsContext(ctx context.Context, tvID, seasonNumber int) (*TMDBSeasonDetails, error)
+	GetCollectionContext(ctx context.Context, collectionID int) (*TMDBCollection, error)
+	FindByIMDbIDContext(ctx context.Context, imdbID string) (*TMDBFindResult, error)
 	GetImageURL(path string, size string) string
-	SearchPerson(query string, page int) (*TMDBPersonSearchResult, error)
-	GetPersonDetails(personID int) (*TMDBPersonDetails, error)
-	GetPersonCredits(personID int) (*TMDBPersonCredits, error)
-	GetTrending(mediaType, timeWindow string, page int) (*TMDBSearchResult, error)
-	GetPopularMovies(page int) (*TMDBSearchResult, error)
-	GetPopularTV(page int) (*TMDBSearchResult, error)
-	GetTopRatedMovies(page int) (*TMDBSearchResult, error)
-	GetTopRatedTV(page int) (*TMDBSearchResult, error)
-	GetUpcomingMovies(page int) (*TMDBSearchResult, error)
-	GetUpcomingTV(page int) (*TMDBSearchResult, error)
-}
\ No newline at end of file
+	SearchPersonContext(ctx context.Context, query string, page int) (*TMDBPersonSearchResult, error)
+	GetPersonDetailsContext(ctx context.Context, personID int) (*TMDBPersonDetails, error)
+	GetPersonCreditsContext(ctx context.Context, personID int) (*TMDBPersonCredits, error)
+	GetTrendingContext(ctx context.Context, mediaType, timeWindow string, page int) (*TMDBSearchResult, error)
+	GetPopularMoviesContext(ctx context.Context, page int) (*TMDBSearchResult, error)
+	GetPopularTVContext(ctx context.Context, page int) (*TMDBSearchResult, error)
+	GetTopRatedMoviesContext(ctx context.Context, page int) (*TMDBSearchResult, error)
+	GetTopRatedTVContext(ctx context.Context, page int) (*TMDBSearchResult, error)
+	GetUpcomingMoviesContext(ctx context.Context, page int) (*TMDBSearchResult, error)
+	GetUpcomingTVContext(ctx context.Context, page int) (*TMDBSearchResult, error)
+	GetRecommendationsContext(ctx context.Context, mediaType string, id, page int) (*TMDBSearchResult, error)
+	GetSimilarContext(ctx context.Context, mediaType string, id, page int) (*TMDBSearchResult, error)
+	DiscoverContext(ctx context.Context, mediaType string, filters TMDBDiscoverFilters, page int) (*TMDBSearchResult, error)
+	GetGenresContext(ctx context.Context, mediaType string) ([]TMDBGenre, error)
+}
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"log"
+)
+
+// NotificationType identifies the event a notification is about
+type NotificationType string
+
+const (
+	NotificationRequestComment    NotificationType = "request_comment"
+	NotificationRequestReminder   NotificationType = "request_reminder"
+	NotificationRequestExpired    NotificationType = "request_expired"
+	NotificationWatchlistReleased NotificationType = "watchlist_released"
+)
+
+// Notification describes an event that should be delivered to users
+type Notification struct {
+	Type      NotificationType
+	UserIDs   []uint // Specific recipients
+	ToAdmins  bool   // Also deliver to all admins
+	RequestID uint
+	Subject   string
+	Message   string
+}
+
+// Notifier delivers notifications to users
+type Notifier interface {
+	Notify(n Notification) error
+}
+
+// LogNotifier writes notifications to the application log.
+// It is the default until a real delivery channel is configured.
+type LogNotifier struct{}
+
+// NewLogNotifier creates a new log notifier
+func NewLogNotifier() *LogNotifier {
+	return &LogNotifier{}
+}
+
+// Notify logs the notification. The message is left out since it may carry
+// comment bodies, including internal admin comments.
+func (n *LogNotifier) Notify(notification Notification) error {
+	log.Printf("Notification [%s] users=%v admins=%t request=%d: %s",
+		notification.Type, notification.UserIDs, notification.ToAdmins,
+		notification.RequestID, notification.Subject)
+	return nil
+}
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -19,7 +20,7 @@ type OMDBService struct {
 
 // OMDBServiceInterface defines methods for OMDB service
 type OMDBServiceInterface interface {
-	GetRatingsByIMDB(imdbID string) (*OMDBRatings, error)
+	GetRatingsByIMDBContext(ctx context.Context, imdbID string) (*OMDBRatings, error)
 }
 
 // NewOMDBService creates a new OMDB service instance
@@ -29,16 +30,29 @@ func NewOMDBService() (*OMDBService, error) {
 		return nil, fmt.Errorf("OMDB_API_KEY environment variable not set")
 	}
 
+	upstreamConfig, err := upstreamConfigFromEnv("OMDB", UpstreamConfig{
+		RequestsPerSecond: 5,
+		Burst:             5,
+		MaxRetries:        2,
+		BaseBackoff:       500 * time.Millisecond,
+		MaxBackoff:        5 * time.Second,
+		FailureThreshold:  5,
+		OpenDuration:      time.Minute,
+		AttemptTimeout:    10 * time.Second,
+		Timeout:           20 * time.Second,
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	return &OMDBService{
-		apiKey: apiKey,
-		httpClient: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+		apiKey:     apiKey,
+		httpClient: NewUpstreamHTTPClient("OMDB", upstreamConfig),
 	}, nil
 }
 
-// GetRatingsByIMDB fetches ratings from OMDB using IMDB ID
-func (s *OMDBService) GetRatingsByIMDB(imdbID string) (*OMDBRatings, error) {
+// GetRatingsByIMDBContext fetches ratings from OMDB using IMDB ID
+func (s *OMDBService) GetRatingsByIMDBContext(ctx context.Context, imdbID string) (*OMDBRatings, error) {
 	if imdbID == "" {
 		return nil, fmt.Errorf("IMDB ID cannot be empty")
 	}
@@ -49,7 +63,7 @@ func (s *OMDBService) GetRatingsByIMDB(imdbID string) (*OMDBRatings, error) {
 
 	url := fmt.Sprintf("%s?%s", omdbBaseURL, params.Encode())
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
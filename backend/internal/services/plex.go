@@ -1,15 +1,29 @@
 package services
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/jrudio/go-plex-client"
 )
 
 type PlexService struct {
 	client *plex.Plex
+
+	// Bounds Plex calls in flight, including those whose caller has given up.
+	// Nil means no limit.
+	calls chan struct{}
+
+	// Batch availability checks, see CheckManyExistContext
+	checkConcurrency int
+	checkTimeout     time.Duration
+	checkCacheTTL    time.Duration
+	checkCache       sync.Map // MediaRef -> plexCheckEntry
 }
 
 func NewPlexService() (*PlexService, error) {
@@ -25,6 +39,30 @@ func NewPlexService() (*PlexService, error) {
 		return nil, fmt.Errorf("failed to create plex client: %w", err)
 	}
 
+	// The Plex client doesn't accept a context, so bound every call instead
+	timeoutSeconds, err := envInt("PLEX_TIMEOUT_SECONDS", 10)
+	if err != nil {
+		return nil, err
+	}
+	client.HTTPClient.Timeout = time.Duration(timeoutSeconds) * time.Second
+
+	concurrency, err := envInt("PLEX_CHECK_CONCURRENCY", 5)
+	if err != nil {
+		return nil, err
+	}
+	checkTimeoutSeconds, err := envInt("PLEX_CHECK_TIMEOUT_SECONDS", 3)
+	if err != nil {
+		return nil, err
+	}
+	cacheSeconds, err := envInt("PLEX_CHECK_CACHE_SECONDS", 60)
+	if err != nil {
+		return nil, err
+	}
+	maxCalls, err := envInt("PLEX_MAX_CALLS", 10)
+	if err != nil {
+		return nil, err
+	}
+
 	// Test connection
 	result, err := client.Test()
 	if err != nil {
@@ -34,12 +72,26 @@ func NewPlexService() (*PlexService, error) {
 		return nil, fmt.Errorf("plex connection test returned false")
 	}
 
-	return &PlexService{client: client}, nil
+	var calls chan struct{}
+	if maxCalls > 0 {
+		calls = make(chan struct{}, maxCalls)
+	}
+
+	return &PlexService{
+		client:           client,
+		calls:            calls,
+		checkConcurrency: concurrency,
+		checkTimeout:     time.Duration(checkTimeoutSeconds) * time.Second,
+		checkCacheTTL:    time.Duration(cacheSeconds) * time.Second,
+	}, nil
 }
 
-// SearchLibrary searches all libraries for a given query
-func (s *PlexService) SearchLibrary(query string) ([]PlexSearchResult, error) {
-	results, err := s.client.Search(query)
+// SearchLibraryContext searches all libraries for a given query. It returns
+// as soon as ctx is done, even if the Plex call is still running.
+func (s *PlexService) SearchLibraryContext(ctx context.Context, query string) ([]PlexSearchResult, error) {
+	results, err := withContext(ctx, s.calls, func() (plex.SearchResults, error) {
+		return s.client.Search(query)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("plex search failed: %w", err)
 	}
@@ -62,9 +114,9 @@ func (s *PlexService) SearchLibrary(query string) ([]PlexSearchResult, error) {
 	return searchResults, nil
 }
 
-// CheckIfExists checks if a specific movie/show exists in the library
-func (s *PlexService) CheckIfExists(title string, year int, mediaType string) (bool, error) {
-	results, err := s.SearchLibrary(title)
+// CheckIfExistsContext checks if a specific movie/show exists in the library
+func (s *PlexService) CheckIfExistsContext(ctx context.Context, title string, year int, mediaType string) (bool, error) {
+	results, err := s.SearchLibraryContext(ctx, title)
 	if err != nil {
 		return false, err
 	}
@@ -73,7 +125,7 @@ func (s *PlexService) CheckIfExists(title string, year int, mediaType string) (b
 		// Normalize for comparison
 		if strings.EqualFold(result.Title, title) && 
 		   (year == 0 || result.Year == year) &&
-		   strings.EqualFold(result.Type, mediaType) {
+		   plexTypeMatches(result.Type, mediaType) {
 			return true, nil
 		}
 	}
@@ -81,9 +133,260 @@ func (s *PlexService) CheckIfExists(title string, year int, mediaType string) (b
 	return false, nil
 }
 
-// GetLibraries returns all available libraries
-func (s *PlexService) GetLibraries() ([]PlexLibrary, error) {
-	libraries, err := s.client.GetLibraries()
+// plexTypeMatches compares a Plex item type with a TMDB media type. Plex calls TV shows "show".
+func plexTypeMatches(plexType, mediaType string) bool {
+	if strings.EqualFold(mediaType, "tv") && strings.EqualFold(plexType, "show") {
+		return true
+	}
+	return strings.EqualFold(plexType, mediaType)
+}
+
+// GetShowSeasonsContext returns the episode numbers in Plex for each season of
+// a show that is in Plex, or nil if the show isn't in Plex
+func (s *PlexService) GetShowSeasonsContext(ctx context.Context, title string, year int) (map[int][]int, error) {
+	showKey, err := s.findShow(ctx, title, year)
+	if err != nil || showKey == "" {
+		return nil, err
+	}
+
+	seasons, err := withContext(ctx, s.calls, func() (plex.MetadataChildren, error) {
+		return s.client.GetMetadataChildren(showKey)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get plex seasons: %w", err)
+	}
+
+	numbers := map[int][]int{}
+	for _, season := range seasons.MediaContainer.Metadata {
+		if season.Type != "season" {
+			continue
+		}
+
+		episodes, err := s.seasonEpisodes(ctx, season.RatingKey)
+		if err != nil {
+			return nil, err
+		}
+		numbers[int(season.Index)] = episodes
+	}
+	return numbers, nil
+}
+
+// GetSeasonEpisodesContext returns the episode numbers of a season that are in
+// Plex, or nil if the show or season isn't in Plex
+func (s *PlexService) GetSeasonEpisodesContext(ctx context.Context, title string, year int, seasonNumber int) ([]int, error) {
+	showKey, err := s.findShow(ctx, title, year)
+	if err != nil || showKey == "" {
+		return nil, err
+	}
+
+	seasons, err := withContext(ctx, s.calls, func() (plex.MetadataChildren, error) {
+		return s.client.GetMetadataChildren(showKey)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get plex seasons: %w", err)
+	}
+
+	for _, season := range seasons.MediaContainer.Metadata {
+		if season.Type != "season" || int(season.Index) != seasonNumber {
+			continue
+		}
+
+		return s.seasonEpisodes(ctx, season.RatingKey)
+	}
+
+	return nil, nil
+}
+
+// seasonEpisodes returns the episode numbers of the season with the given rating key
+func (s *PlexService) seasonEpisodes(ctx context.Context, seasonKey string) ([]int, error) {
+	episodes, err := withContext(ctx, s.calls, func() (plex.SearchResultsEpisode, error) {
+		return s.client.GetEpisodes(seasonKey)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get plex episodes: %w", err)
+	}
+
+	numbers := []int{}
+	for _, episode := range episodes.MediaContainer.Metadata {
+		numbers = append(numbers, int(episode.Index))
+	}
+	return numbers, nil
+}
+
+// findShow returns the rating key of a show in Plex, or "" if it isn't there
+func (s *PlexService) findShow(ctx context.Context, title string, year int) (string, error) {
+	results, err := s.SearchLibraryContext(ctx, title)
+	if err != nil {
+		return "", err
+	}
+
+	for _, result := range results {
+		if strings.EqualFold(result.Title, title) &&
+			(year == 0 || result.Year == year) &&
+			plexTypeMatches(result.Type, "tv") {
+			return result.RatingKey, nil
+		}
+	}
+	return "", nil
+}
+
+// MediaRef identifies a movie or show for a batch availability check
+type MediaRef struct {
+	Title     string
+	Year      int
+	MediaType string
+}
+
+// MediaRef returns the Plex lookup for a movie or TV result. The title is
+// empty for media types that can't be in Plex.
+func (r TMDBResult) MediaRef() MediaRef {
+	ref := MediaRef{MediaType: r.MediaType, Year: r.Year()}
+	switch r.MediaType {
+	case "movie":
+		ref.Title = r.Title
+	case "tv":
+		ref.Title = r.Name
+	}
+	return ref
+}
+
+// Year returns the release or first air year of a result, or 0 if unknown
+func (r TMDBResult) Year() int {
+	date := r.ReleaseDate
+	if r.MediaType == "tv" {
+		date = r.FirstAirDate
+	}
+	if len(date) < 4 {
+		return 0
+	}
+	year, _ := strconv.Atoi(date[:4])
+	return year
+}
+
+type plexCheckEntry struct {
+	exists    bool
+	expiresAt time.Time
+}
+
+// CheckManyExistContext checks several movies/shows in parallel using at most
+// PLEX_CHECK_CONCURRENCY concurrent Plex searches. Each search is bounded by
+// PLEX_CHECK_TIMEOUT_SECONDS, and results are cached for PLEX_CHECK_CACHE_SECONDS.
+// Refs that fail or time out are reported as not in Plex and are not cached.
+// A nil service, when Plex isn't configured, reports every ref as not in Plex.
+func (s *PlexService) CheckManyExistContext(ctx context.Context, refs []MediaRef) map[MediaRef]bool {
+	found := make(map[MediaRef]bool, len(refs))
+	if s == nil {
+		return found
+	}
+
+	now := time.Now()
+	s.pruneCheckCache(now)
+
+	// Answer from the cache and skip duplicates
+	var pending []MediaRef
+	seen := make(map[MediaRef]bool, len(refs))
+	for _, ref := range refs {
+		if ref.Title == "" || seen[ref] {
+			continue
+		}
+		seen[ref] = true
+
+		if cached, ok := s.checkCache.Load(plexCheckKey(ref)); ok {
+			entry := cached.(plexCheckEntry)
+			if now.Before(entry.expiresAt) {
+				found[ref] = entry.exists
+				continue
+			}
+		}
+		found[ref] = false
+		pending = append(pending, ref)
+	}
+	if len(pending) == 0 {
+		return found
+	}
+
+	workers := s.checkConcurrency
+	if workers <= 0 {
+		workers = 1
+	}
+	if workers > len(pending) {
+		workers = len(pending)
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	jobs := make(chan MediaRef)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ref := range jobs {
+				exists, err := s.checkOne(ctx, ref)
+				if err != nil {
+					continue
+				}
+				mu.Lock()
+				found[ref] = exists
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, ref := range pending {
+		if ctx.Err() != nil {
+			break
+		}
+		jobs <- ref
+	}
+	close(jobs)
+	wg.Wait()
+
+	return found
+}
+
+// checkOne checks a single ref with the per-call timeout and caches the result
+func (s *PlexService) checkOne(ctx context.Context, ref MediaRef) (bool, error) {
+	if s.checkTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.checkTimeout)
+		defer cancel()
+	}
+
+	exists, err := s.CheckIfExistsContext(ctx, ref.Title, ref.Year, ref.MediaType)
+	if err != nil {
+		return false, err
+	}
+	if s.checkCacheTTL > 0 {
+		s.checkCache.Store(plexCheckKey(ref), plexCheckEntry{
+			exists:    exists,
+			expiresAt: time.Now().Add(s.checkCacheTTL),
+		})
+	}
+	return exists, nil
+}
+
+// pruneCheckCache drops expired availability results
+func (s *PlexService) pruneCheckCache(now time.Time) {
+	s.checkCache.Range(func(key, value any) bool {
+		if !now.Before(value.(plexCheckEntry).expiresAt) {
+			s.checkCache.Delete(key)
+		}
+		return true
+	})
+}
+
+// plexCheckKey normalizes a ref the same way CheckIfExistsContext compares titles
+func plexCheckKey(ref MediaRef) MediaRef {
+	return MediaRef{
+		Title:     strings.ToLower(ref.Title),
+		Year:      ref.Year,
+		MediaType: strings.ToLower(ref.MediaType),
+	}
+}
+
+// GetLibrariesContext returns all available libraries
+func (s *PlexService) GetLibrariesContext(ctx context.Context) ([]PlexLibrary, error) {
+	libraries, err := withContext(ctx, s.calls, s.client.GetLibraries)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get libraries: %w", err)
 	}
@@ -101,6 +404,44 @@ func (s *PlexService) GetLibraries() ([]PlexLibrary, error) {
 	return plexLibraries, nil
 }
 
+// withContext runs fn and returns its result, or ctx's error if ctx is done first.
+// fn keeps running in the background until the client timeout, holding its slot
+// in calls so abandoned calls still count against the limit.
+func withContext[T any](ctx context.Context, calls chan struct{}, fn func() (T, error)) (T, error) {
+	var zero T
+	if err := ctx.Err(); err != nil {
+		return zero, err
+	}
+
+	if calls != nil {
+		select {
+		case calls <- struct{}{}:
+		case <-ctx.Done():
+			return zero, ctx.Err()
+		}
+	}
+
+	type result struct {
+		value T
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		if calls != nil {
+			defer func() { <-calls }()
+		}
+		value, err := fn()
+		done <- result{value: value, err: err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	case r := <-done:
+		return r.value, r.err
+	}
+}
+
 // PlexSearchResult represents a search result from Plex
 type PlexSearchResult struct {
 	Title     string `json:"title"`
@@ -1,10 +1,18 @@
 package services
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/jacob-fain/MRS/internal/testutil"
+	"github.com/jrudio/go-plex-client"
 )
 
 func TestNewPlexService(t *testing.T) {
@@ -144,6 +152,204 @@ func TestPlexService_CheckIfExists(t *testing.T) {
 	}
 }
 
+func TestPlexService_SearchLibraryContext(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		select {
+		case <-r.Context().Done():
+		case <-time.After(2 * time.Second):
+		}
+	}))
+	defer server.Close()
+
+	client, err := plex.New(server.URL, "test-token")
+	testutil.AssertNoError(t, err)
+	service := &PlexService{client: client}
+
+	// A canceled context never reaches Plex
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = service.CheckIfExistsContext(canceled, "The Matrix", 1999, "movie")
+	testutil.AssertTrue(t, errors.Is(err, context.Canceled), "expected context canceled")
+	testutil.AssertEqual(t, int32(0), atomic.LoadInt32(&calls))
+
+	// A slow Plex server doesn't hold the caller past its deadline
+	ctx, cancelTimeout := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancelTimeout()
+
+	start := time.Now()
+	_, err = service.SearchLibraryContext(ctx, "The Matrix")
+	testutil.AssertTrue(t, errors.Is(err, context.DeadlineExceeded), "expected deadline exceeded")
+	testutil.AssertTrue(t, time.Since(start) < time.Second, "search should return when the context is done")
+}
+
+func TestWithContext_LimitsAbandonedCalls(t *testing.T) {
+	calls := make(chan struct{}, 1)
+	release := make(chan struct{})
+
+	// The first call times out but keeps its slot until Plex answers
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := withContext(ctx, calls, func() (int, error) {
+		<-release
+		return 1, nil
+	})
+	testutil.AssertTrue(t, errors.Is(err, context.DeadlineExceeded), "expected deadline exceeded")
+
+	// So the next call gives up without reaching Plex
+	var started int32
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = withContext(ctx, calls, func() (int, error) {
+		atomic.AddInt32(&started, 1)
+		return 2, nil
+	})
+	testutil.AssertTrue(t, errors.Is(err, context.DeadlineExceeded), "expected deadline exceeded")
+	testutil.AssertEqual(t, int32(0), atomic.LoadInt32(&started))
+
+	// Once the abandoned call returns the slot is free again
+	close(release)
+	value, err := withContext(context.Background(), calls, func() (int, error) { return 3, nil })
+	testutil.AssertNoError(t, err)
+	testutil.AssertEqual(t, 3, value)
+}
+
+func TestPlexService_CheckManyExistContext(t *testing.T) {
+	var calls, active, maxActive int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		current := atomic.AddInt32(&active, 1)
+		defer atomic.AddInt32(&active, -1)
+		for {
+			max := atomic.LoadInt32(&maxActive)
+			if current <= max || atomic.CompareAndSwapInt32(&maxActive, max, current) {
+				break
+			}
+		}
+
+		query := r.URL.Query().Get("query")
+		if query == "Slow Movie" {
+			time.Sleep(500 * time.Millisecond)
+		} else {
+			time.Sleep(20 * time.Millisecond)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if query == "The Matrix" || query == "Breaking Bad" {
+			mediaType := "movie"
+			if query == "Breaking Bad" {
+				mediaType = "tv"
+			}
+			fmt.Fprintf(w, `{"MediaContainer":{"Metadata":[{"title":%q,"year":%d,"type":%q}]}}`, query, 1999, mediaType)
+			return
+		}
+		fmt.Fprint(w, `{"MediaContainer":{"Metadata":[]}}`)
+	}))
+	defer server.Close()
+
+	client, err := plex.New(server.URL, "test-token")
+	testutil.AssertNoError(t, err)
+	service := &PlexService{
+		client:           client,
+		checkConcurrency: 2,
+		checkTimeout:     100 * time.Millisecond,
+		checkCacheTTL:    time.Minute,
+	}
+
+	matrix := MediaRef{Title: "The Matrix", Year: 1999, MediaType: "movie"}
+	breakingBad := MediaRef{Title: "Breaking Bad", Year: 1999, MediaType: "tv"}
+	missing := MediaRef{Title: "Missing Movie", Year: 2001, MediaType: "movie"}
+	slow := MediaRef{Title: "Slow Movie", Year: 2002, MediaType: "movie"}
+	noTitle := MediaRef{MediaType: "person"}
+
+	found := service.CheckManyExistContext(context.Background(), []MediaRef{matrix, breakingBad, missing, slow, matrix, noTitle})
+
+	testutil.AssertEqual(t, 4, len(found))
+	testutil.AssertTrue(t, found[matrix], "The Matrix should be in Plex")
+	testutil.AssertTrue(t, found[breakingBad], "Breaking Bad should be in Plex")
+	testutil.AssertTrue(t, !found[missing], "Missing Movie should not be in Plex")
+	testutil.AssertTrue(t, !found[slow], "a timed out check counts as not in Plex")
+
+	// Duplicates are checked once and the worker pool is bounded
+	testutil.AssertEqual(t, int32(4), atomic.LoadInt32(&calls))
+	testutil.AssertTrue(t, atomic.LoadInt32(&maxActive) <= 2, "no more than 2 concurrent Plex searches")
+
+	// Successful results are cached, ignoring title case; failures are retried
+	found = service.CheckManyExistContext(context.Background(), []MediaRef{
+		{Title: "the matrix", Year: 1999, MediaType: "movie"},
+		missing,
+		slow,
+	})
+	testutil.AssertTrue(t, found[MediaRef{Title: "the matrix", Year: 1999, MediaType: "movie"}], "cached result should be used")
+	testutil.AssertEqual(t, int32(5), atomic.LoadInt32(&calls))
+}
+
+func TestPlexService_CheckManyExistContext_NotConfigured(t *testing.T) {
+	var service *PlexService
+	found := service.CheckManyExistContext(context.Background(), []MediaRef{{Title: "The Matrix", Year: 1999, MediaType: "movie"}})
+	testutil.AssertEqual(t, 0, len(found))
+}
+
+func TestPlexService_GetSeasonEpisodes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/search":
+			fmt.Fprint(w, `{"MediaContainer":{"Metadata":[
+				{"title":"Breaking Bad","year":2008,"type":"show","ratingKey":"100"},
+				{"title":"Breaking Bad","year":2019,"type":"movie","ratingKey":"200"}
+			]}}`)
+		case "/library/metadata/100/children":
+			fmt.Fprint(w, `{"MediaContainer":{"Metadata":[
+				{"type":"season","index":1,"ratingKey":"101"},
+				{"type":"season","index":2,"ratingKey":"102"}
+			]}}`)
+		case "/library/metadata/101/children":
+			fmt.Fprint(w, `{"MediaContainer":{"Metadata":[
+				{"type":"episode","index":1},
+				{"type":"episode","index":2},
+				{"type":"episode","index":3}
+			]}}`)
+		case "/library/metadata/102/children":
+			fmt.Fprint(w, `{"MediaContainer":{"Metadata":[
+				{"type":"episode","index":1},
+				{"type":"episode","index":4}
+			]}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client, err := plex.New(server.URL, "test-token")
+	testutil.AssertNoError(t, err)
+	service := &PlexService{client: client}
+	ctx := context.Background()
+
+	// Plex calls TV shows "show"
+	exists, err := service.CheckIfExistsContext(ctx, "Breaking Bad", 2008, "tv")
+	testutil.AssertNoError(t, err)
+	testutil.AssertTrue(t, exists, "show should be found for media type tv")
+
+	seasons, err := service.GetShowSeasonsContext(ctx, "Breaking Bad", 2008)
+	testutil.AssertNoError(t, err)
+	testutil.AssertEqual(t, "map[1:[1 2 3] 2:[1 4]]", fmt.Sprint(seasons))
+
+	episodes, err := service.GetSeasonEpisodesContext(ctx, "Breaking Bad", 2008, 2)
+	testutil.AssertNoError(t, err)
+	testutil.AssertEqual(t, "[1 4]", fmt.Sprint(episodes))
+
+	// Missing seasons and shows aren't errors
+	episodes, err = service.GetSeasonEpisodesContext(ctx, "Breaking Bad", 2008, 5)
+	testutil.AssertNoError(t, err)
+	testutil.AssertEqual(t, 0, len(episodes))
+
+	seasons, err = service.GetShowSeasonsContext(ctx, "Breaking Bad", 1999)
+	testutil.AssertNoError(t, err)
+	testutil.AssertTrue(t, seasons == nil, "show from another year should not match")
+}
+
 // Helper function
 func contains(s, substr string) bool {
 	return len(substr) > 0 && len(s) >= len(substr) && s[:len(substr)] == substr || len(s) > len(substr) && contains(s[1:], substr)
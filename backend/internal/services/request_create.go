@@ -0,0 +1,139 @@
+package services
+
+import (
+	"errors"
+	"log"
+
+	"github.com/jacob-fain/MRS/internal/models"
+	"gorm.io/gorm"
+)
+
+// NewRequest holds the details of a title a user requests
+type NewRequest struct {
+	Title      string
+	Year       int
+	MediaType  models.MediaType
+	TMDBId     int
+	IMDBId     string
+	Overview   string
+	PosterPath string
+	Notes      string
+	GenreIDs   []int
+}
+
+// CreateRequests creates a request for each input in a single transaction, skipping
+// titles the user already requested, and returns the created requests. Requests are
+// approved right away for admins.
+func CreateRequests(db *gorm.DB, auditService *AuditService, userID uint, isAdmin bool, inputs []NewRequest) ([]models.Request, error) {
+	var created []models.Request
+	if len(inputs) == 0 {
+		return created, nil
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for _, input := range inputs {
+			request, isNew, err := createRequest(tx, userID, isAdmin, input)
+			if err != nil {
+				return err
+			}
+			if isNew {
+				created = append(created, request)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, request := range created {
+		logRequestCreated(auditService, request)
+	}
+
+	return created, nil
+}
+
+// createRequest creates the request within tx. If the user already requested the
+// title, with the same media type and either the same title or TMDB ID, the existing
+// request is returned instead and created is false. The caller logs new requests
+// with logRequestCreated once tx commits.
+func createRequest(tx *gorm.DB, userID uint, isAdmin bool, input NewRequest) (request models.Request, created bool, err error) {
+	query := tx.Where("user_id = ? AND media_type = ?", userID, input.MediaType)
+	if input.TMDBId != 0 {
+		query = query.Where("(title = ? OR tmdb_id = ?)", input.Title, input.TMDBId)
+	} else {
+		query = query.Where("title = ?", input.Title)
+	}
+
+	err = query.First(&request).Error
+	if err == nil {
+		return request, false, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.Request{}, false, err
+	}
+
+	status := models.StatusPending
+	if isAdmin {
+		status = models.StatusApproved
+	}
+
+	request = models.Request{
+		UserID:     userID,
+		Title:      input.Title,
+		Year:       input.Year,
+		MediaType:  input.MediaType,
+		TMDBId:     input.TMDBId,
+		IMDBId:     input.IMDBId,
+		Overview:   input.Overview,
+		PosterPath: input.PosterPath,
+		Notes:      input.Notes,
+		Status:     status,
+		Genres:     requestGenres(input.GenreIDs),
+	}
+	if err := tx.Create(&request).Error; err != nil {
+		return models.Request{}, false, err
+	}
+	return request, true, nil
+}
+
+// requestGenres converts TMDB genre IDs, ignoring duplicates
+func requestGenres(genreIDs []int) []models.RequestGenre {
+	var genres []models.RequestGenre
+	seen := make(map[int]bool, len(genreIDs))
+	for _, id := range genreIDs {
+		if id <= 0 || seen[id] {
+			continue
+		}
+		seen[id] = true
+		genres = append(genres, models.RequestGenre{GenreID: id})
+	}
+	return genres
+}
+
+// tmdbGenreIDs returns the IDs of TMDB genres
+func tmdbGenreIDs(genres []TMDBGenre) []int {
+	ids := make([]int, len(genres))
+	for i, genre := range genres {
+		ids[i] = genre.ID
+	}
+	return ids
+}
+
+// logRequestCreated logs the audit entries for a new request
+func logRequestCreated(auditService *AuditService, request models.Request) {
+	if auditService == nil {
+		return
+	}
+
+	if err := auditService.LogRequestCreated(request.ID, request.UserID); err != nil {
+		log.Printf("Failed to log audit entry for request creation (ID: %d): %v", request.ID, err)
+	}
+
+	// If auto-approved, also log the approval action
+	if request.Status == models.StatusApproved {
+		if err := auditService.LogRequestStatusChange(request.ID, &request.UserID, models.StatusPending, models.StatusApproved); err != nil {
+			log.Printf("Failed to log audit entry for auto-approval (ID: %d): %v", request.ID, err)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/jacob-fain/MRS/internal/models"
+	"github.com/jacob-fain/MRS/internal/testutil"
+)
+
+func TestCreateRequests(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	auditService := NewAuditService(db)
+	user := testutil.CreateTestUser(t, db, "user@example.com", "user", "pass", false)
+	admin := testutil.CreateTestUser(t, db, "admin@example.com", "admin", "pass", true)
+
+	existing := testutil.CreateTestRequest(t, db, user.ID, "The Matrix", models.MediaTypeMovie)
+	db.Model(existing).UpdateColumn("tmdb_id", 603)
+
+	t.Run("skips titles requested by title or TMDB ID", func(t *testing.T) {
+		created, err := CreateRequests(db, auditService, user.ID, false, []NewRequest{
+			{Title: "Matrix", MediaType: models.MediaTypeMovie, TMDBId: 603},
+			{Title: "The Matrix", MediaType: models.MediaTypeMovie},
+			{Title: "The Matrix", MediaType: models.MediaTypeTV},
+			{Title: "Inception", MediaType: models.MediaTypeMovie, TMDBId: 27205, GenreIDs: []int{28, 0, 28, 878}},
+			{Title: "Inception", MediaType: models.MediaTypeMovie, TMDBId: 27205},
+		})
+		testutil.AssertNoError(t, err)
+		testutil.AssertEqual(t, 2, len(created))
+		testutil.AssertEqual(t, models.StatusPending, created[1].Status)
+
+		var genres []models.RequestGenre
+		db.Where("request_id = ?", created[1].ID).Order("genre_id").Find(&genres)
+		testutil.AssertEqual(t, 2, len(genres))
+
+		logs, err := auditService.GetRequestAuditLogs(created[1].ID)
+		testutil.AssertNoError(t, err)
+		testutil.AssertEqual(t, 1, len(logs))
+		testutil.AssertEqual(t, models.ActionCreated, logs[0].Action)
+	})
+
+	t.Run("approves requests by admins", func(t *testing.T) {
+		created, err := CreateRequests(db, auditService, admin.ID, true, []NewRequest{
+			{Title: "The Matrix", MediaType: models.MediaTypeMovie, TMDBId: 603},
+		})
+		testutil.AssertNoError(t, err)
+		testutil.AssertEqual(t, 1, len(created))
+		testutil.AssertEqual(t, models.StatusApproved, created[0].Status)
+
+		logs, err := auditService.GetRequestAuditLogs(created[0].ID)
+		testutil.AssertNoError(t, err)
+		testutil.AssertEqual(t, 2, len(logs))
+	})
+}
@@ -0,0 +1,477 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Supported watchlist export formats
+type ImportFormat string
+
+const (
+	ImportFormatIMDb       ImportFormat = "imdb"       // IMDb list or watchlist CSV export
+	ImportFormatLetterboxd ImportFormat = "letterboxd" // Letterboxd watchlist or list CSV export
+	ImportFormatTrakt      ImportFormat = "trakt"      // Trakt watchlist JSON export
+)
+
+// importConcurrency is how many rows are resolved against TMDB at once
+const importConcurrency = 4
+
+// utf8BOM is stripped from exports saved by spreadsheet apps
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// ErrImportTooManyRows is returned when an export has more rows than IMPORT_MAX_ROWS
+var ErrImportTooManyRows = errors.New("too many rows to import")
+
+// imdbTitleTypes maps IMDb title types to media types. Other types (e.g. episodes)
+// are looked up without a media type.
+var imdbTitleTypes = map[string]string{
+	"movie":          "movie",
+	"tv movie":       "movie",
+	"tvmovie":        "movie",
+	"tv special":     "movie",
+	"tvspecial":      "movie",
+	"video":          "movie",
+	"short":          "movie",
+	"tv series":      "tv",
+	"tvseries":       "tv",
+	"tv mini series": "tv",
+	"tvminiseries":   "tv",
+}
+
+// ImportRow is a title read from an export
+type ImportRow struct {
+	Line      int    `json:"line"` // CSV line or JSON array position, starting at 1
+	Title     string `json:"title"`
+	Year      int    `json:"year,omitempty"`
+	MediaType string `json:"media_type,omitempty"` // Empty when the export doesn't say
+	IMDbID    string `json:"imdb_id,omitempty"`
+	TMDBId    int    `json:"tmdb_id,omitempty"`
+}
+
+// ImportMatch is an export row resolved to a TMDB title
+type ImportMatch struct {
+	Row       ImportRow  `json:"row"`
+	Result    TMDBResult `json:"result"`
+	Requested bool       `json:"requested"` // Added by handler, requested by the current user
+}
+
+// ImportUnmatched is an export row that couldn't be resolved
+type ImportUnmatched struct {
+	Row    ImportRow `json:"row"`
+	Reason string    `json:"reason"`
+}
+
+// ImportPreview groups the rows of an export by what can be done with them
+type ImportPreview struct {
+	Matched   []ImportMatch     `json:"matched"`
+	InPlex    []ImportMatch     `json:"in_plex"`
+	Unmatched []ImportUnmatched `json:"unmatched"`
+}
+
+// DetectImportFormat guesses the format of an export from its content
+func DetectImportFormat(data []byte) (ImportFormat, error) {
+	trimmed := bytes.TrimSpace(bytes.TrimPrefix(data, utf8BOM))
+	if bytes.HasPrefix(trimmed, []byte("[")) {
+		return ImportFormatTrakt, nil
+	}
+
+	header, err := csv.NewReader(bytes.NewReader(trimmed)).Read()
+	if err != nil {
+		return "", fmt.Errorf("unrecognized export: %w", err)
+	}
+	columns := csvColumns(header)
+	if _, ok := columns["const"]; ok {
+		return ImportFormatIMDb, nil
+	}
+	if _, ok := columns["letterboxd uri"]; ok {
+		return ImportFormatLetterboxd, nil
+	}
+	return "", fmt.Errorf("unrecognized export, expected an IMDb or Letterboxd CSV or a Trakt JSON export")
+}
+
+// ParseImport reads the titles of an export
+func ParseImport(format ImportFormat, data []byte) ([]ImportRow, error) {
+	data = bytes.TrimPrefix(data, utf8BOM)
+
+	switch format {
+	case ImportFormatIMDb:
+		return parseIMDbExport(data)
+	case ImportFormatLetterboxd:
+		return parseLetterboxdExport(data)
+	case ImportFormatTrakt:
+		return parseTraktExport(data)
+	default:
+		return nil, fmt.Errorf("unsupported import format %q", format)
+	}
+}
+
+// csvColumns maps lowercased header names to their index
+func csvColumns(header []string) map[string]int {
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	return columns
+}
+
+// readCSVExport reads a CSV export and calls row for each record with a lookup
+// function for its columns. The required columns must be in the header.
+func readCSVExport(data []byte, required []string, row func(line int, field func(name string) string)) error {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	columns := csvColumns(header)
+	for _, name := range required {
+		if _, ok := columns[name]; !ok {
+			return fmt.Errorf("missing column %q", name)
+		}
+	}
+
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read CSV line %d: %w", line, err)
+		}
+
+		row(line, func(name string) string {
+			i, ok := columns[name]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		})
+	}
+}
+
+func parseIMDbExport(data []byte) ([]ImportRow, error) {
+	var rows []ImportRow
+	err := readCSVExport(data, []string{"const", "title"}, func(line int, field func(string) string) {
+		year, _ := strconv.Atoi(field("year"))
+		rows = append(rows, ImportRow{
+			Line:      line,
+			Title:     field("title"),
+			Year:      year,
+			MediaType: imdbTitleTypes[strings.ToLower(field("title type"))],
+			IMDbID:    field("const"),
+		})
+	})
+	return rows, err
+}
+
+func parseLetterboxdExport(data []byte) ([]ImportRow, error) {
+	var rows []ImportRow
+	err := readCSVExport(data, []string{"name"}, func(line int, field func(string) string) {
+		year, _ := strconv.Atoi(field("year"))
+		rows = append(rows, ImportRow{
+			Line:      line,
+			Title:     field("name"),
+			Year:      year,
+			MediaType: "movie", // Letterboxd only lists films
+		})
+	})
+	return rows, err
+}
+
+// traktItem is an entry of a Trakt watchlist export
+type traktItem struct {
+	Type  string      `json:"type"` // movie, show, season or episode
+	Movie *traktMedia `json:"movie"`
+	Show  *traktMedia `json:"show"`
+}
+
+type traktMedia struct {
+	Title string `json:"title"`
+	Year  int    `json:"year"`
+	IDs   struct {
+		IMDb string `json:"imdb"`
+		TMDB int    `json:"tmdb"`
+	} `json:"ids"`
+}
+
+func parseTraktExport(data []byte) ([]ImportRow, error) {
+	var items []traktItem
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("failed to parse Trakt export: %w", err)
+	}
+
+	var rows []ImportRow
+	for i, item := range items {
+		// Seasons and episodes are imported as their show
+		media, mediaType := item.Show, "tv"
+		if item.Type == "movie" {
+			media, mediaType = item.Movie, "movie"
+		}
+		if media == nil {
+			continue
+		}
+
+		rows = append(rows, ImportRow{
+			Line:      i + 1,
+			Title:     media.Title,
+			Year:      media.Year,
+			MediaType: mediaType,
+			IMDbID:    media.IDs.IMDb,
+			TMDBId:    media.IDs.TMDB,
+		})
+	}
+	return rows, nil
+}
+
+// ImportService resolves exported watchlists to TMDB titles
+type ImportService struct {
+	tmdbService TMDBServiceInterface
+	plexService PlexServiceInterface
+	maxRows     int
+}
+
+// NewImportService creates an import service. IMPORT_MAX_ROWS limits the size of an export.
+func NewImportService(tmdbService TMDBServiceInterface, plexService PlexServiceInterface) (*ImportService, error) {
+	maxRows, err := envInt("IMPORT_MAX_ROWS", 500)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ImportService{
+		tmdbService: tmdbService,
+		plexService: plexService,
+		maxRows:     maxRows,
+	}, nil
+}
+
+// CheckRows returns ErrImportTooManyRows when an import has more than IMPORT_MAX_ROWS rows
+func (s *ImportService) CheckRows(rows int) error {
+	if s.maxRows > 0 && rows > s.maxRows {
+		return fmt.Errorf("%w: %d rows, at most %d are allowed", ErrImportTooManyRows, rows, s.maxRows)
+	}
+	return nil
+}
+
+// Preview resolves the rows to TMDB titles and checks which are already in Plex.
+// Rows are matched by IMDb ID first, then by TMDB ID, then by title and year.
+func (s *ImportService) Preview(ctx context.Context, rows []ImportRow) (*ImportPreview, error) {
+	if err := s.CheckRows(len(rows)); err != nil {
+		return nil, err
+	}
+
+	results := make([]*TMDBResult, len(rows))
+	reasons := make([]string, len(rows))
+
+	var mu sync.Mutex
+	var firstErr error
+	var wg sync.WaitGroup
+	jobs := make(chan int)
+	for i := 0; i < importConcurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range jobs {
+				result, reason, err := s.resolve(ctx, rows[index])
+				mu.Lock()
+				if err != nil && firstErr == nil {
+					firstErr = err
+				}
+				results[index], reasons[index] = result, reason
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for i := range rows {
+		if ctx.Err() != nil {
+			break
+		}
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Check Plex for every match at once
+	var inPlex map[MediaRef]bool
+	if s.plexService != nil {
+		var refs []MediaRef
+		for _, result := range results {
+			if result != nil {
+				refs = append(refs, result.MediaRef())
+			}
+		}
+		inPlex = s.plexService.CheckManyExistContext(ctx, refs)
+	}
+
+	preview := &ImportPreview{
+		Matched:   []ImportMatch{},
+		InPlex:    []ImportMatch{},
+		Unmatched: []ImportUnmatched{},
+	}
+	seen := make(map[string]bool)
+	for i, row := range rows {
+		result := results[i]
+		if result == nil {
+			preview.Unmatched = append(preview.Unmatched, ImportUnmatched{Row: row, Reason: reasons[i]})
+			continue
+		}
+
+		// Exports can list a title more than once
+		key := fmt.Sprintf("%s:%d", result.MediaType, result.ID)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		match := ImportMatch{Row: row, Result: *result}
+		if inPlex[result.MediaRef()] {
+			match.Result.InPlex = true
+			preview.InPlex = append(preview.InPlex, match)
+		} else {
+			preview.Matched = append(preview.Matched, match)
+		}
+	}
+
+	return preview, nil
+}
+
+// resolve finds the TMDB title of a row. It returns the reason when there is no
+// match, and an error only when TMDB can't be reached.
+func (s *ImportService) resolve(ctx context.Context, row ImportRow) (*TMDBResult, string, error) {
+	lookupFailed := false
+
+	if row.IMDbID != "" {
+		found, err := s.tmdbService.FindByIMDbIDContext(ctx, row.IMDbID)
+		if err != nil {
+			if isUnreachable(ctx, err) {
+				return nil, "", err
+			}
+			lookupFailed = true
+		} else if result := findResultForRow(found, row); result != nil {
+			return result, "", nil
+		}
+	}
+
+	if row.TMDBId != 0 {
+		var result TMDBResult
+		var err error
+		switch row.MediaType {
+		case "movie":
+			var movie *TMDBMovieDetails
+			if movie, err = s.tmdbService.GetMovieDetailsContext(ctx, row.TMDBId); err == nil {
+				result = movie.Result()
+			}
+		case "tv":
+			var tv *TMDBTVDetails
+			if tv, err = s.tmdbService.GetTVDetailsContext(ctx, row.TMDBId); err == nil {
+				result = tv.Result()
+			}
+		}
+		if err != nil {
+			if isUnreachable(ctx, err) {
+				return nil, "", err
+			}
+			lookupFailed = true
+		} else if result.ID != 0 {
+			return &result, "", nil
+		}
+	}
+
+	if row.Title != "" {
+		search, err := s.tmdbService.SearchMultiContext(ctx, row.Title, 1)
+		if err != nil {
+			if isUnreachable(ctx, err) {
+				return nil, "", err
+			}
+			lookupFailed = true
+		} else if result := searchResultForRow(search.Results, row); result != nil {
+			return result, "", nil
+		}
+	}
+
+	if lookupFailed {
+		return nil, "TMDB lookup failed", nil
+	}
+	if row.Title == "" && row.IMDbID == "" && row.TMDBId == 0 {
+		return nil, "no title or ID", nil
+	}
+	return nil, "not found on TMDB", nil
+}
+
+// isUnreachable reports whether err means TMDB can't be used right now, as
+// opposed to a lookup that failed for this row only
+func isUnreachable(ctx context.Context, err error) bool {
+	return ctx.Err() != nil || errors.Is(err, ErrUpstreamRateLimited) || errors.Is(err, ErrUpstreamUnavailable)
+}
+
+// findResultForRow picks the /find result matching the row's media type
+func findResultForRow(found *TMDBFindResult, row ImportRow) *TMDBResult {
+	if row.MediaType != "tv" && len(found.MovieResults) > 0 {
+		return &found.MovieResults[0]
+	}
+	if row.MediaType != "movie" && len(found.TVResults) > 0 {
+		return &found.TVResults[0]
+	}
+	return nil
+}
+
+// searchResultForRow picks the first movie or TV result with the row's title
+// (ignoring case and punctuation) and year. A year off by one is accepted when nothing matches exactly, since
+// exports and TMDB don't always agree on the release year.
+func searchResultForRow(results []TMDBResult, row ImportRow) *TMDBResult {
+	var nearby *TMDBResult
+	for i := range results {
+		result := &results[i]
+		if result.MediaType != "movie" && result.MediaType != "tv" {
+			continue
+		}
+		if row.MediaType != "" && result.MediaType != row.MediaType {
+			continue
+		}
+		title := result.Title
+		if result.MediaType == "tv" {
+			title = result.Name
+		}
+		if importTitleKey(title) != importTitleKey(row.Title) {
+			continue
+		}
+
+		year := result.Year()
+		if row.Year == 0 || year == row.Year {
+			return result
+		}
+		if nearby == nil && (year == row.Year-1 || year == row.Year+1) {
+			nearby = result
+		}
+	}
+	return nearby
+}
+
+// importTitleKey reduces a title to its lowercased letters and digits
+func importTitleKey(title string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, title)
+}
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jacob-fain/MRS/internal/testutil"
+)
+
+const imdbExport = "\xef\xbb\xbfPosition,Const,Created,Modified,Description,Title,Original Title,URL,Title Type,IMDb Rating,Runtime (mins),Year,Genres,Num Votes,Release Date,Directors\n" +
+	`1,tt1375666,2024-01-01,2024-01-01,,Inception,Inception,https://www.imdb.com/title/tt1375666/,Movie,8.8,148,2010,"Action, Sci-Fi",2500000,2010-07-08,Christopher Nolan` + "\n" +
+	`2,tt0903747,2024-01-02,2024-01-02,,Breaking Bad,Breaking Bad,https://www.imdb.com/title/tt0903747/,TV Series,9.5,49,2008,"Crime, Drama",2100000,2008-01-20,` + "\n"
+
+const letterboxdExport = `Date,Name,Year,Letterboxd URI
+2024-01-01,Paris Texas,1984,https://boxd.it/1Ysi
+2024-01-02,"Portrait of a Lady on Fire",2019,https://boxd.it/hTha
+`
+
+const traktExport = `[
+	{"rank": 1, "type": "movie", "movie": {"title": "Inception", "year": 2010, "ids": {"trakt": 16662, "imdb": "tt1375666", "tmdb": 27205}}},
+	{"rank": 2, "type": "show", "show": {"title": "Severance", "year": 2022, "ids": {"trakt": 154997, "tmdb": 95396}}},
+	{"rank": 3, "type": "season", "season": {"number": 2}, "show": {"title": "Severance", "year": 2022, "ids": {"tmdb": 95396}}}
+]`
+
+func TestDetectImportFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		expected ImportFormat
+		wantErr  bool
+	}{
+		{name: "IMDb", data: imdbExport, expected: ImportFormatIMDb},
+		{name: "Letterboxd", data: letterboxdExport, expected: ImportFormatLetterboxd},
+		{name: "Trakt", data: traktExport, expected: ImportFormatTrakt},
+		{name: "unknown CSV", data: "title,year\nInception,2010\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			format, err := DetectImportFormat([]byte(tt.data))
+			if tt.wantErr {
+				testutil.AssertError(t, err)
+				return
+			}
+			testutil.AssertNoError(t, err)
+			testutil.AssertEqual(t, tt.expected, format)
+		})
+	}
+}
+
+func TestParseImport(t *testing.T) {
+	t.Run("IMDb", func(t *testing.T) {
+		rows, err := ParseImport(ImportFormatIMDb, []byte(imdbExport))
+		testutil.AssertNoError(t, err)
+		testutil.AssertEqual(t, 2, len(rows))
+		testutil.AssertEqual(t, ImportRow{Line: 2, Title: "Inception", Year: 2010, MediaType: "movie", IMDbID: "tt1375666"}, rows[0])
+		testutil.AssertEqual(t, "tv", rows[1].MediaType)
+	})
+
+	t.Run("Letterboxd", func(t *testing.T) {
+		rows, err := ParseImport(ImportFormatLetterboxd, []byte(letterboxdExport))
+		testutil.AssertNoError(t, err)
+		testutil.AssertEqual(t, 2, len(rows))
+		testutil.AssertEqual(t, ImportRow{Line: 3, Title: "Portrait of a Lady on Fire", Year: 2019, MediaType: "movie"}, rows[1])
+	})
+
+	t.Run("Trakt", func(t *testing.T) {
+		rows, err := ParseImport(ImportFormatTrakt, []byte(traktExport))
+		testutil.AssertNoError(t, err)
+		testutil.AssertEqual(t, 3, len(rows))
+		testutil.AssertEqual(t, ImportRow{Line: 1, Title: "Inception", Year: 2010, MediaType: "movie", IMDbID: "tt1375666", TMDBId: 27205}, rows[0])
+		testutil.AssertEqual(t, ImportRow{Line: 3, Title: "Severance", Year: 2022, MediaType: "tv", TMDBId: 95396}, rows[2])
+	})
+
+	t.Run("missing column", func(t *testing.T) {
+		_, err := ParseImport(ImportFormatIMDb, []byte(letterboxdExport))
+		testutil.AssertErrorContains(t, err, `missing column "const"`)
+	})
+
+	t.Run("invalid JSON", func(t *testing.T) {
+		_, err := ParseImport(ImportFormatTrakt, []byte(`{"movie": {}}`))
+		testutil.AssertError(t, err)
+	})
+}
+
+// importTMDBService resolves a fixed set of titles
+type importTMDBService struct {
+	TMDBServiceInterface
+	found    map[string]TMDBFindResult
+	searches map[string][]TMDBResult
+	tv       map[int]TMDBTVDetails
+}
+
+func (s *importTMDBService) FindByIMDbIDContext(ctx context.Context, imdbID string) (*TMDBFindResult, error) {
+	found := s.found[imdbID]
+	return &found, nil
+}
+
+func (s *importTMDBService) SearchMultiContext(ctx context.Context, query string, page int) (*TMDBSearchResult, error) {
+	return &TMDBSearchResult{Page: page, Results: s.searches[query]}, nil
+}
+
+func (s *importTMDBService) GetTVDetailsContext(ctx context.Context, tvID int) (*TMDBTVDetails, error) {
+	tv := s.tv[tvID]
+	return &tv, nil
+}
+
+func (s *importTMDBService) GetMovieDetailsContext(ctx context.Context, movieID int) (*TMDBMovieDetails, error) {
+	return &TMDBMovieDetails{}, nil
+}
+
+func TestImportService_Preview(t *testing.T) {
+	tmdb := &importTMDBService{
+		found: map[string]TMDBFindResult{
+			"tt1375666": {MovieResults: []TMDBResult{{ID: 27205, MediaType: "movie", Title: "Inception", ReleaseDate: "2010-07-15"}}},
+		},
+		searches: map[string][]TMDBResult{
+			"Paris Texas": {
+				{ID: 1, MediaType: "person", Name: "Paris Texas"},
+				{ID: 655, MediaType: "movie", Title: "Paris, Texas", ReleaseDate: "1984-05-19"},
+			},
+			"Dune": {
+				{ID: 841, MediaType: "movie", Title: "Dune", ReleaseDate: "1984-12-14"},
+				{ID: 438631, MediaType: "movie", Title: "Dune", ReleaseDate: "2021-09-15"},
+			},
+		},
+		tv: map[int]TMDBTVDetails{
+			95396: {ID: 95396, Name: "Severance", FirstAirDate: "2022-02-17"},
+		},
+	}
+	plex := &forYouPlexService{inPlex: map[string]bool{"Dune": true}}
+
+	service := &ImportService{tmdbService: tmdb, plexService: plex, maxRows: 10}
+
+	rows := []ImportRow{
+		{Line: 1, Title: "Inception", Year: 2010, MediaType: "movie", IMDbID: "tt1375666"},
+		{Line: 2, Title: "Severance", Year: 2022, MediaType: "tv", TMDBId: 95396},
+		{Line: 3, Title: "Paris Texas", Year: 1984, MediaType: "movie"},
+		{Line: 4, Title: "Dune", Year: 2021, MediaType: "movie"},
+		{Line: 5, Title: "Inception", Year: 2010, MediaType: "movie", IMDbID: "tt1375666"},
+		{Line: 6, Title: "Nothing Like It", Year: 2001, MediaType: "movie"},
+	}
+
+	preview, err := service.Preview(context.Background(), rows)
+	testutil.AssertNoError(t, err)
+
+	// Duplicate rows are listed once, title search ignores punctuation
+	testutil.AssertEqual(t, 3, len(preview.Matched))
+	testutil.AssertEqual(t, 27205, preview.Matched[0].Result.ID)
+	testutil.AssertEqual(t, "Severance", preview.Matched[1].Result.Name)
+	testutil.AssertEqual(t, "tv", preview.Matched[1].Result.MediaType)
+	testutil.AssertEqual(t, 655, preview.Matched[2].Result.ID)
+
+	// Title search picks the result with the right year
+	testutil.AssertEqual(t, 1, len(preview.InPlex))
+	testutil.AssertEqual(t, 438631, preview.InPlex[0].Result.ID)
+	testutil.AssertEqual(t, true, preview.InPlex[0].Result.InPlex)
+
+	testutil.AssertEqual(t, 1, len(preview.Unmatched))
+	testutil.AssertEqual(t, 6, preview.Unmatched[0].Row.Line)
+	testutil.AssertEqual(t, "not found on TMDB", preview.Unmatched[0].Reason)
+
+	_, err = service.Preview(context.Background(), make([]ImportRow, 11))
+	testutil.AssertTrue(t, errors.Is(err, ErrImportTooManyRows), "expected ErrImportTooManyRows")
+}
+
+func TestImportService_Preview_WithoutPlex(t *testing.T) {
+	tmdb := &importTMDBService{
+		found: map[string]TMDBFindResult{
+			"tt1375666": {MovieResults: []TMDBResult{{ID: 27205, MediaType: "movie", Title: "Inception", ReleaseDate: "2010-07-15"}}},
+		},
+	}
+	rows := []ImportRow{{Line: 1, Title: "Inception", Year: 2010, MediaType: "movie", IMDbID: "tt1375666"}}
+
+	var notConfigured *PlexService
+	for _, plexService := range []PlexServiceInterface{nil, notConfigured} {
+		service, err := NewImportService(tmdb, plexService)
+		testutil.AssertNoError(t, err)
+
+		preview, err := service.Preview(context.Background(), rows)
+		testutil.AssertNoError(t, err)
+		testutil.AssertEqual(t, 1, len(preview.Matched))
+		testutil.AssertEqual(t, 0, len(preview.InPlex))
+	}
+}
@@ -3,6 +3,7 @@ package services
 import (
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/jacob-fain/MRS/internal/models"
 	"gorm.io/gorm"
@@ -25,6 +26,16 @@ func (s *RequestService) GetPendingRequests() ([]models.Request, error) {
 	return requests, err
 }
 
+// GetPendingRequestsCreatedBefore returns pending requests created before the cutoff, oldest first
+func (s *RequestService) GetPendingRequestsCreatedBefore(cutoff time.Time) ([]models.Request, error) {
+	var requests []models.Request
+	err := s.db.Preload("User").
+		Where("status = ? AND created_at < ?", models.StatusPending, cutoff).
+		Order("created_at ASC").
+		Find(&requests).Error
+	return requests, err
+}
+
 // GetApprovedRequests returns all approved requests ready for download
 func (s *RequestService) GetApprovedRequests() ([]models.Request, error) {
 	var requests []models.Request
@@ -136,9 +147,11 @@ func (s *RequestService) ProcessRequestQueue() error {
 	return nil
 }
 
-// CleanupOldRequests removes completed/rejected requests older than specified days
+// CleanupOldRequests removes completed/rejected requests older than specified days.
+// The cutoff is computed in Go so the query works on both Postgres and SQLite.
 func (s *RequestService) CleanupOldRequests(daysOld int) error {
-	return s.db.Where("status IN ? AND updated_at < NOW() - INTERVAL ? DAY",
-		[]models.RequestStatus{models.StatusCompleted, models.StatusRejected}, daysOld).
+	cutoff := time.Now().AddDate(0, 0, -daysOld)
+	return s.db.Where("status IN ? AND updated_at < ?",
+		[]models.RequestStatus{models.StatusCompleted, models.StatusRejected}, cutoff).
 		Delete(&models.Request{}).Error
 }
\ No newline at end of file
@@ -2,6 +2,7 @@ package services
 
 import (
 	"testing"
+	"time"
 
 	"github.com/jacob-fain/MRS/internal/models"
 	"github.com/jacob-fain/MRS/internal/testutil"
@@ -203,4 +204,24 @@ func TestRequestService_GetRequestQueue(t *testing.T) {
 	// Verify FIFO order (first approved should be first in queue)
 	testutil.AssertEqual(t, "Approved First", queue[0].Title)
 	testutil.AssertEqual(t, "Approved Second", queue[1].Title)
-}
\ No newline at end of file
+}
+func TestRequestService_CleanupOldRequests(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	service := NewRequestService(db)
+
+	user := testutil.CreateTestUser(t, db, "user@example.com", "user", "pass", false)
+
+	old := time.Now().AddDate(0, 0, -60)
+	oldRejected := testutil.CreateTestRequest(t, db, user.ID, "Old Rejected", models.MediaTypeMovie)
+	db.Model(oldRejected).UpdateColumns(map[string]interface{}{"status": models.StatusRejected, "updated_at": old})
+	oldPending := testutil.CreateTestRequest(t, db, user.ID, "Old Pending", models.MediaTypeMovie)
+	db.Model(oldPending).UpdateColumn("updated_at", old)
+
+	err := service.CleanupOldRequests(30)
+	testutil.AssertNoError(t, err)
+
+	var remaining []models.Request
+	db.Find(&remaining)
+	testutil.AssertEqual(t, 1, len(remaining))
+	testutil.AssertEqual(t, "Old Pending", remaining[0].Title)
+}
@@ -0,0 +1,204 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/jacob-fain/MRS/internal/models"
+	"gorm.io/gorm"
+)
+
+// RetentionTarget identifies the table a retention policy applies to
+type RetentionTarget string
+
+const (
+	RetentionTargetRequests  RetentionTarget = "requests"
+	RetentionTargetAuditLogs RetentionTarget = "audit_logs"
+)
+
+// retentionBatchSize is how many records are purged per statement, keeping
+// queries well below Postgres's bind parameter limit
+const retentionBatchSize = 1000
+
+// maxRetentionReportIDs caps the record IDs stored with a report
+const maxRetentionReportIDs = 1000
+
+// RetentionPolicy describes which records to purge and after how long
+type RetentionPolicy struct {
+	Name       string
+	Target     RetentionTarget
+	Statuses   []models.RequestStatus // Only used for request policies
+	MaxAgeDays int
+}
+
+// RetentionService purges old requests and audit logs according to policies
+type RetentionService struct {
+	db           *gorm.DB
+	auditService *AuditService
+	policies     []RetentionPolicy
+	dryRun       bool
+	batchSize    int // Defaults to retentionBatchSize
+}
+
+// NewRetentionService creates a retention service configured from the environment.
+// A policy with a max age of 0 days is disabled. Runs only report what they would
+// purge until RETENTION_DRY_RUN is set to false. Purged audit logs are hidden
+// behind a marker entry written with auditService.
+func NewRetentionService(db *gorm.DB, auditService *AuditService) (*RetentionService, error) {
+	completedDays, err := envInt("RETENTION_COMPLETED_DAYS", 180)
+	if err != nil {
+		return nil, err
+	}
+	rejectedDays, err := envInt("RETENTION_REJECTED_DAYS", 180)
+	if err != nil {
+		return nil, err
+	}
+	auditLogDays, err := envInt("RETENTION_AUDIT_LOG_DAYS", 365)
+	if err != nil {
+		return nil, err
+	}
+
+	policies := []RetentionPolicy{
+		{
+			Name:       "completed-requests",
+			Target:     RetentionTargetRequests,
+			Statuses:   []models.RequestStatus{models.StatusCompleted},
+			MaxAgeDays: completedDays,
+		},
+		{
+			Name:       "rejected-requests",
+			Target:     RetentionTargetRequests,
+			Statuses:   []models.RequestStatus{models.StatusRejected},
+			MaxAgeDays: rejectedDays,
+		},
+		{
+			Name:       "audit-logs",
+			Target:     RetentionTargetAuditLogs,
+			MaxAgeDays: auditLogDays,
+		},
+	}
+
+	return &RetentionService{
+		db:           db,
+		auditService: auditService,
+		policies:     policies,
+		dryRun:       os.Getenv("RETENTION_DRY_RUN") != "false",
+	}, nil
+}
+
+// Run applies every enabled policy and records a report for each one
+func (s *RetentionService) Run(now time.Time) ([]models.RetentionReport, error) {
+	var reports []models.RetentionReport
+	for _, policy := range s.policies {
+		if policy.MaxAgeDays <= 0 {
+			continue
+		}
+
+		report := s.apply(policy, now)
+		if err := s.db.Create(&report).Error; err != nil {
+			return reports, fmt.Errorf("failed to save retention report for %s: %w", policy.Name, err)
+		}
+
+		log.Printf("Retention policy %s: matched=%d purged=%d dry_run=%t cutoff=%s",
+			report.Policy, report.Matched, report.Purged, report.DryRun, report.Cutoff.Format(time.RFC3339))
+
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+// apply runs a single policy. Errors are captured in the report rather than
+// returned so one failing policy does not stop the others.
+func (s *RetentionService) apply(policy RetentionPolicy, now time.Time) models.RetentionReport {
+	cutoff := now.AddDate(0, 0, -policy.MaxAgeDays)
+	report := models.RetentionReport{
+		Policy: policy.Name,
+		Target: string(policy.Target),
+		Cutoff: cutoff,
+		DryRun: s.dryRun,
+	}
+
+	var query *gorm.DB
+	switch policy.Target {
+	case RetentionTargetRequests:
+		query = s.db.Model(&models.Request{}).Where("status IN ? AND updated_at < ?", policy.Statuses, cutoff)
+	case RetentionTargetAuditLogs:
+		// Marker entries are kept, they vouch for the entries they hid
+		query = s.db.Model(&models.AuditLog{}).Where("created_at < ? AND entity_type <> ?", cutoff, models.AuditEntityAuditLog)
+	default:
+		report.Error = fmt.Sprintf("unknown retention target %q", policy.Target)
+		return report
+	}
+
+	var ids []uint
+	if err := query.Order("id").Pluck("id", &ids).Error; err != nil {
+		report.Error = err.Error()
+		return report
+	}
+	report.Matched = int64(len(ids))
+	reportIDs := ids
+	if len(reportIDs) > maxRetentionReportIDs {
+		reportIDs = reportIDs[:maxRetentionReportIDs]
+	}
+	if idsJSON, err := json.Marshal(reportIDs); err == nil {
+		report.RecordIDs = string(idsJSON)
+	}
+
+	if s.dryRun || len(ids) == 0 {
+		return report
+	}
+
+	batchSize := s.batchSize
+	if batchSize <= 0 {
+		batchSize = retentionBatchSize
+	}
+	for start := 0; start < len(ids); start += batchSize {
+		batch := ids[start:min(start+batchSize, len(ids))]
+		purged, err := s.purge(policy, batch)
+		report.Purged += purged
+		if err != nil {
+			report.Error = err.Error()
+			return report
+		}
+	}
+
+	return report
+}
+
+// purge deletes a batch of a policy's records. Audit logs are hidden behind one
+// marker entry per batch.
+func (s *RetentionService) purge(policy RetentionPolicy, ids []uint) (int64, error) {
+	switch policy.Target {
+	case RetentionTargetRequests:
+		result := s.db.Where("id IN ?", ids).Delete(&models.Request{})
+		return result.RowsAffected, result.Error
+	case RetentionTargetAuditLogs:
+		notes := fmt.Sprintf("Retention policy %s hid %d audit logs", policy.Name, len(ids))
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			return s.auditService.HideEntries(tx, ids, nil, notes)
+		})
+		if err != nil {
+			return 0, err
+		}
+		return int64(len(ids)), nil
+	default:
+		return 0, fmt.Errorf("unknown retention target %q", policy.Target)
+	}
+}
+
+// envInt reads an integer environment variable, falling back to a default
+func envInt(key string, defaultValue int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be an integer: %w", key, err)
+	}
+	return parsed, nil
+}
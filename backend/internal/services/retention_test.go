@@ -0,0 +1,194 @@
+package services
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jacob-fain/MRS/internal/models"
+	"github.com/jacob-fain/MRS/internal/testutil"
+)
+
+func TestNewRetentionService(t *testing.T) {
+	os.Setenv("RETENTION_COMPLETED_DAYS", "30")
+	defer os.Unsetenv("RETENTION_COMPLETED_DAYS")
+
+	db := testutil.SetupTestDB(t)
+	service, err := NewRetentionService(db, NewAuditService(db))
+	testutil.AssertNoError(t, err)
+	testutil.AssertEqual(t, true, service.dryRun) // Nothing is purged until an operator opts in
+	testutil.AssertEqual(t, 30, service.policies[0].MaxAgeDays)
+	testutil.AssertEqual(t, 365, service.policies[2].MaxAgeDays)
+
+	os.Setenv("RETENTION_DRY_RUN", "false")
+	defer os.Unsetenv("RETENTION_DRY_RUN")
+	service, err = NewRetentionService(db, NewAuditService(db))
+	testutil.AssertNoError(t, err)
+	testutil.AssertEqual(t, false, service.dryRun)
+
+	os.Setenv("RETENTION_AUDIT_LOG_DAYS", "a year")
+	defer os.Unsetenv("RETENTION_AUDIT_LOG_DAYS")
+	_, err = NewRetentionService(db, NewAuditService(db))
+	testutil.AssertError(t, err)
+}
+
+func TestRetentionService_Run(t *testing.T) {
+	now := time.Now()
+	old := now.AddDate(0, 0, -200)
+
+	tests := []struct {
+		name            string
+		dryRun          bool
+		expectedPurged  int64
+		expectRemaining int64
+	}{
+		{
+			name:            "dry run only reports",
+			dryRun:          true,
+			expectedPurged:  0,
+			expectRemaining: 3,
+		},
+		{
+			name:            "purges old completed requests",
+			dryRun:          false,
+			expectedPurged:  1,
+			expectRemaining: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := testutil.SetupTestDB(t)
+			user := testutil.CreateTestUser(t, db, "user@example.com", "user", "pass", false)
+
+			oldCompleted := testutil.CreateTestRequest(t, db, user.ID, "Old Completed", models.MediaTypeMovie)
+			db.Model(oldCompleted).UpdateColumns(map[string]interface{}{"status": models.StatusCompleted, "updated_at": old})
+
+			oldPending := testutil.CreateTestRequest(t, db, user.ID, "Old Pending", models.MediaTypeMovie)
+			db.Model(oldPending).UpdateColumn("updated_at", old)
+
+			recentCompleted := testutil.CreateTestRequest(t, db, user.ID, "Recent Completed", models.MediaTypeMovie)
+			db.Model(recentCompleted).UpdateColumn("status", models.StatusCompleted)
+
+			service := &RetentionService{
+				db:     db,
+				dryRun: tt.dryRun,
+				policies: []RetentionPolicy{
+					{
+						Name:       "completed-requests",
+						Target:     RetentionTargetRequests,
+						Statuses:   []models.RequestStatus{models.StatusCompleted},
+						MaxAgeDays: 180,
+					},
+					{
+						Name:       "disabled",
+						Target:     RetentionTargetAuditLogs,
+						MaxAgeDays: 0,
+					},
+				},
+			}
+
+			reports, err := service.Run(now)
+			testutil.AssertNoError(t, err)
+			testutil.AssertEqual(t, 1, len(reports))
+			testutil.AssertEqual(t, int64(1), reports[0].Matched)
+			testutil.AssertEqual(t, tt.expectedPurged, reports[0].Purged)
+			testutil.AssertEqual(t, tt.dryRun, reports[0].DryRun)
+			testutil.AssertEqual(t, "", reports[0].Error)
+
+			var remaining int64
+			db.Model(&models.Request{}).Count(&remaining)
+			testutil.AssertEqual(t, tt.expectRemaining, remaining)
+
+			// Reports are persisted
+			var saved int64
+			db.Model(&models.RetentionReport{}).Count(&saved)
+			testutil.AssertEqual(t, int64(1), saved)
+		})
+	}
+}
+
+func TestRetentionService_PurgesAuditLogs(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, db, "user@example.com", "user", "pass", false)
+	req := testutil.CreateTestRequest(t, db, user.ID, "The Matrix", models.MediaTypeMovie)
+
+	// Entries are hashed with their timestamp, so the policy runs later instead
+	// of the entries being backdated
+	auditService := NewAuditService(db)
+	auditService.LogRequestCreated(req.ID, user.ID)
+	later := time.Now().AddDate(2, 0, 0)
+
+	service := &RetentionService{
+		db:           db,
+		auditService: auditService,
+		policies: []RetentionPolicy{
+			{Name: "audit-logs", Target: RetentionTargetAuditLogs, MaxAgeDays: 365},
+		},
+	}
+
+	reports, err := service.Run(later)
+	testutil.AssertNoError(t, err)
+	testutil.AssertEqual(t, int64(1), reports[0].Purged)
+
+	auditService.LogRequestStatusChange(req.ID, &user.ID, models.StatusPending, models.StatusApproved)
+	logs, err := auditService.GetRequestAuditLogs(req.ID)
+	testutil.AssertNoError(t, err)
+	testutil.AssertEqual(t, 1, len(logs))
+	testutil.AssertEqual(t, models.ActionApproved, logs[0].Action)
+
+	// The purge is recorded with a marker entry, which later runs keep
+	report, err := auditService.VerifyChain(nil)
+	testutil.AssertNoError(t, err)
+	testutil.AssertTrue(t, report.Valid, "audit chain should stay valid")
+	testutil.AssertEqual(t, int64(1), report.Deleted)
+
+	reports, err = service.Run(later)
+	testutil.AssertNoError(t, err)
+	testutil.AssertEqual(t, int64(1), reports[0].Purged)
+
+	var markers int64
+	db.Model(&models.AuditLog{}).Where("entity_type = ?", models.AuditEntityAuditLog).Count(&markers)
+	testutil.AssertEqual(t, int64(2), markers)
+
+	report, err = auditService.VerifyChain(nil)
+	testutil.AssertNoError(t, err)
+	testutil.AssertTrue(t, report.Valid, "audit chain should stay valid")
+	testutil.AssertEqual(t, int64(2), report.Deleted)
+}
+
+func TestRetentionService_PurgesInBatches(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, db, "user@example.com", "user", "pass", false)
+
+	auditService := NewAuditService(db)
+	for i := 0; i < 5; i++ {
+		testutil.AssertNoError(t, auditService.LogUserLogin(user.ID))
+	}
+
+	service := &RetentionService{
+		db:           db,
+		auditService: auditService,
+		batchSize:    2,
+		policies: []RetentionPolicy{
+			{Name: "audit-logs", Target: RetentionTargetAuditLogs, MaxAgeDays: 365},
+		},
+	}
+
+	reports, err := service.Run(time.Now().AddDate(2, 0, 0))
+	testutil.AssertNoError(t, err)
+	testutil.AssertEqual(t, "", reports[0].Error)
+	testutil.AssertEqual(t, int64(5), reports[0].Matched)
+	testutil.AssertEqual(t, int64(5), reports[0].Purged)
+
+	// One marker entry per batch
+	var markers []models.AuditLog
+	db.Where("entity_type = ?", models.AuditEntityAuditLog).Order("id").Find(&markers)
+	testutil.AssertEqual(t, 3, len(markers))
+	testutil.AssertEqual(t, "Retention policy audit-logs hid 1 audit logs", markers[2].Notes)
+
+	report, err := auditService.VerifyChain(nil)
+	testutil.AssertNoError(t, err)
+	testutil.AssertTrue(t, report.Valid, "audit chain should stay valid")
+	testutil.AssertEqual(t, int64(5), report.Deleted)
+}
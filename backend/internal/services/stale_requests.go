@@ -0,0 +1,175 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jacob-fain/MRS/internal/models"
+	"gorm.io/gorm"
+)
+
+// StaleRequestService enforces the pending-request SLA: admins are reminded
+// about requests pending for too long, and very old requests are rejected.
+type StaleRequestService struct {
+	db             *gorm.DB
+	requestService *RequestService
+	auditService   *AuditService
+	notifier       Notifier
+	reminderDays   int
+	expiryDays     int
+}
+
+// NewStaleRequestService creates a stale request service configured from the
+// environment. A threshold of 0 days disables that action.
+func NewStaleRequestService(db *gorm.DB, auditService *AuditService, notifier Notifier) (*StaleRequestService, error) {
+	reminderDays, err := envInt("REQUEST_REMINDER_DAYS", 14)
+	if err != nil {
+		return nil, err
+	}
+	expiryDays, err := envInt("REQUEST_EXPIRY_DAYS", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StaleRequestService{
+		db:             db,
+		requestService: NewRequestService(db),
+		auditService:   auditService,
+		notifier:       notifier,
+		reminderDays:   reminderDays,
+		expiryDays:     expiryDays,
+	}, nil
+}
+
+// StaleRequestResult summarizes a single SLA run
+type StaleRequestResult struct {
+	Reminded int
+	Expired  int
+}
+
+// Run expires requests past the expiry threshold, then reminds admins about
+// the remaining requests past the reminder threshold
+func (s *StaleRequestService) Run(now time.Time) (StaleRequestResult, error) {
+	var result StaleRequestResult
+
+	if s.expiryDays > 0 {
+		expired, err := s.expireRequests(now)
+		result.Expired = expired
+		if err != nil {
+			return result, err
+		}
+	}
+
+	if s.reminderDays > 0 {
+		reminded, err := s.remindAdmins(now)
+		result.Reminded = reminded
+		if err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// expireRequests rejects pending requests older than the expiry threshold
+func (s *StaleRequestService) expireRequests(now time.Time) (int, error) {
+	cutoff := now.AddDate(0, 0, -s.expiryDays)
+	requests, err := s.requestService.GetPendingRequestsCreatedBefore(cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get expired requests: %w", err)
+	}
+
+	systemNote := fmt.Sprintf("Automatically rejected: pending for more than %d days", s.expiryDays)
+
+	expired := 0
+	for _, request := range requests {
+		adminNotes := systemNote
+		if request.AdminNotes != "" {
+			adminNotes = request.AdminNotes + "\n\n" + systemNote
+		}
+
+		// Guard on status so a request approved in the meantime is left alone
+		result := s.db.Model(&models.Request{}).
+			Where("id = ? AND status = ?", request.ID, models.StatusPending).
+			Updates(map[string]interface{}{
+				"status":      models.StatusRejected,
+				"admin_notes": adminNotes,
+			})
+		if result.Error != nil {
+			log.Printf("Failed to expire request %d: %v", request.ID, result.Error)
+			continue
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+		expired++
+
+		if s.auditService != nil {
+			if err := s.auditService.LogRequestStatusChange(request.ID, nil, models.StatusPending, models.StatusRejected); err != nil {
+				log.Printf("Failed to log audit entry for expired request %d: %v", request.ID, err)
+			}
+		}
+
+		s.notify(Notification{
+			Type:      NotificationRequestExpired,
+			UserIDs:   []uint{request.UserID},
+			RequestID: request.ID,
+			Subject:   fmt.Sprintf("Your request for %s expired", request.Title),
+			Message:   systemNote,
+		})
+	}
+
+	return expired, nil
+}
+
+// remindAdmins sends one reminder per request past the reminder threshold
+func (s *StaleRequestService) remindAdmins(now time.Time) (int, error) {
+	cutoff := now.AddDate(0, 0, -s.reminderDays)
+	requests, err := s.requestService.GetPendingRequestsCreatedBefore(cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get stale requests: %w", err)
+	}
+
+	reminded := 0
+	for _, request := range requests {
+		if request.ReminderSentAt != nil {
+			continue
+		}
+
+		if err := s.db.Model(&models.Request{}).
+			Where("id = ?", request.ID).
+			UpdateColumn("reminder_sent_at", now).Error; err != nil {
+			log.Printf("Failed to mark reminder for request %d: %v", request.ID, err)
+			continue
+		}
+		reminded++
+
+		if s.auditService != nil {
+			if err := s.auditService.LogRequestStatusChange(request.ID, nil, models.StatusPending, models.StatusPending); err != nil {
+				log.Printf("Failed to log audit entry for request reminder %d: %v", request.ID, err)
+			}
+		}
+
+		days := int(now.Sub(request.CreatedAt).Hours() / 24)
+		s.notify(Notification{
+			Type:      NotificationRequestReminder,
+			ToAdmins:  true,
+			RequestID: request.ID,
+			Subject:   fmt.Sprintf("%s has been pending for %d days", request.Title, days),
+			Message:   fmt.Sprintf("Requested by %s on %s", request.User.Username, request.CreatedAt.Format("2006-01-02")),
+		})
+	}
+
+	return reminded, nil
+}
+
+// notify sends a notification if a notifier is configured
+func (s *StaleRequestService) notify(n Notification) {
+	if s.notifier == nil {
+		return
+	}
+	if err := s.notifier.Notify(n); err != nil {
+		log.Printf("Failed to send %s notification for request %d: %v", n.Type, n.RequestID, err)
+	}
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jacob-fain/MRS/internal/models"
+	"github.com/jacob-fain/MRS/internal/testutil"
+)
+
+// Notifier that records sent notifications
+type recordingNotifier struct {
+	notifications []Notification
+}
+
+func (n *recordingNotifier) Notify(notification Notification) error {
+	n.notifications = append(n.notifications, notification)
+	return nil
+}
+
+func TestStaleRequestService_Run(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	notifier := &recordingNotifier{}
+	now := time.Now()
+
+	service := &StaleRequestService{
+		db:             db,
+		requestService: NewRequestService(db),
+		auditService:   NewAuditService(db),
+		notifier:       notifier,
+		reminderDays:   7,
+		expiryDays:     30,
+	}
+
+	user := testutil.CreateTestUser(t, db, "user@example.com", "user", "pass", false)
+
+	fresh := testutil.CreateTestRequest(t, db, user.ID, "Fresh", models.MediaTypeMovie)
+	stale := testutil.CreateTestRequest(t, db, user.ID, "Stale", models.MediaTypeMovie)
+	db.Model(stale).UpdateColumn("created_at", now.AddDate(0, 0, -10))
+	ancient := testutil.CreateTestRequest(t, db, user.ID, "Ancient", models.MediaTypeMovie)
+	db.Model(ancient).UpdateColumns(map[string]interface{}{"created_at": now.AddDate(0, 0, -45), "admin_notes": "Looking for it"})
+	approved := testutil.CreateTestRequest(t, db, user.ID, "Old Approved", models.MediaTypeMovie)
+	db.Model(approved).UpdateColumns(map[string]interface{}{"created_at": now.AddDate(0, 0, -45), "status": models.StatusApproved})
+
+	result, err := service.Run(now)
+	testutil.AssertNoError(t, err)
+	testutil.AssertEqual(t, 1, result.Expired)
+	testutil.AssertEqual(t, 1, result.Reminded)
+
+	// Ancient request was rejected with a system note appended
+	var expired models.Request
+	db.First(&expired, ancient.ID)
+	testutil.AssertEqual(t, models.StatusRejected, expired.Status)
+	testutil.AssertEqual(t, "Looking for it\n\nAutomatically rejected: pending for more than 30 days", expired.AdminNotes)
+
+	// Stale request was marked as reminded, fresh one untouched
+	var reminded models.Request
+	db.First(&reminded, stale.ID)
+	testutil.AssertEqual(t, models.StatusPending, reminded.Status)
+	testutil.AssertTrue(t, reminded.ReminderSentAt != nil, "reminder_sent_at should be set")
+	var untouched models.Request
+	db.First(&untouched, fresh.ID)
+	testutil.AssertTrue(t, untouched.ReminderSentAt == nil, "fresh request should not be reminded")
+
+	// Both actions are audited as system actions
+	var logs []models.AuditLog
+	db.Where("user_id IS NULL").Order("id ASC").Find(&logs)
+	testutil.AssertEqual(t, 2, len(logs))
+	testutil.AssertEqual(t, models.ActionRejected, logs[0].Action)
+	testutil.AssertEqual(t, ancient.ID, *logs[0].RequestID)
+	testutil.AssertEqual(t, models.ActionReminderSent, logs[1].Action)
+	testutil.AssertEqual(t, stale.ID, *logs[1].RequestID)
+
+	// Requester is told about the expiry, admins about the reminder
+	testutil.AssertEqual(t, 2, len(notifier.notifications))
+	testutil.AssertEqual(t, NotificationRequestExpired, notifier.notifications[0].Type)
+	testutil.AssertEqual(t, user.ID, notifier.notifications[0].UserIDs[0])
+	testutil.AssertEqual(t, NotificationRequestReminder, notifier.notifications[1].Type)
+	testutil.AssertEqual(t, true, notifier.notifications[1].ToAdmins)
+
+	// A second run does not remind again
+	result, err = service.Run(now)
+	testutil.AssertNoError(t, err)
+	testutil.AssertEqual(t, 0, result.Expired)
+	testutil.AssertEqual(t, 0, result.Reminded)
+	testutil.AssertEqual(t, 2, len(notifier.notifications))
+}
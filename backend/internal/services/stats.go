@@ -0,0 +1,394 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/jacob-fain/MRS/internal/models"
+	"gorm.io/gorm"
+)
+
+// StatsInterval is the bucket size of the requests time series
+type StatsInterval string
+
+const (
+	StatsIntervalDay  StatsInterval = "day"
+	StatsIntervalWeek StatsInterval = "week"
+)
+
+// statsDateFormat is the format of bucket dates
+const statsDateFormat = "2006-01-02"
+
+// acceptedStatuses are the statuses of requests an admin approved
+var acceptedStatuses = []models.RequestStatus{models.StatusApproved, models.StatusDownloaded, models.StatusCompleted}
+
+// StatsQuery selects the requests the overview is about, by creation time.
+// From is inclusive and To exclusive.
+type StatsQuery struct {
+	From     time.Time
+	To       time.Time
+	Interval StatsInterval
+	Limit    int // Length of the top requesters and top genres lists
+}
+
+// StatsBucket is the number of requests created in a day or a week
+type StatsBucket struct {
+	Date     string `json:"date"` // First day of the bucket, YYYY-MM-DD
+	Requests int64  `json:"requests"`
+}
+
+// StatsDuration is the median time for requests to reach a status
+type StatsDuration struct {
+	Requests      int    `json:"requests"`       // Requests that reached the status
+	MedianSeconds *int64 `json:"median_seconds"` // Null when no request reached it
+}
+
+// StatsUser is the request activity of a user
+type StatsUser struct {
+	UserID       uint     `json:"user_id"`
+	Username     string   `json:"username"`
+	Requests     int64    `json:"requests"`
+	Approved     int64    `json:"approved"` // Approved, downloaded or completed
+	Rejected     int64    `json:"rejected"`
+	Pending      int64    `json:"pending"`
+	ApprovalRate *float64 `json:"approval_rate"` // Approved out of approved and rejected, null when none were decided
+}
+
+// StatsGenre is the number of requests in a TMDB genre
+type StatsGenre struct {
+	GenreID  int    `json:"genre_id"`
+	Name     string `json:"name,omitempty"`
+	Requests int64  `json:"requests"`
+}
+
+// StatsFulfilment is how many approved requests made it to Plex. Requests are
+// marked completed once they are available in the Plex library.
+type StatsFulfilment struct {
+	Approved  int64    `json:"approved"` // Approved, downloaded or completed
+	Completed int64    `json:"completed"`
+	Rate      *float64 `json:"rate"` // Null when nothing was approved
+}
+
+// StatsOverview is the admin analytics overview
+type StatsOverview struct {
+	From           string          `json:"from"`
+	To             string          `json:"to"`
+	Interval       StatsInterval   `json:"interval"`
+	TotalRequests  int64           `json:"total_requests"`
+	Series         []StatsBucket   `json:"series"`
+	TimeToApprove  StatsDuration   `json:"time_to_approve"`
+	TimeToComplete StatsDuration   `json:"time_to_complete"`
+	TopRequesters  []StatsUser     `json:"top_requesters"`
+	Users          []StatsUser     `json:"users"`
+	TopGenres      []StatsGenre    `json:"top_genres"`
+	PlexFulfilment StatsFulfilment `json:"plex_fulfilment"`
+}
+
+// StatsService aggregates request analytics. Counting is done in SQL that runs
+// on both Postgres and SQLite, medians and week buckets are computed in Go.
+type StatsService struct {
+	db          *gorm.DB
+	tmdbService TMDBServiceInterface
+}
+
+// NewStatsService creates a new stats service. The TMDB service is only used
+// for genre names and may be nil.
+func NewStatsService(db *gorm.DB, tmdbService TMDBServiceInterface) *StatsService {
+	return &StatsService{
+		db:          db,
+		tmdbService: tmdbService,
+	}
+}
+
+// Overview computes the analytics overview of the requests created in the range
+func (s *StatsService) Overview(ctx context.Context, query StatsQuery) (*StatsOverview, error) {
+	overview := &StatsOverview{
+		From:     query.From.UTC().Format(time.RFC3339),
+		To:       query.To.UTC().Format(time.RFC3339),
+		Interval: query.Interval,
+	}
+
+	var err error
+	if overview.Series, overview.TotalRequests, err = s.series(query); err != nil {
+		return nil, fmt.Errorf("failed to count requests: %w", err)
+	}
+	if overview.TimeToApprove, err = s.timeTo(query, models.ActionApproved); err != nil {
+		return nil, fmt.Errorf("failed to compute time to approve: %w", err)
+	}
+	if overview.TimeToComplete, err = s.timeTo(query, models.ActionCompleted); err != nil {
+		return nil, fmt.Errorf("failed to compute time to complete: %w", err)
+	}
+	if overview.Users, err = s.users(query); err != nil {
+		return nil, fmt.Errorf("failed to compute user stats: %w", err)
+	}
+	overview.TopRequesters = overview.Users
+	if len(overview.TopRequesters) > query.Limit {
+		overview.TopRequesters = overview.TopRequesters[:query.Limit]
+	}
+	if overview.TopGenres, err = s.topGenres(ctx, query); err != nil {
+		return nil, fmt.Errorf("failed to compute top genres: %w", err)
+	}
+	if overview.PlexFulfilment, err = s.fulfilment(query); err != nil {
+		return nil, fmt.Errorf("failed to compute Plex fulfilment: %w", err)
+	}
+
+	return overview, nil
+}
+
+// requestsInRange selects the requests created in the range
+func (s *StatsService) requestsInRange(query StatsQuery) *gorm.DB {
+	return s.db.Model(&models.Request{}).
+		Where("requests.created_at >= ? AND requests.created_at < ?", query.From, query.To)
+}
+
+// dayExpression is the UTC day of a timestamp column as YYYY-MM-DD. SQLite
+// converts timestamps with an offset to UTC on its own.
+func (s *StatsService) dayExpression(column string) string {
+	if s.db.Dialector.Name() == "postgres" {
+		return fmt.Sprintf("TO_CHAR(%s AT TIME ZONE 'UTC', 'YYYY-MM-DD')", column)
+	}
+	return fmt.Sprintf("DATE(%s)", column)
+}
+
+// series counts requests per day in SQL, then folds the days into buckets and
+// fills the gaps so every bucket of the range is listed
+func (s *StatsService) series(query StatsQuery) ([]StatsBucket, int64, error) {
+	var days []struct {
+		Day   string
+		Count int64
+	}
+	day := s.dayExpression("requests.created_at")
+	if err := s.requestsInRange(query).
+		Select(day + " AS day, COUNT(*) AS count").
+		Group(day).
+		Scan(&days).Error; err != nil {
+		return nil, 0, err
+	}
+
+	counts := make(map[string]int64, len(days))
+	var total int64
+	for _, d := range days {
+		date, err := time.Parse(statsDateFormat, d.Day)
+		if err != nil {
+			return nil, 0, fmt.Errorf("unexpected day %q: %w", d.Day, err)
+		}
+		counts[bucketStart(date, query.Interval).Format(statsDateFormat)] += d.Count
+		total += d.Count
+	}
+
+	series := []StatsBucket{}
+	last := query.To.Add(-time.Nanosecond)
+	for start := bucketStart(query.From, query.Interval); !start.After(last); start = nextBucket(start, query.Interval) {
+		date := start.Format(statsDateFormat)
+		series = append(series, StatsBucket{Date: date, Requests: counts[date]})
+	}
+	return series, total, nil
+}
+
+// bucketStart returns the UTC day a timestamp's bucket starts on. Weeks start on Monday.
+func bucketStart(t time.Time, interval StatsInterval) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	if interval == StatsIntervalWeek {
+		day = day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	}
+	return day
+}
+
+func nextBucket(start time.Time, interval StatsInterval) time.Time {
+	if interval == StatsIntervalWeek {
+		return start.AddDate(0, 0, 7)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+// timeTo computes the median time between the creation of requests and the
+// first audit entry with the action, e.g. their approval. Requests by admins are
+// approved by their requester when created, those approvals aren't counted.
+func (s *StatsService) timeTo(query StatsQuery, action models.AuditAction) (StatsDuration, error) {
+	var seconds []float64
+	reached := s.requestsInRange(query).
+		Select(s.secondsBetween("requests.created_at", "MIN(audit_logs.created_at)")+" AS seconds").
+		Joins("JOIN audit_logs ON audit_logs.request_id = requests.id AND audit_logs.deleted_at IS NULL").
+		Where("audit_logs.action = ?", action)
+	if action == models.ActionApproved {
+		reached = reached.Where("(audit_logs.user_id IS NULL OR audit_logs.user_id <> requests.user_id)")
+	}
+	if err := reached.
+		Group("requests.id, requests.created_at").
+		Pluck("seconds", &seconds).Error; err != nil {
+		return StatsDuration{}, err
+	}
+
+	result := StatsDuration{Requests: len(seconds)}
+	if len(seconds) == 0 {
+		return result, nil
+	}
+
+	durations := make([]time.Duration, len(seconds))
+	for i, value := range seconds {
+		// SQLite's day fractions are off by microseconds
+		durations[i] = max(time.Duration(math.Round(value*1000))*time.Millisecond, 0)
+	}
+	median := int64(medianDuration(durations).Seconds())
+	result.MedianSeconds = &median
+	return result, nil
+}
+
+// secondsBetween is the number of seconds from one timestamp expression to another
+func (s *StatsService) secondsBetween(from, to string) string {
+	if s.db.Dialector.Name() == "postgres" {
+		return fmt.Sprintf("EXTRACT(EPOCH FROM %s - %s)", to, from)
+	}
+	return fmt.Sprintf("(JULIANDAY(%s) - JULIANDAY(%s)) * 86400", to, from)
+}
+
+func medianDuration(durations []time.Duration) time.Duration {
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	middle := len(durations) / 2
+	if len(durations)%2 == 1 {
+		return durations[middle]
+	}
+	return (durations[middle-1] + durations[middle]) / 2
+}
+
+// users counts the requests of every user with requests in the range, most
+// requests first
+func (s *StatsService) users(query StatsQuery) ([]StatsUser, error) {
+	users := []StatsUser{}
+	if err := s.requestsInRange(query).
+		Select(`requests.user_id, users.username, COUNT(*) AS requests,
+			COUNT(CASE WHEN requests.status IN ? THEN 1 END) AS approved,
+			COUNT(CASE WHEN requests.status = ? THEN 1 END) AS rejected,
+			COUNT(CASE WHEN requests.status = ? THEN 1 END) AS pending`,
+			acceptedStatuses, models.StatusRejected, models.StatusPending).
+		Joins("JOIN users ON users.id = requests.user_id").
+		Group("requests.user_id, users.username").
+		Order("COUNT(*) DESC, requests.user_id").
+		Scan(&users).Error; err != nil {
+		return nil, err
+	}
+
+	for i := range users {
+		users[i].ApprovalRate = rate(users[i].Approved, users[i].Approved+users[i].Rejected)
+	}
+	return users, nil
+}
+
+// topGenres counts requests per genre, with names from TMDB when available
+func (s *StatsService) topGenres(ctx context.Context, query StatsQuery) ([]StatsGenre, error) {
+	genres := []StatsGenre{}
+	if err := s.requestsInRange(query).
+		Select("request_genres.genre_id, COUNT(*) AS requests").
+		Joins("JOIN request_genres ON request_genres.request_id = requests.id").
+		Group("request_genres.genre_id").
+		Order("COUNT(*) DESC, request_genres.genre_id").
+		Limit(query.Limit).
+		Scan(&genres).Error; err != nil {
+		return nil, err
+	}
+
+	if len(genres) == 0 || s.tmdbService == nil {
+		return genres, nil
+	}
+
+	// Movie and TV genres share IDs when they have the same name
+	names := make(map[int]string)
+	for _, mediaType := range []string{"movie", "tv"} {
+		list, err := s.tmdbService.GetGenresContext(ctx, mediaType)
+		if err != nil {
+			log.Printf("Failed to fetch %s genres for stats: %v", mediaType, err)
+			continue
+		}
+		for _, genre := range list {
+			names[genre.ID] = genre.Name
+		}
+	}
+	for i := range genres {
+		genres[i].Name = names[genres[i].GenreID]
+	}
+	return genres, nil
+}
+
+// BackfillGenres stores the TMDB genres of requests created before genres were
+// recorded, so top genres counts them too. Requests TMDB lists no genres for are
+// tried again on the next run. It returns how many requests were filled in.
+func (s *StatsService) BackfillGenres(ctx context.Context) (int, error) {
+	if s.tmdbService == nil {
+		return 0, nil
+	}
+
+	var requests []models.Request
+	if err := s.db.Select("id", "media_type", "tmdb_id").
+		Where("tmdb_id > 0 AND NOT EXISTS (SELECT 1 FROM request_genres WHERE request_genres.request_id = requests.id)").
+		Order("id").
+		Find(&requests).Error; err != nil {
+		return 0, fmt.Errorf("failed to find requests without genres: %w", err)
+	}
+
+	filled := 0
+	for _, request := range requests {
+		if err := ctx.Err(); err != nil {
+			return filled, err
+		}
+
+		var genres []TMDBGenre
+		switch request.MediaType {
+		case models.MediaTypeMovie:
+			movie, err := s.tmdbService.GetMovieDetailsContext(ctx, request.TMDBId)
+			if err != nil {
+				log.Printf("Failed to fetch genres of request %d: %v", request.ID, err)
+				continue
+			}
+			genres = movie.Genres
+		case models.MediaTypeTV:
+			tv, err := s.tmdbService.GetTVDetailsContext(ctx, request.TMDBId)
+			if err != nil {
+				log.Printf("Failed to fetch genres of request %d: %v", request.ID, err)
+				continue
+			}
+			genres = tv.Genres
+		default:
+			continue
+		}
+
+		rows := requestGenres(tmdbGenreIDs(genres))
+		if len(rows) == 0 {
+			continue
+		}
+		for i := range rows {
+			rows[i].RequestID = request.ID
+		}
+		if err := s.db.Create(&rows).Error; err != nil {
+			return filled, fmt.Errorf("failed to store genres of request %d: %w", request.ID, err)
+		}
+		filled++
+	}
+	return filled, nil
+}
+
+// fulfilment counts approved requests and those of them completed
+func (s *StatsService) fulfilment(query StatsQuery) (StatsFulfilment, error) {
+	var result StatsFulfilment
+	if err := s.requestsInRange(query).
+		Select("COUNT(CASE WHEN requests.status IN ? THEN 1 END) AS approved, COUNT(CASE WHEN requests.status = ? THEN 1 END) AS completed",
+			acceptedStatuses, models.StatusCompleted).
+		Scan(&result).Error; err != nil {
+		return StatsFulfilment{}, err
+	}
+	result.Rate = rate(result.Completed, result.Approved)
+	return result, nil
+}
+
+// rate divides part by total, nil when total is 0
+func rate(part, total int64) *float64 {
+	if total == 0 {
+		return nil
+	}
+	r := float64(part) / float64(total)
+	return &r
+}
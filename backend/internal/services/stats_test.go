@@ -0,0 +1,218 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jacob-fain/MRS/internal/models"
+	"github.com/jacob-fain/MRS/internal/testutil"
+	"gorm.io/gorm"
+)
+
+// statsTMDBService returns fixed genres, failing for the TV genre list and movie 404
+type statsTMDBService struct {
+	TMDBServiceInterface
+}
+
+func (s *statsTMDBService) GetGenresContext(ctx context.Context, mediaType string) ([]TMDBGenre, error) {
+	if mediaType == "tv" {
+		return nil, errors.New("TMDB unavailable")
+	}
+	return []TMDBGenre{{ID: 28, Name: "Action"}, {ID: 878, Name: "Science Fiction"}}, nil
+}
+
+func (s *statsTMDBService) GetMovieDetailsContext(ctx context.Context, movieID int) (*TMDBMovieDetails, error) {
+	if movieID == 404 {
+		return nil, errors.New("not found")
+	}
+	return &TMDBMovieDetails{ID: movieID, Genres: []TMDBGenre{{ID: 28, Name: "Action"}}}, nil
+}
+
+func (s *statsTMDBService) GetTVDetailsContext(ctx context.Context, tvID int) (*TMDBTVDetails, error) {
+	return &TMDBTVDetails{ID: tvID, Genres: []TMDBGenre{{ID: 18, Name: "Drama"}, {ID: 18, Name: "Drama"}}}, nil
+}
+
+// createStatsRequest creates a request at the given time with the given genres
+func createStatsRequest(t *testing.T, db *gorm.DB, userID uint, status models.RequestStatus, createdAt time.Time, genreIDs ...int) *models.Request {
+	request := &models.Request{
+		UserID:    userID,
+		Title:     "Request",
+		Year:      2024,
+		MediaType: models.MediaTypeMovie,
+		Status:    status,
+		CreatedAt: createdAt,
+	}
+	for _, id := range genreIDs {
+		request.Genres = append(request.Genres, models.RequestGenre{GenreID: id})
+	}
+	testutil.AssertNoError(t, db.Create(request).Error)
+	return request
+}
+
+// createStatsAuditLog records an action on a request at the given time
+func createStatsAuditLog(t *testing.T, db *gorm.DB, requestID uint, action models.AuditAction, createdAt time.Time) {
+	log := &models.AuditLog{
+		EntityType: models.AuditEntityRequest,
+		EntityID:   requestID,
+		RequestID:  &requestID,
+		Action:     action,
+		CreatedAt:  createdAt,
+	}
+	testutil.AssertNoError(t, db.Create(log).Error)
+}
+
+func TestStatsService_Overview(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	service := NewStatsService(db, &statsTMDBService{})
+
+	alice := testutil.CreateTestUser(t, db, "alice@example.com", "alice", "pass", false)
+	bob := testutil.CreateTestUser(t, db, "bob@example.com", "bob", "pass", false)
+
+	// Monday 2 March to Sunday 15 March 2026
+	from := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 3, 16, 0, 0, 0, 0, time.UTC)
+	day := func(n int, hour int) time.Time { return from.AddDate(0, 0, n).Add(time.Duration(hour) * time.Hour) }
+
+	approved := createStatsRequest(t, db, alice.ID, models.StatusApproved, day(0, 9), 28, 878)
+	completed := createStatsRequest(t, db, alice.ID, models.StatusCompleted, day(0, 18), 28)
+	downloaded := createStatsRequest(t, db, alice.ID, models.StatusDownloaded, day(8, 12), 18)
+	createStatsRequest(t, db, alice.ID, models.StatusRejected, day(9, 23))
+	createStatsRequest(t, db, bob.ID, models.StatusPending, day(8, 1), 28)
+
+	// Outside the range
+	createStatsRequest(t, db, bob.ID, models.StatusCompleted, from.Add(-time.Second), 28)
+	createStatsRequest(t, db, bob.ID, models.StatusCompleted, to, 28)
+
+	createStatsAuditLog(t, db, approved.ID, models.ActionApproved, day(0, 10))
+	createStatsAuditLog(t, db, approved.ID, models.ActionApproved, day(0, 20)) // Only the first approval counts
+	createStatsAuditLog(t, db, completed.ID, models.ActionApproved, day(0, 21))
+	createStatsAuditLog(t, db, completed.ID, models.ActionCompleted, day(2, 18))
+	createStatsAuditLog(t, db, downloaded.ID, models.ActionApproved, day(8, 17))
+
+	overview, err := service.Overview(context.Background(), StatsQuery{From: from, To: to, Interval: StatsIntervalDay, Limit: 1})
+	testutil.AssertNoError(t, err)
+
+	testutil.AssertEqual(t, int64(5), overview.TotalRequests)
+	testutil.AssertEqual(t, 14, len(overview.Series))
+	testutil.AssertEqual(t, StatsBucket{Date: "2026-03-02", Requests: 2}, overview.Series[0])
+	testutil.AssertEqual(t, StatsBucket{Date: "2026-03-03", Requests: 0}, overview.Series[1])
+	testutil.AssertEqual(t, StatsBucket{Date: "2026-03-10", Requests: 2}, overview.Series[8])
+	testutil.AssertEqual(t, StatsBucket{Date: "2026-03-11", Requests: 1}, overview.Series[9])
+	testutil.AssertEqual(t, StatsBucket{Date: "2026-03-15", Requests: 0}, overview.Series[13])
+
+	// Medians of 1h, 3h and 5h to approve, and 48h to complete
+	testutil.AssertEqual(t, 3, overview.TimeToApprove.Requests)
+	testutil.AssertEqual(t, int64(3*60*60), *overview.TimeToApprove.MedianSeconds)
+	testutil.AssertEqual(t, 1, overview.TimeToComplete.Requests)
+	testutil.AssertEqual(t, int64(48*60*60), *overview.TimeToComplete.MedianSeconds)
+
+	testutil.AssertEqual(t, 2, len(overview.Users))
+	testutil.AssertEqual(t, 1, len(overview.TopRequesters))
+	testutil.AssertEqual(t, "alice", overview.TopRequesters[0].Username)
+	testutil.AssertEqual(t, int64(4), overview.Users[0].Requests)
+	testutil.AssertEqual(t, int64(3), overview.Users[0].Approved)
+	testutil.AssertEqual(t, int64(1), overview.Users[0].Rejected)
+	testutil.AssertEqual(t, 0.75, *overview.Users[0].ApprovalRate)
+	testutil.AssertEqual(t, bob.ID, overview.Users[1].UserID)
+	testutil.AssertEqual(t, int64(1), overview.Users[1].Pending)
+	testutil.AssertTrue(t, overview.Users[1].ApprovalRate == nil, "undecided requests have no approval rate")
+
+	testutil.AssertEqual(t, 1, len(overview.TopGenres))
+	testutil.AssertEqual(t, StatsGenre{GenreID: 28, Name: "Action", Requests: 3}, overview.TopGenres[0])
+
+	testutil.AssertEqual(t, int64(3), overview.PlexFulfilment.Approved)
+	testutil.AssertEqual(t, int64(1), overview.PlexFulfilment.Completed)
+	testutil.AssertEqual(t, 1.0/3, *overview.PlexFulfilment.Rate)
+
+	// Weekly buckets start on Monday
+	overview, err = service.Overview(context.Background(), StatsQuery{From: day(1, 0), To: to, Interval: StatsIntervalWeek, Limit: 10})
+	testutil.AssertNoError(t, err)
+	testutil.AssertEqual(t, 2, len(overview.Series))
+	testutil.AssertEqual(t, StatsBucket{Date: "2026-03-02", Requests: 0}, overview.Series[0])
+	testutil.AssertEqual(t, StatsBucket{Date: "2026-03-09", Requests: 3}, overview.Series[1])
+
+	// Genres only TV would name are left unnamed when TV genres fail to load
+	testutil.AssertEqual(t, 2, len(overview.TopGenres))
+	testutil.AssertEqual(t, StatsGenre{GenreID: 18, Requests: 1}, overview.TopGenres[0])
+	testutil.AssertEqual(t, StatsGenre{GenreID: 28, Name: "Action", Requests: 1}, overview.TopGenres[1])
+}
+
+func TestStatsService_Overview_Empty(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	service := NewStatsService(db, nil)
+
+	from := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	overview, err := service.Overview(context.Background(), StatsQuery{From: from, To: from.AddDate(0, 0, 7), Interval: StatsIntervalDay, Limit: 10})
+	testutil.AssertNoError(t, err)
+
+	testutil.AssertEqual(t, int64(0), overview.TotalRequests)
+	testutil.AssertEqual(t, 7, len(overview.Series))
+	testutil.AssertTrue(t, overview.TimeToApprove.MedianSeconds == nil, "no median without approvals")
+	testutil.AssertEqual(t, 0, len(overview.Users))
+	testutil.AssertEqual(t, 0, len(overview.TopGenres))
+	testutil.AssertTrue(t, overview.PlexFulfilment.Rate == nil, "no fulfilment rate without approvals")
+}
+
+func TestStatsService_TimeToApprove_SkipsAutoApprovals(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	service := NewStatsService(db, nil)
+
+	admin := testutil.CreateTestUser(t, db, "admin@example.com", "admin", "pass", true)
+	user := testutil.CreateTestUser(t, db, "user@example.com", "user", "pass", false)
+
+	from := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	own := createStatsRequest(t, db, admin.ID, models.StatusApproved, from)
+	waited := createStatsRequest(t, db, user.ID, models.StatusApproved, from)
+
+	// The admin's request is approved by its requester when created
+	testutil.AssertNoError(t, db.Create(&models.AuditLog{
+		EntityType: models.AuditEntityRequest, EntityID: own.ID, RequestID: &own.ID,
+		UserID: &admin.ID, Action: models.ActionApproved, CreatedAt: from,
+	}).Error)
+	testutil.AssertNoError(t, db.Create(&models.AuditLog{
+		EntityType: models.AuditEntityRequest, EntityID: waited.ID, RequestID: &waited.ID,
+		UserID: &admin.ID, Action: models.ActionApproved, CreatedAt: from.Add(2 * time.Hour),
+	}).Error)
+
+	overview, err := service.Overview(context.Background(), StatsQuery{From: from, To: from.AddDate(0, 0, 1), Interval: StatsIntervalDay, Limit: 10})
+	testutil.AssertNoError(t, err)
+	testutil.AssertEqual(t, 1, overview.TimeToApprove.Requests)
+	testutil.AssertEqual(t, int64(2*60*60), *overview.TimeToApprove.MedianSeconds)
+}
+
+func TestStatsService_BackfillGenres(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	service := NewStatsService(db, &statsTMDBService{})
+	user := testutil.CreateTestUser(t, db, "user@example.com", "user", "pass", false)
+
+	movie := createStatsRequest(t, db, user.ID, models.StatusPending, time.Now())
+	db.Model(movie).Update("tmdb_id", 603)
+	show := createStatsRequest(t, db, user.ID, models.StatusPending, time.Now())
+	db.Model(show).Updates(map[string]interface{}{"tmdb_id": 1399, "media_type": models.MediaTypeTV})
+	missing := createStatsRequest(t, db, user.ID, models.StatusPending, time.Now())
+	db.Model(missing).Update("tmdb_id", 404)
+	tagged := createStatsRequest(t, db, user.ID, models.StatusPending, time.Now(), 878)
+	db.Model(tagged).Update("tmdb_id", 27205)
+	createStatsRequest(t, db, user.ID, models.StatusPending, time.Now()) // No TMDB ID
+
+	filled, err := service.BackfillGenres(context.Background())
+	testutil.AssertNoError(t, err)
+	testutil.AssertEqual(t, 2, filled)
+
+	count := func(requestID uint) int64 {
+		var n int64
+		db.Model(&models.RequestGenre{}).Where("request_id = ?", requestID).Count(&n)
+		return n
+	}
+	testutil.AssertEqual(t, int64(1), count(movie.ID))
+	testutil.AssertEqual(t, int64(1), count(show.ID))
+	testutil.AssertEqual(t, int64(0), count(missing.ID))
+	testutil.AssertEqual(t, int64(1), count(tagged.ID))
+
+	// Filled requests aren't fetched again
+	filled, err = service.BackfillGenres(context.Background())
+	testutil.AssertNoError(t, err)
+	testutil.AssertEqual(t, 0, filled)
+}
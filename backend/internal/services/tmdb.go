@@ -1,25 +1,44 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"log"
 	"net/http"
 	"net/url"
 	"os"
+	"sort"
+	"strings"
 	"time"
 )
 
 const (
-	tmdbBaseURL   = "https://api.themoviedb.org/3"
-	tmdbImageBase = "https://image.tmdb.org/t/p"
+	defaultTMDBBaseURL = "https://api.themoviedb.org/3"
+	tmdbImageBase      = "https://image.tmdb.org/t/p"
 )
 
 // TMDBService handles all TMDB API interactions
 type TMDBService struct {
-	apiKey     string
-	httpClient *http.Client
+	apiKey        string
+	baseURL       string
+	httpClient    *http.Client
+	tracer        TMDBTracer
+	defaultLocale TMDBLocale // Used when the context has no language or region
+	upcomingDays  int        // How far ahead upcoming lists look, 180 when unset
 }
 
+// TMDBTrace describes a single call to the TMDB API
+type TMDBTrace struct {
+	Path       string
+	StatusCode int // 0 when no response was received
+	Duration   time.Duration
+	Err        error
+}
+
+// TMDBTracer is called after every TMDB API call
+type TMDBTracer func(ctx context.Context, trace TMDBTrace)
+
 // NewTMDBService creates a new TMDB service instance
 func NewTMDBService() (*TMDBService, error) {
 	apiKey := os.Getenv("TMDB_API_KEY")
@@ -27,93 +46,103 @@ func NewTMDBService() (*TMDBService, error) {
 		return nil, fmt.Errorf("TMDB_API_KEY environment variable not set")
 	}
 
-	return &TMDBService{
-		apiKey: apiKey,
-		httpClient: &http.Client{
-			Timeout: 10 * time.Second,
-		},
-	}, nil
-}
+	baseURL := os.Getenv("TMDB_BASE_URL")
+	if baseURL == "" {
+		baseURL = defaultTMDBBaseURL
+	}
 
-// SearchMulti searches for movies and TV shows
-func (s *TMDBService) SearchMulti(query string, page int) (*TMDBSearchResult, error) {
-	if query == "" {
-		return nil, fmt.Errorf("search query cannot be empty")
+	// TMDB allows roughly 50 requests per second per IP
+	upstreamConfig, err := upstreamConfigFromEnv("TMDB", UpstreamConfig{
+		RequestsPerSecond: 40,
+		Burst:             20,
+		MaxRetries:        3,
+		BaseBackoff:       250 * time.Millisecond,
+		MaxBackoff:        5 * time.Second,
+		FailureThreshold:  5,
+		OpenDuration:      30 * time.Second,
+		AttemptTimeout:    10 * time.Second,
+		Timeout:           30 * time.Second,
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	params := url.Values{}
-	params.Add("query", query)
-	params.Add("page", fmt.Sprintf("%d", page))
+	// Server-wide locale for users without their own preferences
+	defaultLocale := TMDBLocale{
+		Language: os.Getenv("TMDB_LANGUAGE"),
+		Region:   os.Getenv("TMDB_REGION"),
+	}
+	if err := defaultLocale.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid TMDB_LANGUAGE or TMDB_REGION: %w", err)
+	}
 
-	url := fmt.Sprintf("%s/search/multi?%s", tmdbBaseURL, params.Encode())
-	
-	req, err := http.NewRequest("GET", url, nil)
+	upcomingDays, err := envInt("TMDB_UPCOMING_DAYS", 180)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, err
 	}
-	
-	// Use Bearer token for authentication
-	req.Header.Add("Authorization", "Bearer " + s.apiKey)
-	req.Header.Add("accept", "application/json")
-	
-	resp, err := s.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to search TMDB: %w", err)
+
+	service := &TMDBService{
+		apiKey:        apiKey,
+		baseURL:       strings.TrimSuffix(baseURL, "/"),
+		httpClient:    NewUpstreamHTTPClient("TMDB", upstreamConfig),
+		defaultLocale: defaultLocale,
+		upcomingDays:  upcomingDays,
+	}
+	if os.Getenv("TMDB_TRACE") == "true" {
+		service.tracer = LogTMDBTracer
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("TMDB API returned status %d", resp.StatusCode)
+	return service, nil
+}
+
+// SetTracer sets a function that is called after every TMDB API call
+func (s *TMDBService) SetTracer(tracer TMDBTracer) {
+	s.tracer = tracer
+}
+
+// LogTMDBTracer logs every TMDB API call
+func LogTMDBTracer(ctx context.Context, trace TMDBTrace) {
+	if trace.Err != nil {
+		log.Printf("TMDB GET %s status=%d duration=%s error=%v", trace.Path, trace.StatusCode, trace.Duration, trace.Err)
+		return
 	}
+	log.Printf("TMDB GET %s status=%d duration=%s", trace.Path, trace.StatusCode, trace.Duration)
+}
 
-	var result TMDBSearchResult
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode TMDB response: %w", err)
+// SearchMultiContext searches for movies and TV shows
+func (s *TMDBService) SearchMultiContext(ctx context.Context, query string, page int) (*TMDBSearchResult, error) {
+	if query == "" {
+		return nil, fmt.Errorf("search query cannot be empty")
 	}
 
-	// Process results to add full image URLs
-	for i := range result.Results {
-		if result.Results[i].PosterPath != "" {
-			result.Results[i].PosterURL = s.GetImageURL(result.Results[i].PosterPath, "w342")
-		}
-		if result.Results[i].BackdropPath != "" {
-			result.Results[i].BackdropURL = s.GetImageURL(result.Results[i].BackdropPath, "w780")
-		}
+	params := url.Values{}
+	params.Add("query", query)
+	params.Add("page", fmt.Sprintf("%d", page))
+
+	result, err := tmdbGet[TMDBSearchResult](ctx, s, "/search/multi", params, "search TMDB")
+	if err != nil {
+		return nil, err
 	}
 
-	return &result, nil
+	result.Results = filterAdultResults(result.Results, s.locale(ctx))
+	s.addResultImageURLs(result.Results)
+	return result, nil
 }
 
-// GetMovieDetails fetches detailed information about a movie
-func (s *TMDBService) GetMovieDetails(movieID int) (*TMDBMovieDetails, error) {
+// GetMovieDetailsContext fetches detailed information about a movie
+func (s *TMDBService) GetMovieDetailsContext(ctx context.Context, movieID int) (*TMDBMovieDetails, error) {
 	params := url.Values{}
-	params.Add("append_to_response", "credits,videos,external_ids")
+	params.Add("append_to_response", "credits,videos,external_ids,release_dates")
 
-	url := fmt.Sprintf("%s/movie/%d?%s", tmdbBaseURL, movieID, params.Encode())
-	
-	req, err := http.NewRequest("GET", url, nil)
+	movie, err := tmdbGet[TMDBMovieDetails](ctx, s, fmt.Sprintf("/movie/%d", movieID), params, "get movie details")
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, err
 	}
-	
-	// Use Bearer token for authentication
-	req.Header.Add("Authorization", "Bearer " + s.apiKey)
-	req.Header.Add("accept", "application/json")
-	
-	resp, err := s.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get movie details: %w", err)
-	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("TMDB API returned status %d", resp.StatusCode)
-	}
-
-	var movie TMDBMovieDetails
-	if err := json.NewDecoder(resp.Body).Decode(&movie); err != nil {
-		return nil, fmt.Errorf("failed to decode movie details: %w", err)
-	}
+	// Certification and release date for the user's country
+	country := s.locale(ctx).CertificationCountry()
+	movie.Certification = movie.ReleaseDates.certification(country)
+	movie.LocalReleaseDate = movie.ReleaseDates.releaseDate(country)
 
 	// Add full image URLs
 	if movie.PosterPath != "" {
@@ -122,50 +151,120 @@ func (s *TMDBService) GetMovieDetails(movieID int) (*TMDBMovieDetails, error) {
 	if movie.BackdropPath != "" {
 		movie.BackdropURL = s.GetImageURL(movie.BackdropPath, "w1280")
 	}
+	if movie.BelongsToCollection != nil && movie.BelongsToCollection.PosterPath != "" {
+		movie.BelongsToCollection.PosterURL = s.GetImageURL(movie.BelongsToCollection.PosterPath, "w342")
+	}
 
-	return &movie, nil
+	return movie, nil
 }
 
-// GetTVDetails fetches detailed information about a TV show
-func (s *TMDBService) GetTVDetails(tvID int) (*TMDBTVDetails, error) {
+// GetTVDetailsContext fetches detailed information about a TV show
+func (s *TMDBService) GetTVDetailsContext(ctx context.Context, tvID int) (*TMDBTVDetails, error) {
 	params := url.Values{}
-	params.Add("append_to_response", "credits,videos,external_ids")
+	params.Add("append_to_response", "credits,videos,external_ids,content_ratings")
 
-	url := fmt.Sprintf("%s/tv/%d?%s", tmdbBaseURL, tvID, params.Encode())
-	
-	req, err := http.NewRequest("GET", url, nil)
+	tv, err := tmdbGet[TMDBTVDetails](ctx, s, fmt.Sprintf("/tv/%d", tvID), params, "get TV details")
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, err
 	}
-	
-	// Use Bearer token for authentication
-	req.Header.Add("Authorization", "Bearer " + s.apiKey)
-	req.Header.Add("accept", "application/json")
-	
-	resp, err := s.httpClient.Do(req)
+
+	// Content rating for the user's country
+	tv.Certification = tv.ContentRatings.rating(s.locale(ctx).CertificationCountry())
+
+	// Add full image URLs
+	if tv.PosterPath != "" {
+		tv.PosterURL = s.GetImageURL(tv.PosterPath, "w500")
+	}
+	if tv.BackdropPath != "" {
+		tv.BackdropURL = s.GetImageURL(tv.BackdropPath, "w1280")
+	}
+	for i := range tv.Seasons {
+		if tv.Seasons[i].PosterPath != "" {
+			tv.Seasons[i].PosterURL = s.GetImageURL(tv.Seasons[i].PosterPath, "w342")
+		}
+	}
+
+	return tv, nil
+}
+
+// GetSeasonDetailsContext fetches a TV season with its episodes
+func (s *TMDBService) GetSeasonDetailsContext(ctx context.Context, tvID, seasonNumber int) (*TMDBSeasonDetails, error) {
+	season, err := tmdbGet[TMDBSeasonDetails](ctx, s, fmt.Sprintf("/tv/%d/season/%d", tvID, seasonNumber), nil, "get season details")
 	if err != nil {
-		return nil, fmt.Errorf("failed to get TV details: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("TMDB API returned status %d", resp.StatusCode)
+	// Add full image URLs
+	if season.PosterPath != "" {
+		season.PosterURL = s.GetImageURL(season.PosterPath, "w500")
+	}
+	for i := range season.Episodes {
+		if season.Episodes[i].StillPath != "" {
+			season.Episodes[i].StillURL = s.GetImageURL(season.Episodes[i].StillPath, "w300")
+		}
 	}
 
-	var tv TMDBTVDetails
-	if err := json.NewDecoder(resp.Body).Decode(&tv); err != nil {
-		return nil, fmt.Errorf("failed to decode TV details: %w", err)
+	return season, nil
+}
+
+// GetCollectionContext fetches a movie collection with its parts in release order.
+// Parts without a release date come last.
+func (s *TMDBService) GetCollectionContext(ctx context.Context, collectionID int) (*TMDBCollection, error) {
+	collection, err := tmdbGet[TMDBCollection](ctx, s, fmt.Sprintf("/collection/%d", collectionID), nil, "get collection")
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range collection.Parts {
+		collection.Parts[i].MediaType = "movie"
 	}
+	collection.Parts = filterAdultResults(collection.Parts, s.locale(ctx))
+	sort.SliceStable(collection.Parts, func(i, j int) bool {
+		a, b := collection.Parts[i].ReleaseDate, collection.Parts[j].ReleaseDate
+		if a == "" || b == "" {
+			return a != ""
+		}
+		return a < b
+	})
 
 	// Add full image URLs
-	if tv.PosterPath != "" {
-		tv.PosterURL = s.GetImageURL(tv.PosterPath, "w500")
+	if collection.PosterPath != "" {
+		collection.PosterURL = s.GetImageURL(collection.PosterPath, "w500")
 	}
-	if tv.BackdropPath != "" {
-		tv.BackdropURL = s.GetImageURL(tv.BackdropPath, "w1280")
+	if collection.BackdropPath != "" {
+		collection.BackdropURL = s.GetImageURL(collection.BackdropPath, "w1280")
 	}
+	s.addResultImageURLs(collection.Parts)
 
-	return &tv, nil
+	return collection, nil
+}
+
+// FindByIMDbIDContext looks up the movies and TV shows with an IMDb ID (e.g. tt0111161)
+func (s *TMDBService) FindByIMDbIDContext(ctx context.Context, imdbID string) (*TMDBFindResult, error) {
+	if imdbID == "" {
+		return nil, fmt.Errorf("IMDb ID cannot be empty")
+	}
+
+	params := url.Values{}
+	params.Add("external_source", "imdb_id")
+
+	result, err := tmdbGet[TMDBFindResult](ctx, s, "/find/"+url.PathEscape(imdbID), params, "find by IMDb ID")
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range result.MovieResults {
+		result.MovieResults[i].MediaType = "movie"
+	}
+	for i := range result.TVResults {
+		result.TVResults[i].MediaType = "tv"
+	}
+	result.MovieResults = filterAdultResults(result.MovieResults, s.locale(ctx))
+	result.TVResults = filterAdultResults(result.TVResults, s.locale(ctx))
+	s.addResultImageURLs(result.MovieResults)
+	s.addResultImageURLs(result.TVResults)
+
+	return result, nil
 }
 
 // GetImageURL constructs a full image URL from a path
@@ -184,6 +283,12 @@ type TMDBSearchResult struct {
 	Results      []TMDBResult `json:"results"`
 }
 
+// TMDBFindResult represents the titles matching an external ID
+type TMDBFindResult struct {
+	MovieResults []TMDBResult `json:"movie_results"`
+	TVResults    []TMDBResult `json:"tv_results"`
+}
+
 // TMDBResult represents a single search result
 type TMDBResult struct {
 	ID           int      `json:"id"`
@@ -199,6 +304,7 @@ type TMDBResult struct {
 	VoteCount    int      `json:"vote_count"`
 	Popularity   float64  `json:"popularity"`
 	GenreIDs     []int    `json:"genre_ids"`
+	Adult        bool     `json:"adult,omitempty"`
 	PosterURL    string   `json:"poster_url,omitempty"`   // Added by service
 	BackdropURL  string   `json:"backdrop_url,omitempty"` // Added by service
 	InPlex       bool     `json:"in_plex,omitempty"`      // Added by handler
@@ -223,6 +329,10 @@ type TMDBMovieDetails struct {
 	ExternalIDs      TMDBExternalIDs     `json:"external_ids"`
 	Credits          TMDBCredits         `json:"credits"`
 	Videos           TMDBVideos          `json:"videos"`
+	ReleaseDates     TMDBReleaseDates    `json:"release_dates"`
+	BelongsToCollection *TMDBCollectionRef `json:"belongs_to_collection"`
+	Certification    string              `json:"certification,omitempty"`      // Added by service for the user's region
+	LocalReleaseDate string              `json:"local_release_date,omitempty"` // Added by service for the user's region
 	PosterURL        string              `json:"poster_url,omitempty"`
 	BackdropURL      string              `json:"backdrop_url,omitempty"`
 	InPlex           bool                `json:"in_plex,omitempty"`
@@ -249,11 +359,114 @@ type TMDBTVDetails struct {
 	ExternalIDs      TMDBExternalIDs     `json:"external_ids"`
 	Credits          TMDBCredits         `json:"credits"`
 	Videos           TMDBVideos          `json:"videos"`
+	ContentRatings   TMDBContentRatings  `json:"content_ratings"`
+	Seasons          []TMDBSeason        `json:"seasons"`
+	Certification    string              `json:"certification,omitempty"` // Added by service for the user's region
 	PosterURL        string              `json:"poster_url,omitempty"`
 	BackdropURL      string              `json:"backdrop_url,omitempty"`
 	InPlex           bool                `json:"in_plex,omitempty"`
 }
 
+// Result returns the movie as a search result
+func (m TMDBMovieDetails) Result() TMDBResult {
+	return TMDBResult{
+		ID:           m.ID,
+		MediaType:    "movie",
+		Title:        m.Title,
+		ReleaseDate:  m.ReleaseDate,
+		Overview:     m.Overview,
+		PosterPath:   m.PosterPath,
+		BackdropPath: m.BackdropPath,
+		VoteAverage:  m.VoteAverage,
+		VoteCount:    m.VoteCount,
+		Popularity:   m.Popularity,
+		PosterURL:    m.PosterURL,
+		BackdropURL:  m.BackdropURL,
+	}
+}
+
+// Result returns the TV show as a search result
+func (t TMDBTVDetails) Result() TMDBResult {
+	return TMDBResult{
+		ID:           t.ID,
+		MediaType:    "tv",
+		Name:         t.Name,
+		FirstAirDate: t.FirstAirDate,
+		Overview:     t.Overview,
+		PosterPath:   t.PosterPath,
+		BackdropPath: t.BackdropPath,
+		VoteAverage:  t.VoteAverage,
+		VoteCount:    t.VoteCount,
+		Popularity:   t.Popularity,
+		PosterURL:    t.PosterURL,
+		BackdropURL:  t.BackdropURL,
+	}
+}
+
+// TMDBSeason represents a season in TV show details
+type TMDBSeason struct {
+	ID             int    `json:"id"`
+	Name           string `json:"name"`
+	Overview       string `json:"overview"`
+	AirDate        string `json:"air_date"`
+	EpisodeCount   int    `json:"episode_count"`
+	SeasonNumber   int    `json:"season_number"`
+	PosterPath     string `json:"poster_path"`
+	PosterURL      string `json:"poster_url,omitempty"`       // Added by service
+	InPlex         bool   `json:"in_plex,omitempty"`          // Added by handler
+	EpisodesInPlex int    `json:"episodes_in_plex,omitempty"` // Added by handler
+}
+
+// TMDBSeasonDetails represents a TV season with its episodes
+type TMDBSeasonDetails struct {
+	ID             int           `json:"id"`
+	Name           string        `json:"name"`
+	Overview       string        `json:"overview"`
+	AirDate        string        `json:"air_date"`
+	SeasonNumber   int           `json:"season_number"`
+	PosterPath     string        `json:"poster_path"`
+	Episodes       []TMDBEpisode `json:"episodes"`
+	PosterURL      string        `json:"poster_url,omitempty"`       // Added by service
+	InPlex         bool          `json:"in_plex,omitempty"`          // Added by handler, all episodes are in Plex
+	EpisodesInPlex int           `json:"episodes_in_plex,omitempty"` // Added by handler
+}
+
+// TMDBEpisode represents a single episode of a season
+type TMDBEpisode struct {
+	ID            int     `json:"id"`
+	Name          string  `json:"name"`
+	Overview      string  `json:"overview"`
+	AirDate       string  `json:"air_date"`
+	EpisodeNumber int     `json:"episode_number"`
+	SeasonNumber  int     `json:"season_number"`
+	Runtime       int     `json:"runtime"`
+	StillPath     string  `json:"still_path"`
+	VoteAverage   float64 `json:"vote_average"`
+	StillURL      string  `json:"still_url,omitempty"` // Added by service
+	InPlex        bool    `json:"in_plex,omitempty"`   // Added by handler
+}
+
+// TMDBCollectionRef is the collection a movie belongs to, e.g. a trilogy
+type TMDBCollectionRef struct {
+	ID           int    `json:"id"`
+	Name         string `json:"name"`
+	PosterPath   string `json:"poster_path"`
+	BackdropPath string `json:"backdrop_path"`
+	PosterURL    string `json:"poster_url,omitempty"` // Added by service
+}
+
+// TMDBCollection represents a movie collection with its parts
+type TMDBCollection struct {
+	ID           int          `json:"id"`
+	Name         string       `json:"name"`
+	Overview     string       `json:"overview"`
+	PosterPath   string       `json:"poster_path"`
+	BackdropPath string       `json:"backdrop_path"`
+	Parts        []TMDBResult `json:"parts"`
+	PosterURL    string       `json:"poster_url,omitempty"`   // Added by service
+	BackdropURL  string       `json:"backdrop_url,omitempty"` // Added by service
+}
+
 // Supporting types
 type TMDBGenre struct {
 	ID   int    `json:"id"`
@@ -314,76 +527,142 @@ type TMDBVideo struct {
 	Official bool   `json:"official"`
 }
 
-// SearchPerson searches for people (actors, directors, etc.)
-func (s *TMDBService) SearchPerson(query string, page int) (*TMDBPersonSearchResult, error) {
-	if query == "" {
-		return nil, fmt.Errorf("search query cannot be empty")
-	}
-
-	params := url.Values{}
-	params.Add("query", query)
-	params.Add("page", fmt.Sprintf("%d", page))
-
-	url := fmt.Sprintf("%s/search/person?%s", tmdbBaseURL, params.Encode())
+// TMDB release types, see https://developer.themoviedb.org/reference/movie-release-dates
+const (
+	tmdbReleaseTheatricalLimited = 2
+	tmdbReleaseTheatrical        = 3
+	tmdbReleaseDigital           = 4
+	tmdbReleasePhysical          = 5
+)
 
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
+type TMDBReleaseDates struct {
+	Results []TMDBCountryReleaseDates `json:"results"`
+}
 
-	req.Header.Add("Authorization", "Bearer "+s.apiKey)
-	req.Header.Add("accept", "application/json")
+type TMDBCountryReleaseDates struct {
+	Country      string            `json:"iso_3166_1"`
+	ReleaseDates []TMDBReleaseDate `json:"release_dates"`
+}
 
-	resp, err := s.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to search people: %w", err)
-	}
-	defer resp.Body.Close()
+type TMDBReleaseDate struct {
+	Certification string `json:"certification"`
+	ReleaseDate   string `json:"release_date"`
+	Type          int    `json:"type"`
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("TMDB API returned status %d", resp.StatusCode)
+// certification returns the country's certification, preferring the theatrical release
+func (r TMDBReleaseDates) certification(country string) string {
+	var fallback string
+	for _, result := range r.Results {
+		if result.Country != country {
+			continue
+		}
+		for _, release := range result.ReleaseDates {
+			if release.Certification == "" {
+				continue
+			}
+			if release.Type == tmdbReleaseTheatrical {
+				return release.Certification
+			}
+			if fallback == "" {
+				fallback = release.Certification
+			}
+		}
 	}
+	return fallback
+}
 
-	var result TMDBPersonSearchResult
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+// releaseDate returns the country's earliest theatrical release date as YYYY-MM-DD
+func (r TMDBReleaseDates) releaseDate(country string) string {
+	var earliest string
+	for _, result := range r.Results {
+		if result.Country != country {
+			continue
+		}
+		for _, release := range result.ReleaseDates {
+			if release.Type != tmdbReleaseTheatrical && release.Type != tmdbReleaseTheatricalLimited {
+				continue
+			}
+			if len(release.ReleaseDate) < 10 {
+				continue
+			}
+			if date := release.ReleaseDate[:10]; earliest == "" || date < earliest {
+				earliest = date
+			}
+		}
 	}
+	return earliest
+}
 
-	// Add full image URLs
-	for i := range result.Results {
-		if result.Results[i].ProfilePath != "" {
-			result.Results[i].ProfileURL = s.GetImageURL(result.Results[i].ProfilePath, "w185")
+// HomeReleaseDate returns the earliest digital or physical release date in any
+// country as YYYY-MM-DD, or "" if none is known
+func (r TMDBReleaseDates) HomeReleaseDate() string {
+	var earliest string
+	for _, result := range r.Results {
+		for _, release := range result.ReleaseDates {
+			if release.Type != tmdbReleaseDigital && release.Type != tmdbReleasePhysical {
+				continue
+			}
+			if len(release.ReleaseDate) < 10 {
+				continue
+			}
+			if date := release.ReleaseDate[:10]; earliest == "" || date < earliest {
+				earliest = date
+			}
 		}
 	}
+	return earliest
+}
 
-	return &result, nil
+type TMDBContentRatings struct {
+	Results []TMDBContentRating `json:"results"`
 }
 
-// GetPersonDetails fetches detailed information about a person
-func (s *TMDBService) GetPersonDetails(personID int) (*TMDBPersonDetails, error) {
-	url := fmt.Sprintf("%s/person/%d", tmdbBaseURL, personID)
+type TMDBContentRating struct {
+	Country string `json:"iso_3166_1"`
+	Rating  string `json:"rating"`
+}
 
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+// rating returns the country's content rating
+func (r TMDBContentRatings) rating(country string) string {
+	for _, result := range r.Results {
+		if result.Country == country {
+			return result.Rating
+		}
+	}
+	return ""
+}
+
+// SearchPersonContext searches for people (actors, directors, etc.)
+func (s *TMDBService) SearchPersonContext(ctx context.Context, query string, page int) (*TMDBPersonSearchResult, error) {
+	if query == "" {
+		return nil, fmt.Errorf("search query cannot be empty")
 	}
 
-	req.Header.Add("Authorization", "Bearer "+s.apiKey)
-	req.Header.Add("accept", "application/json")
+	params := url.Values{}
+	params.Add("query", query)
+	params.Add("page", fmt.Sprintf("%d", page))
 
-	resp, err := s.httpClient.Do(req)
+	result, err := tmdbGet[TMDBPersonSearchResult](ctx, s, "/search/person", params, "search people")
 	if err != nil {
-		return nil, fmt.Errorf("failed to get person details: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("TMDB API returned status %d", resp.StatusCode)
+	// Add full image URLs
+	for i := range result.Results {
+		if result.Results[i].ProfilePath != "" {
+			result.Results[i].ProfileURL = s.GetImageURL(result.Results[i].ProfilePath, "w185")
+		}
 	}
 
-	var person TMDBPersonDetails
-	if err := json.NewDecoder(resp.Body).Decode(&person); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	return result, nil
+}
+
+// GetPersonDetailsContext fetches detailed information about a person
+func (s *TMDBService) GetPersonDetailsContext(ctx context.Context, personID int) (*TMDBPersonDetails, error) {
+	person, err := tmdbGet[TMDBPersonDetails](ctx, s, fmt.Sprintf("/person/%d", personID), nil, "get person details")
+	if err != nil {
+		return nil, err
 	}
 
 	// Add full image URL
@@ -391,34 +670,33 @@ func (s *TMDBService) GetPersonDetails(personID int) (*TMDBPersonDetails, error)
 		person.ProfileURL = s.GetImageURL(person.ProfilePath, "w342")
 	}
 
-	return &person, nil
+	return person, nil
 }
 
-// GetPersonCredits fetches complete filmography for a person
-func (s *TMDBService) GetPersonCredits(personID int) (*TMDBPersonCredits, error) {
-	url := fmt.Sprintf("%s/person/%d/combined_credits", tmdbBaseURL, personID)
-
-	req, err := http.NewRequest("GET", url, nil)
+// GetPersonCreditsContext fetches complete filmography for a person
+func (s *TMDBService) GetPersonCreditsContext(ctx context.Context, personID int) (*TMDBPersonCredits, error) {
+	credits, err := tmdbGet[TMDBPersonCredits](ctx, s, fmt.Sprintf("/person/%d/combined_credits", personID), nil, "get person credits")
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, err
 	}
 
-	req.Header.Add("Authorization", "Bearer "+s.apiKey)
-	req.Header.Add("accept", "application/json")
-
-	resp, err := s.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get person credits: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("TMDB API returned status %d", resp.StatusCode)
-	}
+	// Combined credits ignore include_adult
+	if !s.locale(ctx).IncludeAdult {
+		cast := credits.Cast[:0]
+		for _, credit := range credits.Cast {
+			if !credit.Adult {
+				cast = append(cast, credit)
+			}
+		}
+		credits.Cast = cast
 
-	var credits TMDBPersonCredits
-	if err := json.NewDecoder(resp.Body).Decode(&credits); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+		crew := credits.Crew[:0]
+		for _, credit := range credits.Crew {
+			if !credit.Adult {
+				crew = append(crew, credit)
+			}
+		}
+		credits.Crew = crew
 	}
 
 	// Add full image URLs for cast credits
@@ -435,7 +713,7 @@ func (s *TMDBService) GetPersonCredits(personID int) (*TMDBPersonCredits, error)
 		}
 	}
 
-	return &credits, nil
+	return credits, nil
 }
 
 // TMDBPersonSearchResult represents person search results
@@ -497,12 +775,33 @@ type TMDBPersonCast struct {
 	Name         string  `json:"name"`
 	Character    string  `json:"character"`
 	MediaType    string  `json:"media_type"`
+	Overview     string  `json:"overview"`
 	PosterPath   string  `json:"poster_path"`
 	PosterURL    string  `json:"poster_url"`
 	VoteAverage  float64 `json:"vote_average"`
 	VoteCount    int     `json:"vote_count"`
 	ReleaseDate  string  `json:"release_date"`
 	FirstAirDate string  `json:"first_air_date"`
+	GenreIDs     []int   `json:"genre_ids"`
+	Adult        bool    `json:"adult,omitempty"`
+	InPlex       bool    `json:"in_plex"`   // Added by handler
+	Requested    bool    `json:"requested"` // Added by handler, requested by the current user
+}
+
+// Result returns the credit as a search result
+func (c TMDBPersonCast) Result() TMDBResult {
+	return TMDBResult{
+		ID:           c.ID,
+		Title:        c.Title,
+		Name:         c.Name,
+		MediaType:    c.MediaType,
+		Overview:     c.Overview,
+		PosterPath:   c.PosterPath,
+		ReleaseDate:  c.ReleaseDate,
+		FirstAirDate: c.FirstAirDate,
+		GenreIDs:     c.GenreIDs,
+		Adult:        c.Adult,
+	}
 }
 
 // TMDBPersonCrew represents a crew credit
@@ -513,348 +812,273 @@ type TMDBPersonCrew struct {
 	Job          string  `json:"job"`
 	Department   string  `json:"department"`
 	MediaType    string  `json:"media_type"`
+	Overview     string  `json:"overview"`
 	PosterPath   string  `json:"poster_path"`
 	PosterURL    string  `json:"poster_url"`
 	VoteAverage  float64 `json:"vote_average"`
 	VoteCount    int     `json:"vote_count"`
 	ReleaseDate  string  `json:"release_date"`
 	FirstAirDate string  `json:"first_air_date"`
+	GenreIDs     []int   `json:"genre_ids"`
+	Adult        bool    `json:"adult,omitempty"`
+	InPlex       bool    `json:"in_plex"`   // Added by handler
+	Requested    bool    `json:"requested"` // Added by handler, requested by the current user
+}
+
+// Result returns the credit as a search result
+func (c TMDBPersonCrew) Result() TMDBResult {
+	return TMDBResult{
+		ID:           c.ID,
+		Title:        c.Title,
+		Name:         c.Name,
+		MediaType:    c.MediaType,
+		Overview:     c.Overview,
+		PosterPath:   c.PosterPath,
+		ReleaseDate:  c.ReleaseDate,
+		FirstAirDate: c.FirstAirDate,
+		GenreIDs:     c.GenreIDs,
+		Adult:        c.Adult,
+	}
 }
 
-// GetTrending fetches trending content from TMDB
+// GetTrendingContext fetches trending content from TMDB
 // mediaType: "all", "movie", or "tv"
 // timeWindow: "day" or "week"
-func (s *TMDBService) GetTrending(mediaType, timeWindow string, page int) (*TMDBSearchResult, error) {
-	url := fmt.Sprintf("%s/trending/%s/%s?page=%d", tmdbBaseURL, mediaType, timeWindow, page)
+func (s *TMDBService) GetTrendingContext(ctx context.Context, mediaType, timeWindow string, page int) (*TMDBSearchResult, error) {
+	params := url.Values{}
+	params.Add("page", fmt.Sprintf("%d", page))
 
-	req, err := http.NewRequest("GET", url, nil)
+	result, err := tmdbGet[TMDBSearchResult](ctx, s, fmt.Sprintf("/trending/%s/%s", mediaType, timeWindow), params, "get trending content")
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, err
 	}
 
-	req.Header.Add("Authorization", "Bearer "+s.apiKey)
-	req.Header.Add("accept", "application/json")
+	// Trending ignores include_adult
+	result.Results = filterAdultResults(result.Results, s.locale(ctx))
+	s.addResultImageURLs(result.Results)
+	return result, nil
+}
 
-	resp, err := s.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get trending content: %w", err)
+// GetPopularMoviesContext fetches popular movies from TMDB
+func (s *TMDBService) GetPopularMoviesContext(ctx context.Context, page int) (*TMDBSearchResult, error) {
+	if s.locale(ctx).MaxCertification != "" {
+		return s.discoverList(ctx, "movie", "popularity.desc", page, "get popular movies")
 	}
-	defer resp.Body.Close()
+	return s.getList(ctx, "/movie/popular", "movie", pageParams(page), "get popular movies")
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("TMDB API returned status %d", resp.StatusCode)
+// GetPopularTVContext fetches popular TV shows from TMDB
+func (s *TMDBService) GetPopularTVContext(ctx context.Context, page int) (*TMDBSearchResult, error) {
+	if s.locale(ctx).MaxCertification != "" {
+		return s.discoverList(ctx, "tv", "popularity.desc", page, "get popular TV shows")
 	}
+	return s.getList(ctx, "/tv/popular", "tv", pageParams(page), "get popular TV shows")
+}
 
-	var result TMDBSearchResult
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+// GetTopRatedMoviesContext fetches top rated movies from TMDB
+func (s *TMDBService) GetTopRatedMoviesContext(ctx context.Context, page int) (*TMDBSearchResult, error) {
+	if s.locale(ctx).MaxCertification != "" {
+		return s.discoverList(ctx, "movie", "vote_average.desc", page, "get top rated movies")
 	}
+	return s.getList(ctx, "/movie/top_rated", "movie", pageParams(page), "get top rated movies")
+}
 
-	// Add full image URLs
-	for i := range result.Results {
-		if result.Results[i].PosterPath != "" {
-			result.Results[i].PosterURL = s.GetImageURL(result.Results[i].PosterPath, "w342")
-		}
-		if result.Results[i].BackdropPath != "" {
-			result.Results[i].BackdropURL = s.GetImageURL(result.Results[i].BackdropPath, "w780")
-		}
+// GetTopRatedTVContext fetches top rated TV shows from TMDB
+func (s *TMDBService) GetTopRatedTVContext(ctx context.Context, page int) (*TMDBSearchResult, error) {
+	if s.locale(ctx).MaxCertification != "" {
+		return s.discoverList(ctx, "tv", "vote_average.desc", page, "get top rated TV shows")
 	}
+	return s.getList(ctx, "/tv/top_rated", "tv", pageParams(page), "get top rated TV shows")
+}
 
-	return &result, nil
+// GetUpcomingMoviesContext fetches movies with a theatrical release in the user's
+// region within TMDB_UPCOMING_DAYS (180 by default)
+func (s *TMDBService) GetUpcomingMoviesContext(ctx context.Context, page int) (*TMDBSearchResult, error) {
+	today, futureDate := s.upcomingWindow()
+	locale := s.locale(ctx)
+
+	params := pageParams(page)
+	if locale.Region != "" {
+		// With a region, release_date filters on that region's release dates
+		params.Add("release_date.gte", today)
+		params.Add("release_date.lte", futureDate)
+		params.Add("with_release_type", fmt.Sprintf("%d|%d", tmdbReleaseTheatricalLimited, tmdbReleaseTheatrical))
+	} else {
+		params.Add("primary_release_date.gte", today)
+		params.Add("primary_release_date.lte", futureDate)
+	}
+	params.Add("sort_by", "popularity.desc")
+	addCertificationParams(params, locale)
+
+	return s.getList(ctx, "/discover/movie", "movie", params, "get upcoming movies")
 }
 
-// GetPopularMovies fetches popular movies from TMDB
-func (s *TMDBService) GetPopularMovies(page int) (*TMDBSearchResult, error) {
-	url := fmt.Sprintf("%s/movie/popular?page=%d", tmdbBaseURL, page)
+// GetUpcomingTVContext fetches TV shows premiering within TMDB_UPCOMING_DAYS (180 by default)
+func (s *TMDBService) GetUpcomingTVContext(ctx context.Context, page int) (*TMDBSearchResult, error) {
+	today, futureDate := s.upcomingWindow()
 
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
+	params := pageParams(page)
+	params.Add("first_air_date.gte", today)
+	params.Add("first_air_date.lte", futureDate)
+	params.Add("sort_by", "popularity.desc")
+	addCertificationParams(params, s.locale(ctx))
 
-	req.Header.Add("Authorization", "Bearer "+s.apiKey)
-	req.Header.Add("accept", "application/json")
+	return s.getList(ctx, "/discover/tv", "tv", params, "get upcoming TV shows")
+}
 
-	resp, err := s.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get popular movies: %w", err)
-	}
-	defer resp.Body.Close()
+// GetRecommendationsContext fetches TMDB's recommendations for a movie or TV show
+func (s *TMDBService) GetRecommendationsContext(ctx context.Context, mediaType string, id, page int) (*TMDBSearchResult, error) {
+	return s.getList(ctx, fmt.Sprintf("/%s/%d/recommendations", mediaType, id), mediaType, pageParams(page), "get recommendations")
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("TMDB API returned status %d", resp.StatusCode)
-	}
+// GetSimilarContext fetches titles similar to a movie or TV show, based on genres and keywords
+func (s *TMDBService) GetSimilarContext(ctx context.Context, mediaType string, id, page int) (*TMDBSearchResult, error) {
+	return s.getList(ctx, fmt.Sprintf("/%s/%d/similar", mediaType, id), mediaType, pageParams(page), "get similar titles")
+}
 
-	var result TMDBSearchResult
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+// upcomingWindow returns today and the last day of the upcoming window as YYYY-MM-DD
+func (s *TMDBService) upcomingWindow() (string, string) {
+	days := s.upcomingDays
+	if days <= 0 {
+		days = 180
 	}
+	now := time.Now()
+	return now.Format("2006-01-02"), now.AddDate(0, 0, days).Format("2006-01-02")
+}
 
-	// Filter out items without posters and add media_type for consistency
-	filteredResults := make([]TMDBResult, 0, len(result.Results))
-	for i := range result.Results {
-		// Skip items without posters
-		if result.Results[i].PosterPath == "" {
-			continue
-		}
-
-		result.Results[i].MediaType = "movie"
-		result.Results[i].PosterURL = s.GetImageURL(result.Results[i].PosterPath, "w342")
-		if result.Results[i].BackdropPath != "" {
-			result.Results[i].BackdropURL = s.GetImageURL(result.Results[i].BackdropPath, "w780")
-		}
-		filteredResults = append(filteredResults, result.Results[i])
+// discoverList fetches a list through /discover so the user's certification limit applies
+func (s *TMDBService) discoverList(ctx context.Context, mediaType, sortBy string, page int, action string) (*TMDBSearchResult, error) {
+	params := pageParams(page)
+	params.Add("sort_by", sortBy)
+	if sortBy == "vote_average.desc" {
+		// Keep titles with a handful of votes out of top rated lists
+		params.Add("vote_count.gte", "200")
 	}
-	result.Results = filteredResults
+	addCertificationParams(params, s.locale(ctx))
 
-	return &result, nil
+	return s.getList(ctx, "/discover/"+mediaType, mediaType, params, action)
 }
 
-// GetPopularTV fetches popular TV shows from TMDB
-func (s *TMDBService) GetPopularTV(page int) (*TMDBSearchResult, error) {
-	url := fmt.Sprintf("%s/tv/popular?page=%d", tmdbBaseURL, page)
-
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+// addCertificationParams limits /discover results to the locale's maximum certification
+func addCertificationParams(params url.Values, locale TMDBLocale) {
+	if locale.MaxCertification == "" {
+		return
 	}
+	params.Add("certification_country", locale.CertificationCountry())
+	params.Add("certification.lte", locale.MaxCertification)
+}
 
-	req.Header.Add("Authorization", "Bearer "+s.apiKey)
-	req.Header.Add("accept", "application/json")
-
-	resp, err := s.httpClient.Do(req)
+// getList fetches a list of a single media type. Items without posters are
+// dropped and media_type is set, since list endpoints don't include it.
+func (s *TMDBService) getList(ctx context.Context, path, mediaType string, params url.Values, action string) (*TMDBSearchResult, error) {
+	result, err := tmdbGet[TMDBSearchResult](ctx, s, path, params, action)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get popular TV shows: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("TMDB API returned status %d", resp.StatusCode)
+		return nil, err
 	}
 
-	var result TMDBSearchResult
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
-	}
-
-	// Filter out items without posters and add media_type for consistency
 	filteredResults := make([]TMDBResult, 0, len(result.Results))
-	for i := range result.Results {
+	for _, item := range result.Results {
 		// Skip items without posters
-		if result.Results[i].PosterPath == "" {
+		if item.PosterPath == "" {
 			continue
 		}
-
-		result.Results[i].MediaType = "tv"
-		result.Results[i].PosterURL = s.GetImageURL(result.Results[i].PosterPath, "w342")
-		if result.Results[i].BackdropPath != "" {
-			result.Results[i].BackdropURL = s.GetImageURL(result.Results[i].BackdropPath, "w780")
-		}
-		filteredResults = append(filteredResults, result.Results[i])
+		item.MediaType = mediaType
+		filteredResults = append(filteredResults, item)
 	}
-	result.Results = filteredResults
+	result.Results = filterAdultResults(filteredResults, s.locale(ctx))
 
-	return &result, nil
+	s.addResultImageURLs(result.Results)
+	return result, nil
 }
 
-// GetTopRatedMovies fetches top rated movies from TMDB
-func (s *TMDBService) GetTopRatedMovies(page int) (*TMDBSearchResult, error) {
-	url := fmt.Sprintf("%s/movie/top_rated?page=%d", tmdbBaseURL, page)
-
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Add("Authorization", "Bearer "+s.apiKey)
-	req.Header.Add("accept", "application/json")
-
-	resp, err := s.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get top rated movies: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("TMDB API returned status %d", resp.StatusCode)
-	}
-
-	var result TMDBSearchResult
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
-	}
-
-	// Filter out items without posters and add media_type for consistency
-	filteredResults := make([]TMDBResult, 0, len(result.Results))
-	for i := range result.Results {
-		// Skip items without posters
-		if result.Results[i].PosterPath == "" {
-			continue
+// addResultImageURLs adds full poster and backdrop URLs to search results
+func (s *TMDBService) addResultImageURLs(results []TMDBResult) {
+	for i := range results {
+		if results[i].PosterPath != "" {
+			results[i].PosterURL = s.GetImageURL(results[i].PosterPath, "w342")
 		}
-
-		result.Results[i].MediaType = "movie"
-		result.Results[i].PosterURL = s.GetImageURL(result.Results[i].PosterPath, "w342")
-		if result.Results[i].BackdropPath != "" {
-			result.Results[i].BackdropURL = s.GetImageURL(result.Results[i].BackdropPath, "w780")
+		if results[i].BackdropPath != "" {
+			results[i].BackdropURL = s.GetImageURL(results[i].BackdropPath, "w780")
 		}
-		filteredResults = append(filteredResults, result.Results[i])
 	}
-	result.Results = filteredResults
-
-	return &result, nil
 }
 
-// GetTopRatedTV fetches top rated TV shows from TMDB
-func (s *TMDBService) GetTopRatedTV(page int) (*TMDBSearchResult, error) {
-	url := fmt.Sprintf("%s/tv/top_rated?page=%d", tmdbBaseURL, page)
-
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Add("Authorization", "Bearer "+s.apiKey)
-	req.Header.Add("accept", "application/json")
-
-	resp, err := s.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get top rated TV shows: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("TMDB API returned status %d", resp.StatusCode)
+// filterAdultResults drops adult titles unless the locale includes them
+func filterAdultResults(results []TMDBResult, locale TMDBLocale) []TMDBResult {
+	if locale.IncludeAdult {
+		return results
 	}
-
-	var result TMDBSearchResult
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
-	}
-
-	// Filter out items without posters and add media_type for consistency
-	filteredResults := make([]TMDBResult, 0, len(result.Results))
-	for i := range result.Results {
-		// Skip items without posters
-		if result.Results[i].PosterPath == "" {
-			continue
-		}
-
-		result.Results[i].MediaType = "tv"
-		result.Results[i].PosterURL = s.GetImageURL(result.Results[i].PosterPath, "w342")
-		if result.Results[i].BackdropPath != "" {
-			result.Results[i].BackdropURL = s.GetImageURL(result.Results[i].BackdropPath, "w780")
+	filtered := results[:0]
+	for _, result := range results {
+		if !result.Adult {
+			filtered = append(filtered, result)
 		}
-		filteredResults = append(filteredResults, result.Results[i])
 	}
-	result.Results = filteredResults
-
-	return &result, nil
+	return filtered
 }
 
-// GetUpcomingMovies fetches upcoming movies from TMDB
-func (s *TMDBService) GetUpcomingMovies(page int) (*TMDBSearchResult, error) {
-	// Calculate date range: today to 180 days from now
-	today := time.Now().Format("2006-01-02")
-	futureDate := time.Now().AddDate(0, 0, 180).Format("2006-01-02")
+// locale returns the context's TMDB locale with the server defaults filled in
+func (s *TMDBService) locale(ctx context.Context) TMDBLocale {
+	return TMDBLocaleFromContext(ctx).withDefaults(s.defaultLocale)
+}
 
-	url := fmt.Sprintf(
-		"%s/discover/movie?primary_release_date.gte=%s&primary_release_date.lte=%s&sort_by=popularity.desc&page=%d",
-		tmdbBaseURL, today, futureDate, page,
-	)
+func pageParams(page int) url.Values {
+	params := url.Values{}
+	params.Add("page", fmt.Sprintf("%d", page))
+	return params
+}
 
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+// tmdbGet performs an authenticated GET request against the TMDB API and
+// decodes the JSON response into T. action describes the call in errors.
+func tmdbGet[T any](ctx context.Context, s *TMDBService, path string, params url.Values, action string) (result *T, err error) {
+	start := time.Now()
+	statusCode := 0
+	if s.tracer != nil {
+		defer func() {
+			s.tracer(ctx, TMDBTrace{
+				Path:       path,
+				StatusCode: statusCode,
+				Duration:   time.Since(start),
+				Err:        err,
+			})
+		}()
 	}
 
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", s.apiKey))
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := s.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get upcoming movies: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("TMDB API returned status %d", resp.StatusCode)
+	baseURL := s.baseURL
+	if baseURL == "" {
+		baseURL = defaultTMDBBaseURL
 	}
 
-	var result TMDBSearchResult
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	if params == nil {
+		params = url.Values{}
 	}
+	s.locale(ctx).apply(params)
+	requestURL := baseURL + path + "?" + params.Encode()
 
-	// Filter out items without posters and add media_type for consistency
-	filteredResults := make([]TMDBResult, 0, len(result.Results))
-	for i := range result.Results {
-		// Skip items without posters
-		if result.Results[i].PosterPath == "" {
-			continue
-		}
-
-		result.Results[i].MediaType = "movie"
-		result.Results[i].PosterURL = s.GetImageURL(result.Results[i].PosterPath, "w342")
-		if result.Results[i].BackdropPath != "" {
-			result.Results[i].BackdropURL = s.GetImageURL(result.Results[i].BackdropPath, "w780")
-		}
-		filteredResults = append(filteredResults, result.Results[i])
-	}
-	result.Results = filteredResults
-
-	return &result, nil
-}
-
-// GetUpcomingTV fetches upcoming TV shows from TMDB (premiering within 180 days)
-func (s *TMDBService) GetUpcomingTV(page int) (*TMDBSearchResult, error) {
-	// Calculate date range: today to 180 days from now
-	today := time.Now().Format("2006-01-02")
-	futureDate := time.Now().AddDate(0, 0, 180).Format("2006-01-02")
-
-	url := fmt.Sprintf(
-		"%s/discover/tv?first_air_date.gte=%s&first_air_date.lte=%s&sort_by=popularity.desc&page=%d",
-		tmdbBaseURL, today, futureDate, page,
-	)
-
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", s.apiKey))
-	req.Header.Set("Content-Type", "application/json")
+	// Use Bearer token for authentication
+	req.Header.Set("Authorization", "Bearer "+s.apiKey)
+	req.Header.Set("Accept", "application/json")
 
 	resp, err := s.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get upcoming TV shows: %w", err)
+		return nil, fmt.Errorf("failed to %s: %w", action, err)
 	}
 	defer resp.Body.Close()
+	statusCode = resp.StatusCode
 
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("TMDB API returned status %d", resp.StatusCode)
 	}
 
-	var result TMDBSearchResult
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
-	}
-
-	// Filter out items without posters and add media_type for consistency
-	filteredResults := make([]TMDBResult, 0, len(result.Results))
-	for i := range result.Results {
-		// Skip items without posters
-		if result.Results[i].PosterPath == "" {
-			continue
-		}
-
-		result.Results[i].MediaType = "tv"
-		result.Results[i].PosterURL = s.GetImageURL(result.Results[i].PosterPath, "w342")
-		if result.Results[i].BackdropPath != "" {
-			result.Results[i].BackdropURL = s.GetImageURL(result.Results[i].BackdropPath, "w780")
-		}
-		filteredResults = append(filteredResults, result.Results[i])
+	var decoded T
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		return nil, fmt.Errorf("failed to decode TMDB response: %w", err)
 	}
-	result.Results = filteredResults
 
-	return &result, nil
-}
\ No newline at end of file
+	return &decoded, nil
+}
@@ -0,0 +1,539 @@
+package services
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jacob-fain/MRS/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TMDB cache endpoint groups, each with its own TTL
+const (
+	CacheEndpointSearch   = "search"
+	CacheEndpointDetails  = "details"
+	CacheEndpointPerson   = "person"
+	CacheEndpointTrending = "trending"
+	CacheEndpointLists    = "lists"
+)
+
+// CacheStore stores encoded responses by key
+type CacheStore interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, value []byte, ttl time.Duration) error
+	Len() (int64, error)
+	Flush() error
+	DeleteExpired(now time.Time) (int64, error)
+}
+
+// CachedTMDBService is a TMDBServiceInterface decorator that caches responses
+type CachedTMDBService struct {
+	next  TMDBServiceInterface
+	store CacheStore
+	ttls  map[string]time.Duration
+
+	mu    sync.Mutex
+	stats map[string]*EndpointCacheStats
+}
+
+// EndpointCacheStats holds hit and miss counters for one endpoint group
+type EndpointCacheStats struct {
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+}
+
+// CacheStats summarizes cache usage since startup or the last flush
+type CacheStats struct {
+	Backend   string                        `json:"backend"`
+	Entries   int64                         `json:"entries"`
+	Hits      int64                         `json:"hits"`
+	Misses    int64                         `json:"misses"`
+	HitRate   float64                       `json:"hit_rate"`
+	Endpoints map[string]EndpointCacheStats `json:"endpoints"`
+}
+
+// NewCachedTMDBService wraps a TMDB service with a cache configured from the environment.
+// TMDB_CACHE_BACKEND selects "memory" (default) or "postgres", which stores entries in the database.
+func NewCachedTMDBService(next TMDBServiceInterface, db *gorm.DB) (*CachedTMDBService, error) {
+	var store CacheStore
+	switch backend := os.Getenv("TMDB_CACHE_BACKEND"); backend {
+	case "", "memory":
+		size, err := envInt("TMDB_CACHE_SIZE", 1000)
+		if err != nil {
+			return nil, err
+		}
+		store = NewMemoryCacheStore(size)
+	case "postgres":
+		store = NewDBCacheStore(db)
+	default:
+		return nil, fmt.Errorf("unknown TMDB_CACHE_BACKEND %q", backend)
+	}
+
+	defaults := map[string]int{
+		CacheEndpointSearch:   15,
+		CacheEndpointDetails:  24 * 60,
+		CacheEndpointPerson:   24 * 60,
+		CacheEndpointTrending: 60,
+		CacheEndpointLists:    6 * 60,
+	}
+
+	ttls := make(map[string]time.Duration, len(defaults))
+	for endpoint, defaultMinutes := range defaults {
+		minutes, err := envInt("TMDB_CACHE_TTL_"+strings.ToUpper(endpoint)+"_MINUTES", defaultMinutes)
+		if err != nil {
+			return nil, err
+		}
+		ttls[endpoint] = time.Duration(minutes) * time.Minute
+	}
+
+	return NewCachedTMDBServiceWithStore(next, store, ttls), nil
+}
+
+// NewCachedTMDBServiceWithStore wraps a TMDB service with the given store and TTLs.
+// Endpoints without a positive TTL are not cached.
+func NewCachedTMDBServiceWithStore(next TMDBServiceInterface, store CacheStore, ttls map[string]time.Duration) *CachedTMDBService {
+	return &CachedTMDBService{
+		next:  next,
+		store: store,
+		ttls:  ttls,
+		stats: make(map[string]*EndpointCacheStats),
+	}
+}
+
+// Stats returns the current cache statistics
+func (s *CachedTMDBService) Stats() CacheStats {
+	stats := CacheStats{
+		Backend:   "memory",
+		Endpoints: make(map[string]EndpointCacheStats),
+	}
+	if _, ok := s.store.(*DBCacheStore); ok {
+		stats.Backend = "postgres"
+	}
+
+	entries, err := s.store.Len()
+	if err != nil {
+		log.Printf("Failed to count TMDB cache entries: %v", err)
+	}
+	stats.Entries = entries
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for endpoint, endpointStats := range s.stats {
+		stats.Endpoints[endpoint] = *endpointStats
+		stats.Hits += endpointStats.Hits
+		stats.Misses += endpointStats.Misses
+	}
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRate = float64(stats.Hits) / float64(total)
+	}
+
+	return stats
+}
+
+// Flush removes all cached entries and resets the statistics
+func (s *CachedTMDBService) Flush() error {
+	if err := s.store.Flush(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.stats = make(map[string]*EndpointCacheStats)
+	s.mu.Unlock()
+
+	return nil
+}
+
+// DeleteExpired removes expired entries from the store
+func (s *CachedTMDBService) DeleteExpired(now time.Time) (int64, error) {
+	return s.store.DeleteExpired(now)
+}
+
+func (s *CachedTMDBService) record(endpoint string, hit bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	endpointStats, ok := s.stats[endpoint]
+	if !ok {
+		endpointStats = &EndpointCacheStats{}
+		s.stats[endpoint] = endpointStats
+	}
+	if hit {
+		endpointStats.Hits++
+	} else {
+		endpointStats.Misses++
+	}
+}
+
+// cachedFetch returns the cached response for key, or calls fetch and caches its result.
+// Responses depend on the context's TMDB locale, so it is part of the key. Errors are never cached.
+func cachedFetch[T any](ctx context.Context, s *CachedTMDBService, endpoint, key string, fetch func() (*T, error)) (*T, error) {
+	ttl := s.ttls[endpoint]
+	if ttl <= 0 {
+		return fetch()
+	}
+	if locale := TMDBLocaleFromContext(ctx).cacheKey(); locale != "" {
+		key = locale + "|" + key
+	}
+
+	if data, ok := s.store.Get(key); ok {
+		var cached T
+		if err := json.Unmarshal(data, &cached); err == nil {
+			s.record(endpoint, true)
+			return &cached, nil
+		}
+	}
+	s.record(endpoint, false)
+
+	result, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		log.Printf("Failed to encode TMDB cache entry %s: %v", key, err)
+		return result, nil
+	}
+	if err := s.store.Set(key, data, ttl); err != nil {
+		log.Printf("Failed to store TMDB cache entry %s: %v", key, err)
+	}
+
+	return result, nil
+}
+
+// SearchMultiContext searches for movies and TV shows
+func (s *CachedTMDBService) SearchMultiContext(ctx context.Context, query string, page int) (*TMDBSearchResult, error) {
+	key := fmt.Sprintf("search/multi:%s:%d", normalizeCacheQuery(query), page)
+	return cachedFetch(ctx, s, CacheEndpointSearch, key, func() (*TMDBSearchResult, error) {
+		return s.next.SearchMultiContext(ctx, query, page)
+	})
+}
+
+// GetMovieDetailsContext fetches detailed information about a movie
+func (s *CachedTMDBService) GetMovieDetailsContext(ctx context.Context, movieID int) (*TMDBMovieDetails, error) {
+	key := fmt.Sprintf("movie:%d", movieID)
+	return cachedFetch(ctx, s, CacheEndpointDetails, key, func() (*TMDBMovieDetails, error) {
+		return s.next.GetMovieDetailsContext(ctx, movieID)
+	})
+}
+
+// GetTVDetailsContext fetches detailed information about a TV show
+func (s *CachedTMDBService) GetTVDetailsContext(ctx context.Context, tvID int) (*TMDBTVDetails, error) {
+	key := fmt.Sprintf("tv:%d", tvID)
+	return cachedFetch(ctx, s, CacheEndpointDetails, key, func() (*TMDBTVDetails, error) {
+		return s.next.GetTVDetailsContext(ctx, tvID)
+	})
+}
+
+// GetSeasonDetailsContext fetches a TV season with its episodes
+func (s *CachedTMDBService) GetSeasonDetailsContext(ctx context.Context, tvID, seasonNumber int) (*TMDBSeasonDetails, error) {
+	key := fmt.Sprintf("tv:%d:season:%d", tvID, seasonNumber)
+	return cachedFetch(ctx, s, CacheEndpointDetails, key, func() (*TMDBSeasonDetails, error) {
+		return s.next.GetSeasonDetailsContext(ctx, tvID, seasonNumber)
+	})
+}
+
+// GetCollectionContext fetches a movie collection with its parts
+func (s *CachedTMDBService) GetCollectionContext(ctx context.Context, collectionID int) (*TMDBCollection, error) {
+	key := fmt.Sprintf("collection:%d", collectionID)
+	return cachedFetch(ctx, s, CacheEndpointDetails, key, func() (*TMDBCollection, error) {
+		return s.next.GetCollectionContext(ctx, collectionID)
+	})
+}
+
+// FindByIMDbIDContext looks up the movies and TV shows with an IMDb ID
+func (s *CachedTMDBService) FindByIMDbIDContext(ctx context.Context, imdbID string) (*TMDBFindResult, error) {
+	key := fmt.Sprintf("find:%s", imdbID)
+	return cachedFetch(ctx, s, CacheEndpointDetails, key, func() (*TMDBFindResult, error) {
+		return s.next.FindByIMDbIDContext(ctx, imdbID)
+	})
+}
+
+// GetImageURL builds a full image URL, no caching needed
+func (s *CachedTMDBService) GetImageURL(path string, size string) string {
+	return s.next.GetImageURL(path, size)
+}
+
+// SearchPersonContext searches for people
+func (s *CachedTMDBService) SearchPersonContext(ctx context.Context, query string, page int) (*TMDBPersonSearchResult, error) {
+	key := fmt.Sprintf("search/person:%s:%d", normalizeCacheQuery(query), page)
+	return cachedFetch(ctx, s, CacheEndpointSearch, key, func() (*TMDBPersonSearchResult, error) {
+		return s.next.SearchPersonContext(ctx, query, page)
+	})
+}
+
+// GetPersonDetailsContext fetches detailed information about a person
+func (s *CachedTMDBService) GetPersonDetailsContext(ctx context.Context, personID int) (*TMDBPersonDetails, error) {
+	key := fmt.Sprintf("person:%d", personID)
+	return cachedFetch(ctx, s, CacheEndpointPerson, key, func() (*TMDBPersonDetails, error) {
+		return s.next.GetPersonDetailsContext(ctx, personID)
+	})
+}
+
+// GetPersonCreditsContext fetches the combined credits of a person
+func (s *CachedTMDBService) GetPersonCreditsContext(ctx context.Context, personID int) (*TMDBPersonCredits, error) {
+	key := fmt.Sprintf("person/credits:%d", personID)
+	return cachedFetch(ctx, s, CacheEndpointPerson, key, func() (*TMDBPersonCredits, error) {
+		return s.next.GetPersonCreditsContext(ctx, personID)
+	})
+}
+
+// GetTrendingContext fetches trending movies and TV shows
+func (s *CachedTMDBService) GetTrendingContext(ctx context.Context, mediaType, timeWindow string, page int) (*TMDBSearchResult, error) {
+	key := fmt.Sprintf("trending:%s:%s:%d", mediaType, timeWindow, page)
+	return cachedFetch(ctx, s, CacheEndpointTrending, key, func() (*TMDBSearchResult, error) {
+		return s.next.GetTrendingContext(ctx, mediaType, timeWindow, page)
+	})
+}
+
+// GetPopularMoviesContext fetches popular movies
+func (s *CachedTMDBService) GetPopularMoviesContext(ctx context.Context, page int) (*TMDBSearchResult, error) {
+	return cachedFetch(ctx, s, CacheEndpointLists, fmt.Sprintf("movie/popular:%d", page), func() (*TMDBSearchResult, error) {
+		return s.next.GetPopularMoviesContext(ctx, page)
+	})
+}
+
+// GetPopularTVContext fetches popular TV shows
+func (s *CachedTMDBService) GetPopularTVContext(ctx context.Context, page int) (*TMDBSearchResult, error) {
+	return cachedFetch(ctx, s, CacheEndpointLists, fmt.Sprintf("tv/popular:%d", page), func() (*TMDBSearchResult, error) {
+		return s.next.GetPopularTVContext(ctx, page)
+	})
+}
+
+// GetTopRatedMoviesContext fetches top rated movies
+func (s *CachedTMDBService) GetTopRatedMoviesContext(ctx context.Context, page int) (*TMDBSearchResult, error) {
+	return cachedFetch(ctx, s, CacheEndpointLists, fmt.Sprintf("movie/top_rated:%d", page), func() (*TMDBSearchResult, error) {
+		return s.next.GetTopRatedMoviesContext(ctx, page)
+	})
+}
+
+// GetTopRatedTVContext fetches top rated TV shows
+func (s *CachedTMDBService) GetTopRatedTVContext(ctx context.Context, page int) (*TMDBSearchResult, error) {
+	return cachedFetch(ctx, s, CacheEndpointLists, fmt.Sprintf("tv/top_rated:%d", page), func() (*TMDBSearchResult, error) {
+		return s.next.GetTopRatedTVContext(ctx, page)
+	})
+}
+
+// GetUpcomingMoviesContext fetches upcoming movies
+func (s *CachedTMDBService) GetUpcomingMoviesContext(ctx context.Context, page int) (*TMDBSearchResult, error) {
+	return cachedFetch(ctx, s, CacheEndpointLists, fmt.Sprintf("movie/upcoming:%d", page), func() (*TMDBSearchResult, error) {
+		return s.next.GetUpcomingMoviesContext(ctx, page)
+	})
+}
+
+// GetUpcomingTVContext fetches TV shows airing soon
+func (s *CachedTMDBService) GetUpcomingTVContext(ctx context.Context, page int) (*TMDBSearchResult, error) {
+	return cachedFetch(ctx, s, CacheEndpointLists, fmt.Sprintf("tv/upcoming:%d", page), func() (*TMDBSearchResult, error) {
+		return s.next.GetUpcomingTVContext(ctx, page)
+	})
+}
+
+// GetRecommendationsContext fetches TMDB's recommendations for a movie or TV show
+func (s *CachedTMDBService) GetRecommendationsContext(ctx context.Context, mediaType string, id, page int) (*TMDBSearchResult, error) {
+	key := fmt.Sprintf("%s/%d/recommendations:%d", mediaType, id, page)
+	return cachedFetch(ctx, s, CacheEndpointLists, key, func() (*TMDBSearchResult, error) {
+		return s.next.GetRecommendationsContext(ctx, mediaType, id, page)
+	})
+}
+
+// GetSimilarContext fetches titles similar to a movie or TV show
+func (s *CachedTMDBService) GetSimilarContext(ctx context.Context, mediaType string, id, page int) (*TMDBSearchResult, error) {
+	key := fmt.Sprintf("%s/%d/similar:%d", mediaType, id, page)
+	return cachedFetch(ctx, s, CacheEndpointLists, key, func() (*TMDBSearchResult, error) {
+		return s.next.GetSimilarContext(ctx, mediaType, id, page)
+	})
+}
+
+// DiscoverContext fetches movies or TV shows matching the filters
+func (s *CachedTMDBService) DiscoverContext(ctx context.Context, mediaType string, filters TMDBDiscoverFilters, page int) (*TMDBSearchResult, error) {
+	key := fmt.Sprintf("discover/%s?%s:%d", mediaType, filters.cacheKey(mediaType), page)
+	return cachedFetch(ctx, s, CacheEndpointLists, key, func() (*TMDBSearchResult, error) {
+		return s.next.DiscoverContext(ctx, mediaType, filters, page)
+	})
+}
+
+// GetGenresContext fetches TMDB's movie or TV genres
+func (s *CachedTMDBService) GetGenresContext(ctx context.Context, mediaType string) ([]TMDBGenre, error) {
+	key := fmt.Sprintf("genres/%s", mediaType)
+	genres, err := cachedFetch(ctx, s, CacheEndpointDetails, key, func() (*[]TMDBGenre, error) {
+		genres, err := s.next.GetGenresContext(ctx, mediaType)
+		return &genres, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return *genres, nil
+}
+
+// normalizeCacheQuery makes searches that only differ in case or spacing share an entry
+func normalizeCacheQuery(query string) string {
+	return strings.ToLower(strings.Join(strings.Fields(query), " "))
+}
+
+// MemoryCacheStore is an in-memory LRU cache store
+type MemoryCacheStore struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List // Front is most recently used
+}
+
+type memoryCacheEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// NewMemoryCacheStore creates an LRU store holding at most capacity entries
+func NewMemoryCacheStore(capacity int) *MemoryCacheStore {
+	if capacity <= 0 {
+		capacity = 1
+	}
+	return &MemoryCacheStore{
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// Get returns the value for key if it exists and has not expired
+func (m *MemoryCacheStore) Get(key string) ([]byte, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	element, ok := m.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*memoryCacheEntry)
+	if time.Now().After(entry.expiresAt) {
+		m.removeElement(element)
+		return nil, false
+	}
+
+	m.order.MoveToFront(element)
+	return entry.value, true
+}
+
+// Set stores a value, evicting the least recently used entry when full
+func (m *MemoryCacheStore) Set(key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	expiresAt := time.Now().Add(ttl)
+	if element, ok := m.entries[key]; ok {
+		entry := element.Value.(*memoryCacheEntry)
+		entry.value = value
+		entry.expiresAt = expiresAt
+		m.order.MoveToFront(element)
+		return nil
+	}
+
+	m.entries[key] = m.order.PushFront(&memoryCacheEntry{
+		key:       key,
+		value:     value,
+		expiresAt: expiresAt,
+	})
+	for m.order.Len() > m.capacity {
+		m.removeElement(m.order.Back())
+	}
+
+	return nil
+}
+
+// Len returns the number of stored entries, including expired ones not yet evicted
+func (m *MemoryCacheStore) Len() (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return int64(m.order.Len()), nil
+}
+
+// Flush removes all entries
+func (m *MemoryCacheStore) Flush() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries = make(map[string]*list.Element)
+	m.order.Init()
+	return nil
+}
+
+// DeleteExpired removes entries that expired before now
+func (m *MemoryCacheStore) DeleteExpired(now time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var deleted int64
+	for element := m.order.Back(); element != nil; {
+		prev := element.Prev()
+		if now.After(element.Value.(*memoryCacheEntry).expiresAt) {
+			m.removeElement(element)
+			deleted++
+		}
+		element = prev
+	}
+	return deleted, nil
+}
+
+func (m *MemoryCacheStore) removeElement(element *list.Element) {
+	m.order.Remove(element)
+	delete(m.entries, element.Value.(*memoryCacheEntry).key)
+}
+
+// DBCacheStore stores cache entries in the database so they survive restarts
+type DBCacheStore struct {
+	db *gorm.DB
+}
+
+// NewDBCacheStore creates a database-backed cache store
+func NewDBCacheStore(db *gorm.DB) *DBCacheStore {
+	return &DBCacheStore{db: db}
+}
+
+// Get returns the value for key if it exists and has not expired
+func (d *DBCacheStore) Get(key string) ([]byte, bool) {
+	// Find instead of First so misses are not logged as errors
+	var entries []models.TMDBCacheEntry
+	if err := d.db.Where("cache_key = ? AND expires_at > ?", key, time.Now()).Limit(1).Find(&entries).Error; err != nil || len(entries) == 0 {
+		return nil, false
+	}
+	return []byte(entries[0].Value), true
+}
+
+// Set inserts or replaces the entry for key
+func (d *DBCacheStore) Set(key string, value []byte, ttl time.Duration) error {
+	entry := models.TMDBCacheEntry{
+		Key:       key,
+		Value:     string(value),
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	return d.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "cache_key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"value", "expires_at", "updated_at"}),
+	}).Create(&entry).Error
+}
+
+// Len returns the number of stored entries, including expired ones not yet deleted
+func (d *DBCacheStore) Len() (int64, error) {
+	var count int64
+	err := d.db.Model(&models.TMDBCacheEntry{}).Count(&count).Error
+	return count, err
+}
+
+// Flush removes all entries
+func (d *DBCacheStore) Flush() error {
+	return d.db.Where("1 = 1").Delete(&models.TMDBCacheEntry{}).Error
+}
+
+// DeleteExpired removes entries that expired before now
+func (d *DBCacheStore) DeleteExpired(now time.Time) (int64, error) {
+	result := d.db.Where("expires_at <= ?", now).Delete(&models.TMDBCacheEntry{})
+	return result.RowsAffected, result.Error
+}
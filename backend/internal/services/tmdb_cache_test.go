@@ -0,0 +1,192 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jacob-fain/MRS/internal/testutil"
+)
+
+// countingTMDBService counts calls to the wrapped TMDB methods used in these tests
+type countingTMDBService struct {
+	TMDBServiceInterface
+	calls int
+	fail  bool
+}
+
+func (c *countingTMDBService) SearchMultiContext(ctx context.Context, query string, page int) (*TMDBSearchResult, error) {
+	c.calls++
+	if c.fail {
+		return nil, fmt.Errorf("TMDB API returned status 500")
+	}
+	return &TMDBSearchResult{
+		Page:    page,
+		Results: []TMDBResult{{ID: 603, Title: "The Matrix", MediaType: "movie"}},
+	}, nil
+}
+
+func (c *countingTMDBService) GetMovieDetailsContext(ctx context.Context, movieID int) (*TMDBMovieDetails, error) {
+	c.calls++
+	return &TMDBMovieDetails{ID: movieID, Title: "The Matrix"}, nil
+}
+
+func testCacheTTLs() map[string]time.Duration {
+	return map[string]time.Duration{
+		CacheEndpointSearch:  time.Minute,
+		CacheEndpointDetails: time.Minute,
+	}
+}
+
+func TestCachedTMDBService_CachesResponses(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	ctx := context.Background()
+
+	stores := map[string]CacheStore{
+		"memory":   NewMemoryCacheStore(10),
+		"database": NewDBCacheStore(db),
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			upstream := &countingTMDBService{}
+			cache := NewCachedTMDBServiceWithStore(upstream, store, testCacheTTLs())
+
+			first, err := cache.SearchMultiContext(ctx, "The Matrix", 1)
+			testutil.AssertNoError(t, err)
+
+			// Same query with different case and spacing is a hit
+			second, err := cache.SearchMultiContext(ctx, "  the   matrix ", 1)
+			testutil.AssertNoError(t, err)
+			testutil.AssertEqual(t, 1, upstream.calls)
+			testutil.AssertEqual(t, first.Results[0].Title, second.Results[0].Title)
+
+			// Callers can modify results without affecting the cache
+			second.Results[0].InPlex = true
+			third, _ := cache.SearchMultiContext(ctx, "The Matrix", 1)
+			testutil.AssertEqual(t, false, third.Results[0].InPlex)
+
+			// A different page is a different entry
+			cache.SearchMultiContext(ctx, "The Matrix", 2)
+			testutil.AssertEqual(t, 2, upstream.calls)
+
+			// Details use their own endpoint group
+			cache.GetMovieDetailsContext(ctx, 603)
+			cache.GetMovieDetailsContext(ctx, 603)
+			testutil.AssertEqual(t, 3, upstream.calls)
+
+			stats := cache.Stats()
+			testutil.AssertEqual(t, int64(3), stats.Hits)
+			testutil.AssertEqual(t, int64(3), stats.Misses)
+			testutil.AssertEqual(t, int64(2), stats.Endpoints[CacheEndpointSearch].Hits)
+			testutil.AssertEqual(t, int64(1), stats.Endpoints[CacheEndpointDetails].Hits)
+			testutil.AssertEqual(t, int64(3), stats.Entries)
+
+			// Flushing empties the cache and resets the statistics
+			testutil.AssertNoError(t, cache.Flush())
+			stats = cache.Stats()
+			testutil.AssertEqual(t, int64(0), stats.Entries)
+			testutil.AssertEqual(t, int64(0), stats.Hits)
+
+			cache.SearchMultiContext(ctx, "The Matrix", 1)
+			testutil.AssertEqual(t, 4, upstream.calls)
+		})
+	}
+}
+
+func TestCachedTMDBService_DoesNotCacheErrors(t *testing.T) {
+	ctx := context.Background()
+	upstream := &countingTMDBService{fail: true}
+	cache := NewCachedTMDBServiceWithStore(upstream, NewMemoryCacheStore(10), testCacheTTLs())
+
+	_, err := cache.SearchMultiContext(ctx, "The Matrix", 1)
+	testutil.AssertError(t, err)
+
+	upstream.fail = false
+	result, err := cache.SearchMultiContext(ctx, "The Matrix", 1)
+	testutil.AssertNoError(t, err)
+	testutil.AssertEqual(t, 1, len(result.Results))
+	testutil.AssertEqual(t, 2, upstream.calls)
+}
+
+func TestCachedTMDBService_ZeroTTLDisablesCaching(t *testing.T) {
+	ctx := context.Background()
+	upstream := &countingTMDBService{}
+	ttls := testCacheTTLs()
+	ttls[CacheEndpointSearch] = 0
+	cache := NewCachedTMDBServiceWithStore(upstream, NewMemoryCacheStore(10), ttls)
+
+	cache.SearchMultiContext(ctx, "The Matrix", 1)
+	cache.SearchMultiContext(ctx, "The Matrix", 1)
+	testutil.AssertEqual(t, 2, upstream.calls)
+}
+
+func TestMemoryCacheStore_EvictsLeastRecentlyUsed(t *testing.T) {
+	store := NewMemoryCacheStore(2)
+
+	store.Set("a", []byte("1"), time.Minute)
+	store.Set("b", []byte("2"), time.Minute)
+
+	// Touch "a" so "b" becomes the least recently used
+	_, ok := store.Get("a")
+	testutil.AssertTrue(t, ok, "a should be cached")
+
+	store.Set("c", []byte("3"), time.Minute)
+
+	_, ok = store.Get("b")
+	testutil.AssertTrue(t, !ok, "b should have been evicted")
+	_, ok = store.Get("a")
+	testutil.AssertTrue(t, ok, "a should still be cached")
+	_, ok = store.Get("c")
+	testutil.AssertTrue(t, ok, "c should be cached")
+}
+
+func TestCacheStores_Expiry(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+
+	stores := map[string]CacheStore{
+		"memory":   NewMemoryCacheStore(10),
+		"database": NewDBCacheStore(db),
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			store.Set("expired", []byte("1"), -time.Minute)
+			store.Set("fresh", []byte("2"), time.Minute)
+
+			_, ok := store.Get("expired")
+			testutil.AssertTrue(t, !ok, "expired entry should not be returned")
+
+			// Overwriting an existing key refreshes it
+			testutil.AssertNoError(t, store.Set("fresh", []byte("3"), time.Minute))
+			value, ok := store.Get("fresh")
+			testutil.AssertTrue(t, ok, "fresh entry should be returned")
+			testutil.AssertEqual(t, "3", string(value))
+
+			store.Set("expired", []byte("1"), -time.Minute)
+			deleted, err := store.DeleteExpired(time.Now())
+			testutil.AssertNoError(t, err)
+			testutil.AssertEqual(t, int64(1), deleted)
+
+			count, _ := store.Len()
+			testutil.AssertEqual(t, int64(1), count)
+		})
+	}
+}
+
+func TestCachedTMDBService_SeparatesLocales(t *testing.T) {
+	upstream := &countingTMDBService{}
+	cache := NewCachedTMDBServiceWithStore(upstream, NewMemoryCacheStore(10), testCacheTTLs())
+
+	french := WithTMDBLocale(context.Background(), TMDBLocale{Language: "fr-FR", Region: "FR"})
+	spanish := WithTMDBLocale(context.Background(), TMDBLocale{Language: "es-ES", Region: "ES"})
+
+	cache.GetMovieDetailsContext(french, 603)
+	cache.GetMovieDetailsContext(french, 603)
+	testutil.AssertEqual(t, 1, upstream.calls)
+
+	cache.GetMovieDetailsContext(spanish, 603)
+	cache.GetMovieDetailsContext(context.Background(), 603)
+	testutil.AssertEqual(t, 3, upstream.calls)
+}
@@ -0,0 +1,164 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// TMDBDiscoverFilters are the /discover filters exposed by the API. Zero values
+// leave a filter off.
+type TMDBDiscoverFilters struct {
+	Genres           []int   // Genre IDs, titles must have all of them
+	YearFrom         int     // First release or air year, inclusive
+	YearTo           int     // Last release or air year, inclusive
+	MinVoteAverage   float64 // 0-10
+	MinVoteCount     int
+	MinRuntime       int    // Minutes
+	MaxRuntime       int    // Minutes
+	OriginalLanguage string // ISO 639-1, e.g. "ja"
+	WatchProviders   []int  // Provider IDs, titles must be on any of them
+	WatchRegion      string // Country for WatchProviders, the user's region or US by default
+	SortBy           string // One of TMDBDiscoverSortOptions, popularity.desc by default
+}
+
+// TMDBDiscoverSortOptions lists the supported sort orders. "release_date" sorts by
+// primary release date for movies and first air date for TV.
+var TMDBDiscoverSortOptions = []string{
+	"popularity.desc", "popularity.asc",
+	"vote_average.desc", "vote_average.asc",
+	"vote_count.desc", "vote_count.asc",
+	"release_date.desc", "release_date.asc",
+}
+
+var tmdbOriginalLanguagePattern = regexp.MustCompile(`^[a-z]{2}$`)
+
+// Validate checks the filter values and ranges
+func (f TMDBDiscoverFilters) Validate() error {
+	if f.YearFrom < 0 || f.YearTo < 0 {
+		return fmt.Errorf("invalid year range")
+	}
+	if f.YearFrom > 0 && f.YearTo > 0 && f.YearFrom > f.YearTo {
+		return fmt.Errorf("year_from must not be after year_to")
+	}
+	if f.MinVoteAverage < 0 || f.MinVoteAverage > 10 {
+		return fmt.Errorf("min_rating must be between 0 and 10")
+	}
+	if f.MinVoteCount < 0 {
+		return fmt.Errorf("min_votes must not be negative")
+	}
+	if f.MinRuntime < 0 || f.MaxRuntime < 0 {
+		return fmt.Errorf("invalid runtime range")
+	}
+	if f.MinRuntime > 0 && f.MaxRuntime > 0 && f.MinRuntime > f.MaxRuntime {
+		return fmt.Errorf("min_runtime must not be more than max_runtime")
+	}
+	if f.OriginalLanguage != "" && !tmdbOriginalLanguagePattern.MatchString(f.OriginalLanguage) {
+		return fmt.Errorf("invalid language %q, expected a code like \"ja\"", f.OriginalLanguage)
+	}
+	if f.WatchRegion != "" && !tmdbRegionPattern.MatchString(f.WatchRegion) {
+		return fmt.Errorf("invalid region %q, expected a country code like \"FR\"", f.WatchRegion)
+	}
+	if f.SortBy != "" && !validDiscoverSort(f.SortBy) {
+		return fmt.Errorf("invalid sort_by %q, must be one of %s", f.SortBy, strings.Join(TMDBDiscoverSortOptions, ", "))
+	}
+	return nil
+}
+
+func validDiscoverSort(sortBy string) bool {
+	for _, option := range TMDBDiscoverSortOptions {
+		if sortBy == option {
+			return true
+		}
+	}
+	return false
+}
+
+// params converts the filters to TMDB /discover parameters for the media type
+func (f TMDBDiscoverFilters) params(mediaType string, locale TMDBLocale) url.Values {
+	params := url.Values{}
+
+	dateField := "primary_release_date"
+	if mediaType == "tv" {
+		dateField = "first_air_date"
+	}
+
+	sortBy := f.SortBy
+	if sortBy == "" {
+		sortBy = "popularity.desc"
+	}
+	params.Set("sort_by", strings.Replace(sortBy, "release_date", dateField, 1))
+
+	if len(f.Genres) > 0 {
+		params.Set("with_genres", joinInts(f.Genres, ","))
+	}
+	if f.YearFrom > 0 {
+		params.Set(dateField+".gte", fmt.Sprintf("%d-01-01", f.YearFrom))
+	}
+	if f.YearTo > 0 {
+		params.Set(dateField+".lte", fmt.Sprintf("%d-12-31", f.YearTo))
+	}
+	if f.MinVoteAverage > 0 {
+		params.Set("vote_average.gte", strconv.FormatFloat(f.MinVoteAverage, 'f', -1, 64))
+	}
+	if f.MinVoteCount > 0 {
+		params.Set("vote_count.gte", strconv.Itoa(f.MinVoteCount))
+	}
+	if f.MinRuntime > 0 {
+		params.Set("with_runtime.gte", strconv.Itoa(f.MinRuntime))
+	}
+	if f.MaxRuntime > 0 {
+		params.Set("with_runtime.lte", strconv.Itoa(f.MaxRuntime))
+	}
+	if f.OriginalLanguage != "" {
+		params.Set("with_original_language", f.OriginalLanguage)
+	}
+	if len(f.WatchProviders) > 0 {
+		// TMDB requires a region for provider filters
+		region := f.WatchRegion
+		if region == "" {
+			region = locale.CertificationCountry()
+		}
+		params.Set("with_watch_providers", joinInts(f.WatchProviders, "|"))
+		params.Set("watch_region", region)
+	}
+	addCertificationParams(params, locale)
+
+	return params
+}
+
+// cacheKey identifies the filters in TMDB cache keys
+func (f TMDBDiscoverFilters) cacheKey(mediaType string) string {
+	// The locale is added to the key by cachedFetch
+	return f.params(mediaType, TMDBLocale{}).Encode()
+}
+
+func joinInts(values []int, sep string) string {
+	parts := make([]string, len(values))
+	for i, value := range values {
+		parts[i] = strconv.Itoa(value)
+	}
+	return strings.Join(parts, sep)
+}
+
+// DiscoverContext fetches movies or TV shows matching the filters from TMDB
+func (s *TMDBService) DiscoverContext(ctx context.Context, mediaType string, filters TMDBDiscoverFilters, page int) (*TMDBSearchResult, error) {
+	params := filters.params(mediaType, s.locale(ctx))
+	params.Set("page", strconv.Itoa(page))
+
+	return s.getList(ctx, "/discover/"+mediaType, mediaType, params, "discover titles")
+}
+
+// GetGenresContext fetches TMDB's movie or TV genres, with names in the user's language
+func (s *TMDBService) GetGenresContext(ctx context.Context, mediaType string) ([]TMDBGenre, error) {
+	result, err := tmdbGet[struct {
+		Genres []TMDBGenre `json:"genres"`
+	}](ctx, s, "/genre/"+mediaType+"/list", nil, "get genres")
+	if err != nil {
+		return nil, err
+	}
+	return result.Genres, nil
+}
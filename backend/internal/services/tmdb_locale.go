@@ -0,0 +1,92 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+)
+
+// TMDBLocale holds the language, region and content preferences used for TMDB calls.
+// Handlers put the current user's locale in the request context with WithTMDBLocale.
+// IncludeAdult is a content restriction set by admins and applies to every result list.
+// MaxCertification is the user's own discover preference: TMDB only filters /discover
+// by certification, so it limits popular, top rated, upcoming and discover lists but
+// not search, trending, recommendations, For You, credits or collections.
+type TMDBLocale struct {
+	Language         string // ISO 639-1 language, optionally with a region, e.g. "fr-FR"
+	Region           string // ISO 3166-1 country used for release dates and certifications, e.g. "FR"
+	IncludeAdult     bool   // Include adult titles in search, list and credit results
+	MaxCertification string // Highest certification in discover lists for Region, e.g. "PG-13"
+}
+
+var (
+	tmdbLanguagePattern = regexp.MustCompile(`^[a-z]{2}(-[A-Z]{2})?$`)
+	tmdbRegionPattern   = regexp.MustCompile(`^[A-Z]{2}$`)
+)
+
+// Validate checks the language and region formats. Empty values are allowed.
+func (l TMDBLocale) Validate() error {
+	if l.Language != "" && !tmdbLanguagePattern.MatchString(l.Language) {
+		return fmt.Errorf("invalid language %q, expected a code like \"fr\" or \"fr-FR\"", l.Language)
+	}
+	if l.Region != "" && !tmdbRegionPattern.MatchString(l.Region) {
+		return fmt.Errorf("invalid region %q, expected a country code like \"FR\"", l.Region)
+	}
+	if len(l.MaxCertification) > 10 {
+		return fmt.Errorf("invalid max certification %q", l.MaxCertification)
+	}
+	return nil
+}
+
+// CertificationCountry returns the country whose certifications apply, US by default
+func (l TMDBLocale) CertificationCountry() string {
+	if l.Region != "" {
+		return l.Region
+	}
+	return "US"
+}
+
+// withDefaults fills empty fields from defaults
+func (l TMDBLocale) withDefaults(defaults TMDBLocale) TMDBLocale {
+	if l.Language == "" {
+		l.Language = defaults.Language
+	}
+	if l.Region == "" {
+		l.Region = defaults.Region
+	}
+	return l
+}
+
+// cacheKey identifies the locale in TMDB cache keys. It is empty for the default locale,
+// so entries cached without a locale keep their keys.
+func (l TMDBLocale) cacheKey() string {
+	if l == (TMDBLocale{}) {
+		return ""
+	}
+	return fmt.Sprintf("%s:%s:%t:%s", l.Language, l.Region, l.IncludeAdult, l.MaxCertification)
+}
+
+// apply adds the locale's parameters to a TMDB request
+func (l TMDBLocale) apply(params url.Values) {
+	if l.Language != "" {
+		params.Set("language", l.Language)
+	}
+	if l.Region != "" {
+		params.Set("region", l.Region)
+	}
+	params.Set("include_adult", fmt.Sprintf("%t", l.IncludeAdult))
+}
+
+type tmdbLocaleKey struct{}
+
+// WithTMDBLocale returns a context carrying the locale for TMDB calls
+func WithTMDBLocale(ctx context.Context, locale TMDBLocale) context.Context {
+	return context.WithValue(ctx, tmdbLocaleKey{}, locale)
+}
+
+// TMDBLocaleFromContext returns the locale set with WithTMDBLocale, or the zero locale
+func TMDBLocaleFromContext(ctx context.Context) TMDBLocale {
+	locale, _ := ctx.Value(tmdbLocaleKey{}).(TMDBLocale)
+	return locale
+}
@@ -1,11 +1,15 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/jacob-fain/MRS/internal/testutil"
 )
@@ -53,7 +57,7 @@ func TestTMDBService_SearchMulti(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Verify request
 		testutil.AssertEqual(t, "/3/search/multi", r.URL.Path)
-		testutil.AssertEqual(t, "test-api-key", r.URL.Query().Get("api_key"))
+		testutil.AssertEqual(t, "Bearer test-api-key", r.Header.Get("Authorization"))
 		
 		query := r.URL.Query().Get("query")
 		
@@ -101,13 +105,9 @@ func TestTMDBService_SearchMulti(t *testing.T) {
 	// Create service with test server URL
 	service := &TMDBService{
 		apiKey:     "test-api-key",
+		baseURL:    server.URL + "/3",
 		httpClient: &http.Client{},
 	}
-	
-	// Override base URL for testing
-	originalBaseURL := tmdbBaseURL
-	defer func() { tmdbBaseURL = originalBaseURL }()
-	tmdbBaseURL = server.URL
 
 	tests := []struct {
 		name          string
@@ -139,7 +139,7 @@ func TestTMDBService_SearchMulti(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result, err := service.SearchMulti(tt.query, tt.page)
+			result, err := service.SearchMultiContext(context.Background(), tt.query, tt.page)
 			
 			if tt.wantErr {
 				testutil.AssertError(t, err)
@@ -199,8 +199,8 @@ func TestTMDBService_GetMovieDetails(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Verify request
 		testutil.AssertEqual(t, "/3/movie/603", r.URL.Path)
-		testutil.AssertEqual(t, "test-api-key", r.URL.Query().Get("api_key"))
-		testutil.AssertEqual(t, "credits,videos,external_ids", r.URL.Query().Get("append_to_response"))
+		testutil.AssertEqual(t, "Bearer test-api-key", r.Header.Get("Authorization"))
+		testutil.AssertEqual(t, "credits,videos,external_ids,release_dates", r.URL.Query().Get("append_to_response"))
 		
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte(`{
@@ -226,15 +226,11 @@ func TestTMDBService_GetMovieDetails(t *testing.T) {
 	// Create service with test server URL
 	service := &TMDBService{
 		apiKey:     "test-api-key",
+		baseURL:    server.URL + "/3",
 		httpClient: &http.Client{},
 	}
-	
-	// Override base URL for testing
-	originalBaseURL := tmdbBaseURL
-	defer func() { tmdbBaseURL = originalBaseURL }()
-	tmdbBaseURL = server.URL
 
-	movie, err := service.GetMovieDetails(603)
+	movie, err := service.GetMovieDetailsContext(context.Background(), 603)
 	testutil.AssertNoError(t, err)
 	
 	// Verify response
@@ -247,4 +243,314 @@ func TestTMDBService_GetMovieDetails(t *testing.T) {
 	// Verify image URLs
 	expectedPoster := fmt.Sprintf("%s/w500%s", tmdbImageBase, movie.PosterPath)
 	testutil.AssertEqual(t, expectedPoster, movie.PosterURL)
-}
\ No newline at end of file
+}
+
+func TestTMDBService_GetPopularMovies(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		testutil.AssertEqual(t, "/3/movie/popular", r.URL.Path)
+		testutil.AssertEqual(t, "2", r.URL.Query().Get("page"))
+		testutil.AssertEqual(t, "application/json", r.Header.Get("Accept"))
+
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{
+			"page": 2,
+			"results": [
+				{"id": 603, "title": "The Matrix", "poster_path": "/matrix.jpg"},
+				{"id": 604, "title": "No Poster"}
+			]
+		}`))
+	}))
+	defer server.Close()
+
+	var traces []TMDBTrace
+	service := &TMDBService{
+		apiKey:     "test-api-key",
+		baseURL:    server.URL + "/3",
+		httpClient: &http.Client{},
+	}
+	service.SetTracer(func(ctx context.Context, trace TMDBTrace) {
+		traces = append(traces, trace)
+	})
+
+	result, err := service.GetPopularMoviesContext(context.Background(), 2)
+	testutil.AssertNoError(t, err)
+
+	// Items without posters are dropped and media_type is filled in
+	testutil.AssertEqual(t, 1, len(result.Results))
+	testutil.AssertEqual(t, "movie", result.Results[0].MediaType)
+	testutil.AssertEqual(t, tmdbImageBase+"/w342/matrix.jpg", result.Results[0].PosterURL)
+
+	testutil.AssertEqual(t, 1, len(traces))
+	testutil.AssertEqual(t, "/movie/popular", traces[0].Path)
+	testutil.AssertEqual(t, http.StatusOK, traces[0].StatusCode)
+}
+
+func TestTMDBService_ContextCancellation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer server.Close()
+
+	service := &TMDBService{
+		apiKey:     "test-api-key",
+		baseURL:    server.URL + "/3",
+		httpClient: &http.Client{},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := service.GetMovieDetailsContext(ctx, 603)
+	testutil.AssertTrue(t, errors.Is(err, context.DeadlineExceeded), "expected deadline exceeded, got "+errString(err))
+	testutil.AssertTrue(t, time.Since(start) < time.Second, "request should stop when the context is done")
+}
+
+func TestTMDBService_Locale(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		testutil.AssertEqual(t, "fr-FR", query.Get("language"))
+		testutil.AssertEqual(t, "FR", query.Get("region"))
+		testutil.AssertEqual(t, "false", query.Get("include_adult"))
+
+		w.WriteHeader(http.StatusOK)
+		switch r.URL.Path {
+		case "/3/movie/603":
+			w.Write([]byte(`{
+				"id": 603,
+				"title": "Matrix",
+				"release_dates": {"results": [
+					{"iso_3166_1": "US", "release_dates": [{"certification": "R", "release_date": "1999-03-31T00:00:00.000Z", "type": 3}]},
+					{"iso_3166_1": "FR", "release_dates": [
+						{"certification": "", "release_date": "1999-05-01T00:00:00.000Z", "type": 1},
+						{"certification": "12", "release_date": "1999-06-23T00:00:00.000Z", "type": 3}
+					]}
+				]}
+			}`))
+		case "/3/tv/1396":
+			w.Write([]byte(`{
+				"id": 1396,
+				"name": "Breaking Bad",
+				"content_ratings": {"results": [
+					{"iso_3166_1": "US", "rating": "TV-MA"},
+					{"iso_3166_1": "FR", "rating": "16"}
+				]}
+			}`))
+		case "/3/discover/movie":
+			// Upcoming movies use French release dates and the certification limit
+			testutil.AssertTrue(t, query.Get("release_date.gte") != "", "expected a regional release date filter")
+			testutil.AssertEqual(t, "", query.Get("primary_release_date.gte"))
+			testutil.AssertEqual(t, "2|3", query.Get("with_release_type"))
+			testutil.AssertEqual(t, "FR", query.Get("certification_country"))
+			testutil.AssertEqual(t, "12", query.Get("certification.lte"))
+			w.Write([]byte(`{"page": 1, "results": [
+				{"id": 1, "title": "Film", "poster_path": "/film.jpg"},
+				{"id": 2, "title": "Adult Film", "poster_path": "/adult.jpg", "adult": true}
+			]}`))
+		case "/3/person/31/combined_credits":
+			w.Write([]byte(`{
+				"cast": [{"id": 1, "title": "Film"}, {"id": 2, "title": "Adult Film", "adult": true}],
+				"crew": [{"id": 3, "title": "Adult Film 2", "job": "Director", "adult": true}]
+			}`))
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	service := &TMDBService{
+		apiKey:     "test-api-key",
+		baseURL:    server.URL + "/3",
+		httpClient: &http.Client{},
+	}
+	ctx := WithTMDBLocale(context.Background(), TMDBLocale{
+		Language:         "fr-FR",
+		Region:           "FR",
+		MaxCertification: "12",
+	})
+
+	movie, err := service.GetMovieDetailsContext(ctx, 603)
+	testutil.AssertNoError(t, err)
+	testutil.AssertEqual(t, "12", movie.Certification)
+	testutil.AssertEqual(t, "1999-06-23", movie.LocalReleaseDate)
+
+	tv, err := service.GetTVDetailsContext(ctx, 1396)
+	testutil.AssertNoError(t, err)
+	testutil.AssertEqual(t, "16", tv.Certification)
+
+	upcoming, err := service.GetUpcomingMoviesContext(ctx, 1)
+	testutil.AssertNoError(t, err)
+	testutil.AssertEqual(t, 1, len(upcoming.Results))
+	testutil.AssertEqual(t, "Film", upcoming.Results[0].Title)
+
+	// Combined credits ignore include_adult, so adult credits are filtered here
+	credits, err := service.GetPersonCreditsContext(ctx, 31)
+	testutil.AssertNoError(t, err)
+	testutil.AssertEqual(t, 1, len(credits.Cast))
+	testutil.AssertEqual(t, "Film", credits.Cast[0].Title)
+	testutil.AssertEqual(t, 0, len(credits.Crew))
+}
+
+func TestTMDBService_DefaultLocale(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		testutil.AssertEqual(t, "es-ES", query.Get("language"))
+		testutil.AssertEqual(t, "ES", query.Get("region"))
+
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"id": 603, "title": "Matrix", "release_dates": {"results": [
+			{"iso_3166_1": "US", "release_dates": [{"certification": "R", "type": 3}]}
+		]}}`))
+	}))
+	defer server.Close()
+
+	service := &TMDBService{
+		apiKey:        "test-api-key",
+		baseURL:       server.URL + "/3",
+		httpClient:    &http.Client{},
+		defaultLocale: TMDBLocale{Language: "en-US", Region: "ES"},
+	}
+
+	// The user's language wins, the server region fills in the rest
+	ctx := WithTMDBLocale(context.Background(), TMDBLocale{Language: "es-ES"})
+	movie, err := service.GetMovieDetailsContext(ctx, 603)
+	testutil.AssertNoError(t, err)
+
+	// No Spanish certification
+	testutil.AssertEqual(t, "", movie.Certification)
+}
+
+func TestTMDBService_GetRecommendations(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"page": 1, "results": [{"id": 1398, "name": "The Sopranos", "poster_path": "/sopranos.jpg"}]}`))
+	}))
+	defer server.Close()
+
+	service := &TMDBService{
+		apiKey:     "test-api-key",
+		baseURL:    server.URL + "/3",
+		httpClient: &http.Client{},
+	}
+
+	result, err := service.GetRecommendationsContext(context.Background(), "tv", 1396, 1)
+	testutil.AssertNoError(t, err)
+	testutil.AssertEqual(t, "tv", result.Results[0].MediaType)
+
+	_, err = service.GetSimilarContext(context.Background(), "movie", 603, 2)
+	testutil.AssertNoError(t, err)
+
+	testutil.AssertEqual(t, "/3/tv/1396/recommendations", paths[0])
+	testutil.AssertEqual(t, "/3/movie/603/similar", paths[1])
+}
+
+func TestTMDBService_Discover(t *testing.T) {
+	var query url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"page": 1, "results": [{"id": 1396, "name": "Breaking Bad", "poster_path": "/bb.jpg"}]}`))
+	}))
+	defer server.Close()
+
+	service := &TMDBService{
+		apiKey:     "test-api-key",
+		baseURL:    server.URL + "/3",
+		httpClient: &http.Client{},
+	}
+
+	filters := TMDBDiscoverFilters{
+		Genres:         []int{18, 80},
+		YearFrom:       2000,
+		YearTo:         2010,
+		MinVoteAverage: 8.5,
+		WatchProviders: []int{8, 337},
+		SortBy:         "release_date.desc",
+	}
+	testutil.AssertNoError(t, filters.Validate())
+
+	result, err := service.DiscoverContext(context.Background(), "tv", filters, 3)
+	testutil.AssertNoError(t, err)
+	testutil.AssertEqual(t, "tv", result.Results[0].MediaType)
+
+	testutil.AssertEqual(t, "3", query.Get("page"))
+	testutil.AssertEqual(t, "18,80", query.Get("with_genres"))
+	testutil.AssertEqual(t, "2000-01-01", query.Get("first_air_date.gte"))
+	testutil.AssertEqual(t, "2010-12-31", query.Get("first_air_date.lte"))
+	testutil.AssertEqual(t, "8.5", query.Get("vote_average.gte"))
+	testutil.AssertEqual(t, "8|337", query.Get("with_watch_providers"))
+	testutil.AssertEqual(t, "US", query.Get("watch_region"))
+	testutil.AssertEqual(t, "first_air_date.desc", query.Get("sort_by"))
+
+	// Invalid filters
+	testutil.AssertError(t, TMDBDiscoverFilters{MinVoteAverage: 11}.Validate())
+	testutil.AssertError(t, TMDBDiscoverFilters{SortBy: "revenue.desc"}.Validate())
+	testutil.AssertError(t, TMDBDiscoverFilters{OriginalLanguage: "english"}.Validate())
+}
+
+func TestTMDBService_GetCollection(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		testutil.AssertEqual(t, "/3/collection/119", r.URL.Path)
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"id": 119, "name": "The Lord of the Rings Collection", "poster_path": "/lotr.jpg", "parts": [
+			{"id": 999, "title": "The Hunt for Gollum", "release_date": ""},
+			{"id": 122, "title": "The Return of the King", "release_date": "2003-12-01"},
+			{"id": 120, "title": "The Fellowship of the Ring", "release_date": "2001-12-18", "poster_path": "/fellowship.jpg"}
+		]}`))
+	}))
+	defer server.Close()
+
+	service := &TMDBService{
+		apiKey:     "test-api-key",
+		baseURL:    server.URL + "/3",
+		httpClient: &http.Client{},
+	}
+
+	collection, err := service.GetCollectionContext(context.Background(), 119)
+	testutil.AssertNoError(t, err)
+	testutil.AssertEqual(t, "https://image.tmdb.org/t/p/w500/lotr.jpg", collection.PosterURL)
+
+	// Parts are in release order, unreleased parts last
+	testutil.AssertEqual(t, 3, len(collection.Parts))
+	testutil.AssertEqual(t, 120, collection.Parts[0].ID)
+	testutil.AssertEqual(t, 122, collection.Parts[1].ID)
+	testutil.AssertEqual(t, 999, collection.Parts[2].ID)
+	testutil.AssertEqual(t, "movie", collection.Parts[0].MediaType)
+	testutil.AssertEqual(t, "https://image.tmdb.org/t/p/w342/fellowship.jpg", collection.Parts[0].PosterURL)
+}
+
+func TestTMDBService_FindByIMDbID(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		testutil.AssertEqual(t, "/3/find/tt1375666", r.URL.Path)
+		testutil.AssertEqual(t, "imdb_id", r.URL.Query().Get("external_source"))
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{
+			"movie_results": [{"id": 27205, "title": "Inception", "release_date": "2010-07-15", "poster_path": "/inception.jpg"}],
+			"tv_results": [],
+			"person_results": []
+		}`))
+	}))
+	defer server.Close()
+
+	service := &TMDBService{
+		apiKey:     "test-api-key",
+		baseURL:    server.URL + "/3",
+		httpClient: &http.Client{},
+	}
+
+	result, err := service.FindByIMDbIDContext(context.Background(), "tt1375666")
+	testutil.AssertNoError(t, err)
+	testutil.AssertEqual(t, 1, len(result.MovieResults))
+	testutil.AssertEqual(t, 0, len(result.TVResults))
+	testutil.AssertEqual(t, 27205, result.MovieResults[0].ID)
+	testutil.AssertEqual(t, "movie", result.MovieResults[0].MediaType)
+	testutil.AssertEqual(t, "https://image.tmdb.org/t/p/w342/inception.jpg", result.MovieResults[0].PosterURL)
+
+	_, err = service.FindByIMDbIDContext(context.Background(), "")
+	testutil.AssertError(t, err)
+}
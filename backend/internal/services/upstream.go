@@ -0,0 +1,390 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Errors returned by upstream API clients. Handlers map them to 503 responses.
+var (
+	ErrUpstreamRateLimited = errors.New("upstream rate limited")
+	ErrUpstreamUnavailable = errors.New("upstream unavailable")
+)
+
+// UpstreamError describes a failed call to an upstream API
+type UpstreamError struct {
+	Upstream   string
+	StatusCode int           // 0 when no response was received
+	RetryAfter time.Duration // Set when the upstream asked us to back off
+	Err        error         // ErrUpstreamRateLimited or ErrUpstreamUnavailable
+	Cause      error         // Underlying transport error, if any
+}
+
+func (e *UpstreamError) Error() string {
+	msg := fmt.Sprintf("%s: %v", e.Upstream, e.Err)
+	if e.StatusCode != 0 {
+		msg += fmt.Sprintf(" (status %d)", e.StatusCode)
+	}
+	if e.Cause != nil {
+		msg += fmt.Sprintf(": %v", e.Cause)
+	}
+	return msg
+}
+
+func (e *UpstreamError) Unwrap() error {
+	return e.Err
+}
+
+// UpstreamConfig configures rate limiting, retries and the circuit breaker for one upstream
+type UpstreamConfig struct {
+	RequestsPerSecond int           // Token bucket refill rate, 0 disables rate limiting
+	Burst             int           // Token bucket size
+	MaxRetries        int           // Retries after the first attempt for 429, 5xx and network errors
+	BaseBackoff       time.Duration // First retry delay, doubled on every attempt
+	MaxBackoff        time.Duration // Upper bound for a single retry delay
+	FailureThreshold  int           // Consecutive failures that open the circuit, 0 disables the breaker
+	OpenDuration      time.Duration // How long the circuit stays open before a trial request
+	AttemptTimeout    time.Duration // Time to wait for response headers on each attempt
+	Timeout           time.Duration // Overall timeout including retries
+}
+
+// upstreamConfigFromEnv applies <PREFIX>_RATE_LIMIT, <PREFIX>_MAX_RETRIES and
+// <PREFIX>_BREAKER_THRESHOLD overrides to the defaults
+func upstreamConfigFromEnv(prefix string, config UpstreamConfig) (UpstreamConfig, error) {
+	var err error
+	if config.RequestsPerSecond, err = envInt(prefix+"_RATE_LIMIT", config.RequestsPerSecond); err != nil {
+		return config, err
+	}
+	if config.MaxRetries, err = envInt(prefix+"_MAX_RETRIES", config.MaxRetries); err != nil {
+		return config, err
+	}
+	if config.FailureThreshold, err = envInt(prefix+"_BREAKER_THRESHOLD", config.FailureThreshold); err != nil {
+		return config, err
+	}
+	return config, nil
+}
+
+// NewUpstreamHTTPClient creates an HTTP client that rate limits, retries with
+// jittered exponential backoff and stops calling an upstream that keeps failing
+func NewUpstreamHTTPClient(name string, config UpstreamConfig) *http.Client {
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	base.ResponseHeaderTimeout = config.AttemptTimeout
+
+	return &http.Client{
+		Timeout:   config.Timeout,
+		Transport: NewUpstreamTransport(name, config, base),
+	}
+}
+
+// UpstreamTransport is an http.RoundTripper that protects an upstream API
+type UpstreamTransport struct {
+	name    string
+	config  UpstreamConfig
+	next    http.RoundTripper
+	limiter *tokenBucket
+	breaker *circuitBreaker
+}
+
+// NewUpstreamTransport wraps next with rate limiting, retries and a circuit breaker
+func NewUpstreamTransport(name string, config UpstreamConfig, next http.RoundTripper) *UpstreamTransport {
+	return &UpstreamTransport{
+		name:    name,
+		config:  config,
+		next:    next,
+		limiter: newTokenBucket(config.RequestsPerSecond, config.Burst),
+		breaker: newCircuitBreaker(config.FailureThreshold, config.OpenDuration),
+	}
+}
+
+// RoundTrip sends the request, retrying 429, 5xx and network errors. When all
+// attempts fail it returns an *UpstreamError instead of the last response.
+func (t *UpstreamTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	allowed, trial := t.breaker.allow()
+	if !allowed {
+		return nil, &UpstreamError{Upstream: t.name, Err: ErrUpstreamUnavailable, Cause: errors.New("circuit breaker open")}
+	}
+	// A trial request that ends without a success or failure, e.g. rate limited
+	// or canceled, hands the trial to the next request
+	defer t.breaker.release(trial)
+
+	// Requests with a body can only be retried if it can be rewound
+	canRetry := req.Body == nil || req.GetBody != nil
+
+	for attempt := 0; ; attempt++ {
+		if err := t.limiter.wait(ctx); err != nil {
+			return nil, err
+		}
+
+		attemptReq := req
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			attemptReq = req.Clone(ctx)
+			attemptReq.Body = body
+		}
+
+		resp, err := t.next.RoundTrip(attemptReq)
+		upstreamErr := t.classify(resp, err)
+		if upstreamErr == nil {
+			if err == nil {
+				t.breaker.success()
+			}
+			return resp, err
+		}
+
+		// Rate limiting means the upstream is healthy, only count real failures
+		if !errors.Is(upstreamErr, ErrUpstreamRateLimited) {
+			t.breaker.failure()
+		}
+		if upstreamErr.RetryAfter > 0 {
+			t.limiter.pauseFor(upstreamErr.RetryAfter)
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		// Don't hold the caller for longer than a normal backoff
+		tooLong := t.config.MaxBackoff > 0 && upstreamErr.RetryAfter > t.config.MaxBackoff
+		if attempt >= t.config.MaxRetries || !canRetry || tooLong || t.breaker.open() {
+			log.Printf("Upstream %s failed after %d attempts: %v", t.name, attempt+1, upstreamErr)
+			return nil, upstreamErr
+		}
+
+		delay := t.backoff(attempt)
+		if upstreamErr.RetryAfter > delay {
+			delay = upstreamErr.RetryAfter
+		}
+		if err := sleepContext(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// classify returns an *UpstreamError for retryable outcomes, or nil when the
+// response should be handed to the caller
+func (t *UpstreamTransport) classify(resp *http.Response, err error) *UpstreamError {
+	if err != nil {
+		// The caller gave up, retrying will not help
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return nil
+		}
+		return &UpstreamError{Upstream: t.name, Err: ErrUpstreamUnavailable, Cause: err}
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return &UpstreamError{
+			Upstream:   t.name,
+			StatusCode: resp.StatusCode,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+			Err:        ErrUpstreamRateLimited,
+		}
+	case resp.StatusCode >= 500:
+		return &UpstreamError{
+			Upstream:   t.name,
+			StatusCode: resp.StatusCode,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+			Err:        ErrUpstreamUnavailable,
+		}
+	}
+	return nil
+}
+
+// backoff returns a jittered delay between half and all of BaseBackoff * 2^attempt
+func (t *UpstreamTransport) backoff(attempt int) time.Duration {
+	delay := t.config.BaseBackoff << attempt
+	if delay <= 0 || (t.config.MaxBackoff > 0 && delay > t.config.MaxBackoff) {
+		delay = t.config.MaxBackoff
+	}
+	if delay <= 0 {
+		return 0
+	}
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// parseRetryAfter parses a Retry-After header in seconds or HTTP date format
+func parseRetryAfter(value string) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if delay := time.Until(date); delay > 0 {
+			return delay
+		}
+	}
+	return 0
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// tokenBucket is a token bucket rate limiter shared by all requests to one upstream
+type tokenBucket struct {
+	mu          sync.Mutex
+	rate        float64 // Tokens added per second, 0 disables limiting
+	capacity    float64
+	tokens      float64
+	last        time.Time
+	pausedUntil time.Time
+}
+
+func newTokenBucket(ratePerSecond, burst int) *tokenBucket {
+	if burst <= 0 {
+		burst = ratePerSecond
+	}
+	return &tokenBucket{
+		rate:     float64(ratePerSecond),
+		capacity: float64(burst),
+		tokens:   float64(burst),
+		last:     time.Now(),
+	}
+}
+
+// wait blocks until a token is available or the context is done
+func (b *tokenBucket) wait(ctx context.Context) error {
+	for {
+		delay := b.reserve()
+		if delay == 0 {
+			return nil
+		}
+		if err := sleepContext(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// reserve takes a token and returns 0, or returns how long to wait for one
+func (b *tokenBucket) reserve() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	if now.Before(b.pausedUntil) {
+		return b.pausedUntil.Sub(now)
+	}
+	if b.rate <= 0 {
+		return 0
+	}
+
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.capacity {
+		b.tokens = b.capacity
+	}
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
+
+// pauseFor stops handing out tokens, used when the upstream sends Retry-After
+func (b *tokenBucket) pauseFor(d time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if until := time.Now().Add(d); until.After(b.pausedUntil) {
+		b.pausedUntil = until
+	}
+}
+
+// circuitBreaker stops calls to an upstream after consecutive failures. Once
+// the open period has passed a single trial request is let through.
+type circuitBreaker struct {
+	mu           sync.Mutex
+	threshold    int // 0 disables the breaker
+	openDuration time.Duration
+	failures     int
+	openUntil    time.Time
+	trialPending bool
+	trial        uint64 // Identifies the pending trial request
+}
+
+func newCircuitBreaker(threshold int, openDuration time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		threshold:    threshold,
+		openDuration: openDuration,
+	}
+}
+
+// allow reports whether a request may be sent. When it is the trial request
+// of a half-open circuit, trial identifies it for release.
+func (b *circuitBreaker) allow() (allowed bool, trial uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.threshold <= 0 || b.failures < b.threshold {
+		return true, 0
+	}
+	if time.Now().Before(b.openUntil) || b.trialPending {
+		return false, 0
+	}
+	b.trialPending = true
+	b.trial++
+	return true, b.trial
+}
+
+// open reports whether the circuit is open, without claiming the trial
+func (b *circuitBreaker) open() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.threshold > 0 && b.failures >= b.threshold && time.Now().Before(b.openUntil)
+}
+
+func (b *circuitBreaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures = 0
+	b.trialPending = false
+}
+
+// release gives up a trial request that ended without a result. It does
+// nothing once the trial was settled or handed to another request.
+func (b *circuitBreaker) release(trial uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if trial != 0 && b.trial == trial {
+		b.trialPending = false
+	}
+}
+
+func (b *circuitBreaker) failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	b.trialPending = false
+	if b.threshold > 0 && b.failures >= b.threshold {
+		b.openUntil = time.Now().Add(b.openDuration)
+	}
+}
@@ -0,0 +1,241 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jacob-fain/MRS/internal/testutil"
+)
+
+func testUpstreamConfig() UpstreamConfig {
+	return UpstreamConfig{
+		MaxRetries:       2,
+		BaseBackoff:      time.Millisecond,
+		MaxBackoff:       10 * time.Millisecond,
+		FailureThreshold: 0,
+		Timeout:          5 * time.Second,
+	}
+}
+
+// newStatusServer responds with the given statuses in order, then 200
+func newStatusServer(t *testing.T, headers map[string]string, statuses ...int) (*httptest.Server, *int32) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		call := int(atomic.AddInt32(&calls, 1))
+		for key, value := range headers {
+			w.Header().Set(key, value)
+		}
+		if call <= len(statuses) {
+			w.WriteHeader(statuses[call-1])
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func TestUpstreamTransport_Retries(t *testing.T) {
+	tests := []struct {
+		name          string
+		statuses      []int
+		headers       map[string]string
+		expectedCalls int32
+		expectedErr   error
+		expectedCode  int
+	}{
+		{
+			name:          "success without retry",
+			expectedCalls: 1,
+			expectedCode:  http.StatusOK,
+		},
+		{
+			name:          "retries server errors",
+			statuses:      []int{http.StatusBadGateway, http.StatusServiceUnavailable},
+			expectedCalls: 3,
+			expectedCode:  http.StatusOK,
+		},
+		{
+			name:          "does not retry client errors",
+			statuses:      []int{http.StatusNotFound},
+			expectedCalls: 1,
+			expectedCode:  http.StatusNotFound,
+		},
+		{
+			name:          "gives up after max retries",
+			statuses:      []int{500, 500, 500, 500},
+			expectedCalls: 3,
+			expectedErr:   ErrUpstreamUnavailable,
+		},
+		{
+			name:          "rate limited after max retries",
+			statuses:      []int{429, 429, 429},
+			expectedCalls: 3,
+			expectedErr:   ErrUpstreamRateLimited,
+		},
+		{
+			name:          "gives up when Retry-After is too long",
+			statuses:      []int{429},
+			headers:       map[string]string{"Retry-After": "120"},
+			expectedCalls: 1,
+			expectedErr:   ErrUpstreamRateLimited,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, calls := newStatusServer(t, tt.headers, tt.statuses...)
+			client := NewUpstreamHTTPClient("TMDB", testUpstreamConfig())
+
+			resp, err := client.Get(server.URL)
+			testutil.AssertEqual(t, tt.expectedCalls, atomic.LoadInt32(calls))
+
+			if tt.expectedErr != nil {
+				testutil.AssertTrue(t, errors.Is(err, tt.expectedErr), "expected "+tt.expectedErr.Error()+", got "+errString(err))
+				return
+			}
+			testutil.AssertNoError(t, err)
+			defer resp.Body.Close()
+			testutil.AssertEqual(t, tt.expectedCode, resp.StatusCode)
+		})
+	}
+}
+
+func TestUpstreamTransport_HonoursRetryAfter(t *testing.T) {
+	server, calls := newStatusServer(t, map[string]string{"Retry-After": "1"}, http.StatusTooManyRequests)
+
+	config := testUpstreamConfig()
+	config.MaxBackoff = 2 * time.Second
+	client := NewUpstreamHTTPClient("TMDB", config)
+
+	start := time.Now()
+	resp, err := client.Get(server.URL)
+	testutil.AssertNoError(t, err)
+	resp.Body.Close()
+
+	testutil.AssertEqual(t, int32(2), atomic.LoadInt32(calls))
+	testutil.AssertTrue(t, time.Since(start) >= time.Second, "should wait for Retry-After before retrying")
+}
+
+func TestUpstreamTransport_CircuitBreaker(t *testing.T) {
+	server, calls := newStatusServer(t, nil, 500, 500, 500, 500)
+
+	config := testUpstreamConfig()
+	config.MaxRetries = 0
+	config.FailureThreshold = 2
+	config.OpenDuration = 50 * time.Millisecond
+	client := NewUpstreamHTTPClient("TMDB", config)
+
+	// Two failures open the circuit
+	for i := 0; i < 2; i++ {
+		_, err := client.Get(server.URL)
+		testutil.AssertTrue(t, errors.Is(err, ErrUpstreamUnavailable), "expected upstream unavailable")
+	}
+
+	// Open circuit fails fast without calling the upstream
+	_, err := client.Get(server.URL)
+	testutil.AssertTrue(t, errors.Is(err, ErrUpstreamUnavailable), "expected upstream unavailable")
+	testutil.AssertEqual(t, int32(2), atomic.LoadInt32(calls))
+
+	// After the open period a failing trial request opens it again
+	time.Sleep(60 * time.Millisecond)
+	client.Get(server.URL)
+	testutil.AssertEqual(t, int32(3), atomic.LoadInt32(calls))
+	client.Get(server.URL)
+	testutil.AssertEqual(t, int32(3), atomic.LoadInt32(calls))
+
+	// A successful trial request closes it
+	time.Sleep(60 * time.Millisecond)
+	client.Get(server.URL) // 4th call, still 500
+	time.Sleep(60 * time.Millisecond)
+	resp, err := client.Get(server.URL)
+	testutil.AssertNoError(t, err)
+	resp.Body.Close()
+	resp, err = client.Get(server.URL)
+	testutil.AssertNoError(t, err)
+	resp.Body.Close()
+	testutil.AssertEqual(t, int32(6), atomic.LoadInt32(calls))
+}
+
+func TestUpstreamTransport_CircuitBreakerReleasesTrial(t *testing.T) {
+	tests := []struct {
+		name  string
+		trial func(t *testing.T, client *http.Client, url string, status *int32)
+	}{
+		{
+			name: "rate limited trial",
+			trial: func(t *testing.T, client *http.Client, url string, status *int32) {
+				atomic.StoreInt32(status, http.StatusTooManyRequests)
+				_, err := client.Get(url)
+				testutil.AssertTrue(t, errors.Is(err, ErrUpstreamRateLimited), "expected rate limited, got "+errString(err))
+			},
+		},
+		{
+			name: "canceled trial",
+			trial: func(t *testing.T, client *http.Client, url string, status *int32) {
+				atomic.StoreInt32(status, http.StatusOK)
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+				_, err := client.Do(req)
+				testutil.AssertTrue(t, errors.Is(err, context.Canceled), "expected canceled, got "+errString(err))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := int32(http.StatusInternalServerError)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(int(atomic.LoadInt32(&status)))
+			}))
+			t.Cleanup(server.Close)
+
+			config := testUpstreamConfig()
+			config.MaxRetries = 0
+			config.FailureThreshold = 1
+			config.OpenDuration = 20 * time.Millisecond
+			client := NewUpstreamHTTPClient("TMDB", config)
+
+			// Open the circuit, then let it go half-open
+			_, err := client.Get(server.URL)
+			testutil.AssertTrue(t, errors.Is(err, ErrUpstreamUnavailable), "expected upstream unavailable")
+			time.Sleep(30 * time.Millisecond)
+
+			tt.trial(t, client, server.URL, &status)
+
+			// The trial ended without a verdict, so the next request gets to try
+			atomic.StoreInt32(&status, http.StatusOK)
+			resp, err := client.Get(server.URL)
+			testutil.AssertNoError(t, err)
+			resp.Body.Close()
+		})
+	}
+}
+
+func TestTokenBucket_LimitsRate(t *testing.T) {
+	bucket := newTokenBucket(10, 2)
+
+	// The burst is available immediately
+	testutil.AssertEqual(t, time.Duration(0), bucket.reserve())
+	testutil.AssertEqual(t, time.Duration(0), bucket.reserve())
+
+	// The next token takes about 100ms at 10 per second
+	delay := bucket.reserve()
+	testutil.AssertTrue(t, delay > 50*time.Millisecond && delay <= 100*time.Millisecond, "expected ~100ms delay, got "+delay.String())
+
+	bucket.pauseFor(time.Second)
+	testutil.AssertTrue(t, bucket.reserve() > 900*time.Millisecond, "paused bucket should not hand out tokens")
+}
+
+func errString(err error) string {
+	if err == nil {
+		return "nil"
+	}
+	return err.Error()
+}
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jacob-fain/MRS/internal/models"
+	"gorm.io/gorm"
+)
+
+// WatchlistService turns watchlist items into requests once the titles are released
+type WatchlistService struct {
+	db           *gorm.DB
+	tmdbService  TMDBServiceInterface
+	auditService *AuditService
+	notifier     Notifier
+}
+
+// NewWatchlistService creates a new watchlist service
+func NewWatchlistService(db *gorm.DB, tmdbService TMDBServiceInterface, auditService *AuditService, notifier Notifier) *WatchlistService {
+	return &WatchlistService{
+		db:           db,
+		tmdbService:  tmdbService,
+		auditService: auditService,
+		notifier:     notifier,
+	}
+}
+
+// WatchlistResult summarizes a single watchlist run
+type WatchlistResult struct {
+	Checked   int
+	Converted int
+}
+
+// Run checks every pending watchlist item against TMDB. Movies are converted once a
+// digital or physical release exists, TV shows once their first episode has aired.
+func (s *WatchlistService) Run(ctx context.Context, now time.Time) (WatchlistResult, error) {
+	var result WatchlistResult
+
+	var items []models.WatchlistItem
+	if err := s.db.Preload("User").Where("converted_at IS NULL").Order("id").Find(&items).Error; err != nil {
+		return result, fmt.Errorf("failed to get watchlist items: %w", err)
+	}
+
+	today := now.Format("2006-01-02")
+	for _, item := range items {
+		// Items of deleted users wait until the user is restored
+		if item.User.ID == 0 {
+			continue
+		}
+
+		releaseDate, genres, err := s.releaseDate(ctx, item)
+		if err != nil {
+			if ctx.Err() != nil {
+				return result, ctx.Err()
+			}
+			log.Printf("Failed to check release of watchlist item %d (%s): %v", item.ID, item.Title, err)
+			continue
+		}
+		result.Checked++
+
+		if releaseDate == "" || releaseDate > today {
+			if err := s.db.Model(&item).Updates(map[string]interface{}{
+				"release_date":    releaseDate,
+				"last_checked_at": now,
+			}).Error; err != nil {
+				log.Printf("Failed to update watchlist item %d: %v", item.ID, err)
+			}
+			continue
+		}
+
+		if err := s.convert(item, releaseDate, genres, now); err != nil {
+			log.Printf("Failed to convert watchlist item %d (%s): %v", item.ID, item.Title, err)
+			continue
+		}
+		result.Converted++
+	}
+
+	return result, nil
+}
+
+// releaseDate returns the date the item can be requested from, or "" if unknown,
+// and its genres from the same TMDB details
+func (s *WatchlistService) releaseDate(ctx context.Context, item models.WatchlistItem) (string, []TMDBGenre, error) {
+	switch item.MediaType {
+	case models.MediaTypeMovie:
+		movie, err := s.tmdbService.GetMovieDetailsContext(ctx, item.TMDBId)
+		if err != nil {
+			return "", nil, err
+		}
+		return movie.ReleaseDates.HomeReleaseDate(), movie.Genres, nil
+	case models.MediaTypeTV:
+		tv, err := s.tmdbService.GetTVDetailsContext(ctx, item.TMDBId)
+		if err != nil {
+			return "", nil, err
+		}
+		return tv.FirstAirDate, tv.Genres, nil
+	default:
+		return "", nil, fmt.Errorf("unknown media type %q", item.MediaType)
+	}
+}
+
+// convert creates the request for a released item and notifies the user. If the user
+// already requested the title, the item is linked to that request instead.
+func (s *WatchlistService) convert(item models.WatchlistItem, releaseDate string, genres []TMDBGenre, now time.Time) error {
+	var request models.Request
+	created := false
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		request, created, err = createRequest(tx, item.UserID, item.User.IsAdmin, NewRequest{
+			Title:      item.Title,
+			Year:       item.Year,
+			MediaType:  item.MediaType,
+			TMDBId:     item.TMDBId,
+			Overview:   item.Overview,
+			PosterPath: item.PosterPath,
+			Notes:      fmt.Sprintf("Added from watchlist, released %s", releaseDate),
+			GenreIDs:   tmdbGenreIDs(genres),
+		})
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&item).Updates(map[string]interface{}{
+			"release_date":    releaseDate,
+			"last_checked_at": now,
+			"request_id":      request.ID,
+			"converted_at":    now,
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	if created {
+		logRequestCreated(s.auditService, request)
+	}
+
+	if s.notifier != nil {
+		notification := Notification{
+			Type:      NotificationWatchlistReleased,
+			UserIDs:   []uint{item.UserID},
+			RequestID: request.ID,
+			Subject:   fmt.Sprintf("%s is out", item.Title),
+			Message:   "It was on your watchlist and has been requested for you",
+		}
+		if !created {
+			notification.Message = "It was on your watchlist, you had already requested it"
+		}
+		if err := s.notifier.Notify(notification); err != nil {
+			log.Printf("Failed to send %s notification for request %d: %v", notification.Type, request.ID, err)
+		}
+	}
+
+	return nil
+}
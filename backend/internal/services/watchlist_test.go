@@ -0,0 +1,123 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jacob-fain/MRS/internal/models"
+	"github.com/jacob-fain/MRS/internal/testutil"
+)
+
+// watchlistTMDBService returns the given home release dates and genres for movies and first air dates for TV
+type watchlistTMDBService struct {
+	TMDBServiceInterface
+	homeReleases  map[int]string
+	firstAirDates map[int]string
+	genres        map[int][]TMDBGenre
+}
+
+func (w *watchlistTMDBService) GetMovieDetailsContext(ctx context.Context, movieID int) (*TMDBMovieDetails, error) {
+	details := &TMDBMovieDetails{ID: movieID, Genres: w.genres[movieID]}
+	if date, ok := w.homeReleases[movieID]; ok {
+		details.ReleaseDates.Results = []TMDBCountryReleaseDates{
+			{Country: "US", ReleaseDates: []TMDBReleaseDate{
+				{ReleaseDate: "2020-01-01T00:00:00.000Z", Type: tmdbReleaseTheatrical},
+				{ReleaseDate: date + "T00:00:00.000Z", Type: tmdbReleaseDigital},
+			}},
+		}
+	}
+	return details, nil
+}
+
+func (w *watchlistTMDBService) GetTVDetailsContext(ctx context.Context, tvID int) (*TMDBTVDetails, error) {
+	return &TMDBTVDetails{ID: tvID, FirstAirDate: w.firstAirDates[tvID]}, nil
+}
+
+func TestWatchlistService_Run(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	notifier := &recordingNotifier{}
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tmdb := &watchlistTMDBService{
+		homeReleases:  map[int]string{1: "2026-02-20", 2: "2026-06-01", 4: "2026-01-15", 5: "2026-02-01"},
+		firstAirDates: map[int]string{3: "2026-02-28"},
+		genres:        map[int][]TMDBGenre{1: {{ID: 28, Name: "Action"}, {ID: 878, Name: "Science Fiction"}}},
+	}
+	service := NewWatchlistService(db, tmdb, NewAuditService(db), notifier)
+
+	user := testutil.CreateTestUser(t, db, "user@example.com", "user", "pass", false)
+	admin := testutil.CreateTestUser(t, db, "admin@example.com", "admin", "pass", true)
+
+	existing := testutil.CreateTestRequest(t, db, user.ID, "Already Requested", models.MediaTypeMovie)
+
+	items := []models.WatchlistItem{
+		{UserID: user.ID, Title: "Released", Year: 2026, MediaType: models.MediaTypeMovie, TMDBId: 1},
+		{UserID: user.ID, Title: "Still In Theaters", Year: 2026, MediaType: models.MediaTypeMovie, TMDBId: 2},
+		{UserID: user.ID, Title: "New Show", Year: 2026, MediaType: models.MediaTypeTV, TMDBId: 3},
+		{UserID: user.ID, Title: "Already Requested", Year: 2026, MediaType: models.MediaTypeMovie, TMDBId: 4},
+		{UserID: admin.ID, Title: "Admin Pick", Year: 2026, MediaType: models.MediaTypeMovie, TMDBId: 5},
+	}
+	for i := range items {
+		testutil.AssertNoError(t, db.Create(&items[i]).Error)
+	}
+
+	result, err := service.Run(context.Background(), now)
+	testutil.AssertNoError(t, err)
+	testutil.AssertEqual(t, 5, result.Checked)
+	testutil.AssertEqual(t, 4, result.Converted)
+
+	// Released movie became a pending request
+	var released models.WatchlistItem
+	db.First(&released, items[0].ID)
+	testutil.AssertTrue(t, released.ConvertedAt != nil, "converted_at should be set")
+	testutil.AssertEqual(t, "2026-02-20", released.ReleaseDate)
+	var request models.Request
+	testutil.AssertNoError(t, db.First(&request, *released.RequestID).Error)
+	testutil.AssertEqual(t, "Released", request.Title)
+	testutil.AssertEqual(t, 1, request.TMDBId)
+	testutil.AssertEqual(t, models.StatusPending, request.Status)
+	var genres []models.RequestGenre
+	db.Where("request_id = ?", request.ID).Order("genre_id").Find(&genres)
+	testutil.AssertEqual(t, 2, len(genres))
+	testutil.AssertEqual(t, 878, genres[1].GenreID)
+
+	// Movie without a home release stays on the watchlist
+	var waiting models.WatchlistItem
+	db.First(&waiting, items[1].ID)
+	testutil.AssertTrue(t, waiting.ConvertedAt == nil, "unreleased item should not be converted")
+	testutil.AssertTrue(t, waiting.LastCheckedAt != nil, "last_checked_at should be set")
+	testutil.AssertEqual(t, "2026-06-01", waiting.ReleaseDate)
+
+	// Existing request is linked instead of duplicated
+	var linked models.WatchlistItem
+	db.First(&linked, items[3].ID)
+	testutil.AssertEqual(t, existing.ID, *linked.RequestID)
+	var count int64
+	db.Model(&models.Request{}).Where("user_id = ? AND title = ?", user.ID, "Already Requested").Count(&count)
+	testutil.AssertEqual(t, int64(1), count)
+
+	// Admins are auto-approved
+	var adminItem models.WatchlistItem
+	db.First(&adminItem, items[4].ID)
+	var adminRequest models.Request
+	db.First(&adminRequest, *adminItem.RequestID)
+	testutil.AssertEqual(t, models.StatusApproved, adminRequest.Status)
+
+	// Creation is audited for the three new requests, plus the admin's approval
+	var auditCount int64
+	db.Model(&models.AuditLog{}).Count(&auditCount)
+	testutil.AssertEqual(t, int64(4), auditCount)
+
+	// Every converted item notifies its user
+	testutil.AssertEqual(t, 4, len(notifier.notifications))
+	testutil.AssertEqual(t, NotificationWatchlistReleased, notifier.notifications[0].Type)
+	testutil.AssertEqual(t, user.ID, notifier.notifications[0].UserIDs[0])
+	testutil.AssertEqual(t, request.ID, notifier.notifications[0].RequestID)
+
+	// Converted items are not checked again
+	result, err = service.Run(context.Background(), now)
+	testutil.AssertNoError(t, err)
+	testutil.AssertEqual(t, 1, result.Checked)
+	testutil.AssertEqual(t, 0, result.Converted)
+}
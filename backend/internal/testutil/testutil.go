@@ -19,7 +19,7 @@ func SetupTestDB(t *testing.T) *gorm.DB {
 	}
 
 	// Run migrations
-	err = db.AutoMigrate(&models.User{}, &models.Request{})
+	err = db.AutoMigrate(&models.User{}, &models.Request{}, &models.AuditLog{}, &models.RequestComment{}, &models.RetentionReport{}, &models.TMDBCacheEntry{}, &models.WatchlistItem{}, &models.RequestGenre{})
 	if err != nil {
 		t.Fatalf("failed to migrate test database: %v", err)
 	}
@@ -76,6 +76,22 @@ func AssertEqual(t *testing.T, expected, actual interface{}) {
 	}
 }
 
+// AssertTrue checks if a condition holds
+func AssertTrue(t *testing.T, condition bool, msg string) {
+	t.Helper()
+	if !condition {
+		t.Error(msg)
+	}
+}
+
+// AssertNotNil checks if value is not nil
+func AssertNotNil(t *testing.T, value interface{}) {
+	t.Helper()
+	if value == nil {
+		t.Error("expected non-nil value")
+	}
+}
+
 // AssertNoError checks if error is nil
 func AssertNoError(t *testing.T, err error) {
 	t.Helper()
@@ -1,7 +1,14 @@
 import React, { useState } from 'react';
 import { useNavigate } from 'react-router-dom';
 
-const MediaCard = ({ media, onRequest, isRequested, showReleaseDate = false }) => {
+const MediaCard = ({
+  media,
+  onRequest,
+  isRequested,
+  showReleaseDate = false,
+  requestLabel = 'Request This',
+  requestedLabel = 'Request Pending',
+}) => {
   const navigate = useNavigate();
   const [imageLoading, setImageLoading] = useState(true);
   const [imageError, setImageError] = useState(false);
@@ -254,7 +261,7 @@ const MediaCard = ({ media, onRequest, isRequested, showReleaseDate = false }) =
               <svg className="w-4 h-4 flex-shrink-0" fill="currentColor" viewBox="0 0 20 20">
                 <path fillRule="evenodd" d="M10 18a8 8 0 100-16 8 8 0 000 16zm1-12a1 1 0 10-2 0v4a1 1 0 00.293.707l2.828 2.829a1 1 0 101.415-1.415L11 9.586V6z" clipRule="evenodd" />
               </svg>
-              <span>Request Pending</span>
+              <span>{requestedLabel}</span>
             </button>
           ) : (
             <button
@@ -264,7 +271,7 @@ const MediaCard = ({ media, onRequest, isRequested, showReleaseDate = false }) =
               <svg className="w-4 h-4 group-hover:scale-110 transition-transform flex-shrink-0" fill="currentColor" viewBox="0 0 20 20">
                 <path fillRule="evenodd" d="M10 3a1 1 0 011 1v5h5a1 1 0 110 2h-5v5a1 1 0 11-2 0v-5H4a1 1 0 110-2h5V4a1 1 0 011-1z" clipRule="evenodd" />
               </svg>
-              <span>Request This</span>
+              <span>{requestLabel}</span>
             </button>
           )}
         </div>
@@ -884,6 +884,7 @@ const AdminRequestCard = ({ request, onQuickAction, onAdminNotesUpdate, getStatu
                           {log.action === 'rejected' && <span className="text-red-400">✗</span>}
                           {log.action === 'completed' && <span className="text-green-400">✓</span>}
                           {log.action === 'notes_updated' && <span className="text-yellow-400">✎</span>}
+                          {log.action === 'updated' && <span className="text-yellow-400">✎</span>}
                           {log.action === 'deleted' && <span className="text-red-400">−</span>}
                         </div>
                         <div className="flex-1">
@@ -891,6 +892,16 @@ const AdminRequestCard = ({ request, onQuickAction, onAdminNotesUpdate, getStatu
                             <span className="font-medium text-blue-300">{log.user?.username || 'System'}</span>{' '}
                             {log.notes}
                           </p>
+                          {log.changes && log.changes.length > 0 && (
+                            <ul className="mt-0.5 text-gray-400">
+                              {log.changes.map((change) => (
+                                <li key={change.field}>
+                                  <span className="text-gray-500">{change.field.replace('_', ' ')}:</span>{' '}
+                                  {String(change.old ?? '—') || '—'} → {String(change.new ?? '—') || '—'}
+                                </li>
+                              ))}
+                            </ul>
+                          )}
                           <p className="text-gray-500 text-xs mt-0.5">
                             {new Date(log.created_at).toLocaleString()}
                           </p>
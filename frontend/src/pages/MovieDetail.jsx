@@ -58,6 +58,7 @@ const MovieDetail = () => {
         tmdb_id: parseInt(id),
         overview: mediaDetails.overview,
         poster_path: mediaDetails.poster_path,
+        genre_ids: mediaDetails.genres?.map((genre) => genre.id),
       });
       queryClient.invalidateQueries(['userRequests']);
     } catch (err) {
@@ -167,6 +167,7 @@ const Search = () => {
         tmdb_id: media.id,
         overview: media.overview,
         poster_path: media.poster_path,
+        genre_ids: media.genre_ids,
       });
       queryClient.invalidateQueries(['userRequests']);
     } catch (err) {
@@ -1,7 +1,8 @@
 import React, { useState } from 'react';
 import { useSearchParams } from 'react-router-dom';
-import { useQuery } from '@tanstack/react-query';
+import { useQuery, useQueryClient } from '@tanstack/react-query';
 import mediaService from '../services/media.service';
+import watchlistService from '../services/watchlist.service';
 import MediaCard from '../components/MediaCard';
 
 // Skeleton loading card
@@ -35,6 +36,38 @@ const Upcoming = () => {
     staleTime: 5 * 60 * 1000, // Cache for 5 minutes
   });
 
+  // Fetch the user's watchlist to mark saved titles
+  const queryClient = useQueryClient();
+  const { data: watchlistData } = useQuery({
+    queryKey: ['watchlist'],
+    queryFn: () => watchlistService.getWatchlist(),
+  });
+
+  const isOnWatchlist = (media) => {
+    return watchlistData?.items?.some(
+      item => item.tmdb_id === media.id && item.media_type === (media.media_type || mediaType)
+    );
+  };
+
+  // Upcoming titles can't be requested yet, they are requested automatically once released
+  const handleAddToWatchlist = async (media) => {
+    const releaseDate = media.release_date || media.first_air_date || '';
+    try {
+      await watchlistService.addToWatchlist({
+        title: media.title || media.name,
+        year: releaseDate ? parseInt(releaseDate.substring(0, 4)) : 0,
+        media_type: media.media_type || mediaType,
+        tmdb_id: media.id,
+        overview: media.overview,
+        poster_path: media.poster_path,
+        release_date: releaseDate,
+      });
+      queryClient.invalidateQueries(['watchlist']);
+    } catch (err) {
+      console.error('Failed to add to watchlist:', err);
+    }
+  };
+
   // Update URL when filters change
   React.useEffect(() => {
     const params = {};
@@ -117,7 +150,15 @@ const Upcoming = () => {
           <>
             <div className="grid grid-cols-1 sm:grid-cols-2 lg:grid-cols-3 xl:grid-cols-4 2xl:grid-cols-5 gap-6">
               {results.map((media) => (
-                <MediaCard key={media.id} media={media} showReleaseDate={true} />
+                <MediaCard
+                  key={media.id}
+                  media={media}
+                  showReleaseDate={true}
+                  onRequest={() => handleAddToWatchlist(media)}
+                  isRequested={isOnWatchlist(media)}
+                  requestLabel="Add to Watchlist"
+                  requestedLabel="On Watchlist"
+                />
               ))}
             </div>
 
@@ -21,15 +21,50 @@ class RequestService {
     return response.data;
   }
 
+  async previewImport(file, format) {
+    const formData = new FormData();
+    formData.append('file', file);
+    if (format) formData.append('format', format);
+    const response = await api.post('/requests/import', formData, {
+      headers: { 'Content-Type': 'multipart/form-data' },
+    });
+    return response.data;
+  }
+
+  async confirmImport(items, format) {
+    const response = await api.post('/requests/import/confirm', { items, format });
+    return response.data;
+  }
+
   async getRequestStats() {
     const response = await api.get('/requests/stats');
     return response.data;
   }
 
+  async getStatsOverview(params = {}) {
+    const response = await api.get('/stats/overview', { params });
+    return response.data;
+  }
+
+  async exportRequests(params = {}) {
+    const response = await api.get('/requests/export', { params, responseType: 'blob' });
+    return response.data;
+  }
+
   async getRequestAuditLogs(id) {
     const response = await api.get(`/requests/${id}/audit-logs`);
     return response.data;
   }
+
+  async getAuditLogs(params = {}) {
+    const response = await api.get('/audit-logs', { params });
+    return response.data;
+  }
+
+  async verifyAuditLogs() {
+    const response = await api.get('/audit-logs/verify');
+    return response.data;
+  }
 }
 
 export default new RequestService();
\ No newline at end of file
@@ -0,0 +1,20 @@
+import api from './api';
+
+class WatchlistService {
+  async getWatchlist() {
+    const response = await api.get('/watchlist');
+    return response.data;
+  }
+
+  async addToWatchlist(item) {
+    const response = await api.post('/watchlist', item);
+    return response.data;
+  }
+
+  async removeFromWatchlist(id) {
+    const response = await api.delete(`/watchlist/${id}`);
+    return response.data;
+  }
+}
+
+export default new WatchlistService();